@@ -3,7 +3,12 @@ package cmd
 import (
 	"fmt"
 	"github.com/maczg/kube-event-generator/cmd/cluster"
+	"github.com/maczg/kube-event-generator/cmd/record"
+	"github.com/maczg/kube-event-generator/cmd/report"
+	"github.com/maczg/kube-event-generator/cmd/scenario"
+	"github.com/maczg/kube-event-generator/cmd/serve"
 	"github.com/maczg/kube-event-generator/cmd/simulation"
+	"github.com/maczg/kube-event-generator/cmd/sweep"
 	"github.com/maczg/kube-event-generator/pkg/logger"
 	"github.com/spf13/cobra"
 	"os"
@@ -56,7 +61,12 @@ func (app *App) setupCommands() {
 	// Add sub-commands.
 	app.rootCmd.AddCommand(
 		cluster.NewCommand(app.logger),
+		record.NewCommand(app.logger),
+		report.NewCommand(app.logger),
+		scenario.NewCommand(app.logger),
+		serve.NewCommand(app.logger),
 		simulation.NewCommand(app.logger),
+		sweep.NewCommand(app.logger),
 		app.versionCommand(),
 		app.completionCommand(),
 	)
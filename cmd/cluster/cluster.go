@@ -3,6 +3,8 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/maczg/kube-event-generator/pkg/kubernetes"
 	"github.com/maczg/kube-event-generator/pkg/logger"
 	"github.com/spf13/cobra"
@@ -45,14 +47,37 @@ func newResetCommand(log *logger.Logger) *cobra.Command {
 	var nodes bool
 	var scheduler bool
 	var schedulerUrl string
+	var simulationID string
+	var selector string
+	var wait bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "reset",
 		Short: "Reset cluster state",
-		Long:  "Remove all pods and optionally other resources from the cluster",
+		Long: `Remove all pods and optionally other resources from the cluster.
+With --simulation or --selector only the workloads, pods, namespaces and priority classes matching the
+labels and created by keg are deleted, and the scheduler is only reset if --scheduler is set explicitly. keg labels every
+object it creates with app.kubernetes.io/managed-by=kube-event-generator, kube-event-generator/simulation,
+kube-event-generator/scenario, kube-event-generator/event-name and kube-event-generator/event-id.
+With --dry-run the matching objects are listed without deleting them.`,
+		Example: `  keg cluster reset --force
+  keg cluster reset --simulation sim-web-15_04_05_020106 --wait
+  keg cluster reset --selector app.kubernetes.io/managed-by=kube-event-generator --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 
+			if simulationID != "" {
+				selector = kubernetes.SimulationSelector(simulationID)
+			}
+			if selector != "" {
+				resetScheduler := scheduler && cmd.Flags().Changed("scheduler") && !dryRun
+				return runSelectiveReset(ctx, log, selector, wait, dryRun, resetScheduler, schedulerUrl)
+			}
+			if wait || dryRun {
+				return fmt.Errorf("--wait and --dry-run require --simulation or --selector")
+			}
+
 			if !force {
 				log.Warn("This will delete all pods in the cluster. Use --force to confirm.")
 				return nil
@@ -66,6 +91,11 @@ func newResetCommand(log *logger.Logger) *cobra.Command {
 	cmd.Flags().BoolVar(&pods, "pods", true, "Reset pods in the cluster")
 	cmd.Flags().BoolVar(&nodes, "nodes", false, "Reset nodes in the cluster (not implemented)")
 	cmd.Flags().BoolVar(&scheduler, "scheduler", true, "Reset scheduler weights and configuration")
+	cmd.Flags().StringVar(&simulationID, "simulation", "", "Delete only the objects created by this simulation")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Delete only the objects matching this label selector")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the deleted objects are gone")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the objects that would be deleted")
+	cmd.MarkFlagsMutuallyExclusive("simulation", "selector")
 
 	return cmd
 }
@@ -94,5 +124,43 @@ func runReset(ctx context.Context, log *logger.Logger, pods, nodes, scheduler bo
 	return nil
 }
 
+// waitTimeout bounds the wait for the deleted objects to be gone.
+const waitTimeout = 5 * time.Minute
+
+// runSelectiveReset deletes the objects matching a label selector.
+func runSelectiveReset(ctx context.Context, log *logger.Logger, selector string, wait, dryRun, scheduler bool, schedulerUrl string) error {
+	clientset, err := kubernetes.GetClientset()
+	if err != nil {
+		return err
+	}
+	objs, err := kubernetes.ResetSelected(ctx, clientset, log, selector, dryRun)
+	if dryRun {
+		for _, o := range objs {
+			fmt.Println(o)
+		}
+		log.Infof("%d object(s) match %s", len(objs), selector)
+		return err
+	}
+	if err != nil {
+		log.Errorf("Failed to delete the objects matching %s: %v", selector, err)
+		return err
+	}
+	log.Infof("Deleted %d object(s) matching %s", len(objs), selector)
+
+	if wait {
+		waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+		defer cancel()
+		if err := kubernetes.WaitDeleted(waitCtx, clientset, objs); err != nil {
+			return err
+		}
+		log.Info("All deleted objects are gone.")
+	}
+
+	if scheduler {
+		return runReset(ctx, log, false, false, true, schedulerUrl)
+	}
+	return nil
+}
+
 // ClusterCmd is deprecated, use NewCommand instead.
 var ClusterCmd = NewCommand(logger.Default())
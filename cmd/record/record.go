@@ -0,0 +1,85 @@
+package record
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/recorder"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/spf13/cobra"
+)
+
+// NewCommand creates the record command.
+func NewCommand(log *logger.Logger) *cobra.Command {
+	var duration time.Duration
+	var namespaces []string
+	var selector string
+	var anonymize bool
+	var nodes bool
+	var name string
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "record",
+		Short: "Record the pod activity of a cluster as a scenario",
+		Long: `Watch the pods created and deleted in a live cluster and write them as a scenario, to replay
+production patterns against the simulator. Arrival times are relative to the start of the recording
+and evict times are the observed lifetimes; pods still running at the end have no evict time.
+Pod specs are sanitized: status, node, UID and the fields set by the API server are stripped.
+Pods existing when the recording starts are ignored. Interrupt with Ctrl-C to stop early and keep
+what was recorded.`,
+		Example: `  keg record --duration 1h --namespace team-a --namespace team-b -o recorded.yaml
+  keg record --duration 30m --selector app=batch --anonymize -o batch.yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientset, err := kubernetes.GetClientset()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			r := recorder.NewRecorder(clientset, log,
+				recorder.WithNamespaces(namespaces...),
+				recorder.WithLabelSelector(selector),
+				recorder.WithAnonymize(anonymize),
+				recorder.WithNodes(nodes))
+			scenario, err := r.Record(ctx, duration)
+			if err != nil {
+				return err
+			}
+			if name != "" {
+				scenario.Metadata.Name = name
+			}
+			log.Infof("recorded %d pod(s)", len(scenario.Events.Pods))
+			return write(scenario, outputFile)
+		},
+	}
+
+	cmd.Flags().DurationVar(&duration, "duration", time.Hour, "Duration of the recording, 0 records until interrupted")
+	cmd.Flags().StringArrayVarP(&namespaces, "namespace", "n", nil, "Namespace to record, can be repeated (default all namespaces)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Label selector of the pods to record")
+	cmd.Flags().BoolVar(&anonymize, "anonymize", false, "Replace the names and images of pods, namespaces, nodes and containers")
+	cmd.Flags().BoolVar(&nodes, "nodes", true, "Record the nodes of the cluster under cluster.nodes")
+	cmd.Flags().StringVar(&name, "name", "", "Name of the recorded scenario")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write the scenario to a file instead of stdout")
+	return cmd
+}
+
+// write writes the scenario to the file, or to stdout if no file is given.
+func write(scenario *simulation.Scenario, file string) error {
+	if file != "" {
+		return simulation.WriteScenario(file, scenario)
+	}
+	data, err := simulation.MarshalScenario(scenario)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
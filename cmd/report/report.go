@@ -0,0 +1,57 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+// reportFile is the default name of the report, written into the results directory.
+const reportFile = "report.html"
+
+// NewCommand creates the report command.
+func NewCommand(log *logger.Logger) *cobra.Command {
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "report <results-dir>",
+		Short: "Render the results of a simulation as an HTML report",
+		Long: `Render the results directory of a simulation as a single HTML file with no external resource, to
+share or attach to a review. The report shows the allocation and allocation ratio of each node over
+time, the pending queue length, the distributions of the pending and running durations and the
+timeline of the pod events. For a sweep, pass the directory of one of its runs.`,
+		Example: `  keg report results/sim-web-10_00_00_010125
+  keg report results/web-20250101_100000/run-003 -o run-003.html`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+			results, err := report.Load(dir)
+			if err != nil {
+				return err
+			}
+			if outputFile == "" {
+				outputFile = filepath.Join(dir, reportFile)
+			}
+
+			f, err := os.Create(outputFile)
+			if err != nil {
+				return err
+			}
+			if err := report.Write(f, results); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			log.Infof("report written to %s", outputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Report file (default <results-dir>/report.html)")
+	return cmd
+}
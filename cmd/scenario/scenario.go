@@ -0,0 +1,332 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/generator"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/maczg/kube-event-generator/pkg/trace"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// NewCommand creates the scenario command.
+func NewCommand(log *logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "scenario",
+		Aliases: []string{"scn"},
+		Short:   "Scenario commands",
+		Long:    "Commands for authoring and checking scenario files",
+	}
+	// sub-commands.
+	cmd.AddCommand(
+		newValidateCommand(log),
+		newSchemaCommand(),
+		newTimelineCommand(log),
+		newImportCommand(log),
+		newGenerateCommand(log),
+	)
+	return cmd
+}
+
+// fileDiagnostic is a diagnostic reported for a given scenario file.
+type fileDiagnostic struct {
+	File string `json:"file"`
+	simulation.Diagnostic
+}
+
+// newValidateCommand creates the validate sub-command.
+func newValidateCommand(log *logger.Logger) *cobra.Command {
+	var output string
+	var strict bool
+	var knownPlugins []string
+	var params []string
+
+	cmd := &cobra.Command{
+		Use:   "validate FILE...",
+		Short: "Validate scenario files",
+		Long: `Validate the structure and semantics of one or more scenario files.
+Each problem is reported with its YAML path and line number. The command exits with a
+non-zero status if any error is found (or any warning with --strict), so it can gate CI.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unknown output format %q, expected text or json", output)
+			}
+			overrides, err := simulation.ParseParamOverrides(params)
+			if err != nil {
+				return err
+			}
+
+			all := make([]fileDiagnostic, 0)
+			errorCount, warningCount := 0, 0
+
+			for _, file := range args {
+				diags, err := simulation.ValidateFile(file,
+					simulation.WithKnownPlugins(knownPlugins...),
+					simulation.WithLoadOpts(simulation.WithParams(overrides)))
+				if err != nil {
+					log.Errorf("failed to read scenario file %s: %v", file, err)
+					return err
+				}
+				errorCount += diags.Count(simulation.SeverityError)
+				warningCount += diags.Count(simulation.SeverityWarning)
+				for _, d := range diags {
+					all = append(all, fileDiagnostic{File: file, Diagnostic: d})
+				}
+			}
+
+			if output == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(all); err != nil {
+					return err
+				}
+			} else {
+				for _, d := range all {
+					fmt.Printf("%s:%s\n", d.File, d.Diagnostic.String())
+				}
+				fmt.Printf("%d file(s) checked: %d error(s), %d warning(s)\n", len(args), errorCount, warningCount)
+			}
+
+			if errorCount > 0 || (strict && warningCount > 0) {
+				return fmt.Errorf("scenario validation failed with %d error(s) and %d warning(s)", errorCount, warningCount)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format (text, json)")
+	cmd.Flags().BoolVar(&strict, "strict", false, "Treat warnings as errors")
+	cmd.Flags().StringSliceVar(&knownPlugins, "known-plugins", nil, "Additional scheduler plugin names accepted in weights")
+	cmd.Flags().StringArrayVar(&params, "set", nil, "Override a scenario parameter (name=value), can be repeated")
+	return cmd
+}
+
+// newSchemaCommand creates the schema sub-command.
+func newSchemaCommand() *cobra.Command {
+	var outputFile string
+
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the scenario JSON Schema",
+		Long: `Print the JSON Schema of the scenario format. Point your editor to it for autocomplete
+and inline validation, e.g. with the YAML language server:
+
+  # yaml-language-server: $schema=./scenario.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := simulation.MarshalJSONSchema()
+			if err != nil {
+				return err
+			}
+			if outputFile != "" {
+				return os.WriteFile(outputFile, data, 0644)
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write the schema to a file instead of stdout")
+	return cmd
+}
+
+// newTimelineCommand creates the timeline sub-command.
+func newTimelineCommand(log *logger.Logger) *cobra.Command {
+	var output string
+	var params []string
+	var seed int64
+
+	cmd := &cobra.Command{
+		Use:   "timeline FILE",
+		Short: "Print the planned timeline of a scenario",
+		Long: `Expand the pod events and print the planned events of a scenario with their arrival, target and
+planned eviction, and the resources requested over time against the capacity of the cluster nodes
+declared in the scenario. No cluster is needed.`,
+		Example: `  keg scenario timeline scenario.yaml --seed 42
+  keg scenario timeline scenario.yaml --set replicas=200 -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			overrides, err := simulation.ParseParamOverrides(params)
+			if err != nil {
+				return err
+			}
+			var seedOverride *int64
+			if cmd.Flags().Changed("seed") {
+				seedOverride = &seed
+			}
+			return printTimeline(log, args[0], overrides, seedOverride, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+	cmd.Flags().StringArrayVar(&params, "set", nil, "Override a scenario parameter (name=value), can be repeated")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Seed of the scenario distributions (default the scenario seed, or random)")
+	return cmd
+}
+
+// printTimeline validates and loads the scenario file and prints its timeline.
+func printTimeline(log *logger.Logger, file string, params map[string]string, seed *int64, output string) error {
+	diags, err := simulation.ValidateFile(file, simulation.WithLoadOpts(simulation.WithParams(params)))
+	if err != nil {
+		return err
+	}
+	for _, d := range diags {
+		log.Warnf("%s:%s", file, d.String())
+	}
+	if diags.HasErrors() {
+		return fmt.Errorf("scenario %s is invalid, run 'keg scenario validate' for details", file)
+	}
+
+	scenario, err := simulation.LoadFromYaml(file, simulation.WithParams(params))
+	if err != nil {
+		return err
+	}
+	if seed != nil {
+		scenario.Seed = seed
+	}
+	timeline, err := scenario.Timeline()
+	if err != nil {
+		return err
+	}
+	return timeline.Write(os.Stdout, output)
+}
+
+// newImportCommand creates the import sub-command.
+func newImportCommand(log *logger.Logger) *cobra.Command {
+	var format string
+	var outputFile string
+	var name string
+	var from, to time.Duration
+	var sample float64
+	var seed int64
+	var limit int
+	var nodes int
+	var nodeCPU, nodeMemory string
+	var image string
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Convert a public cluster trace into a scenario",
+		Long: `Convert a public cluster trace CSV into a scenario. Submission times become arrival times and task
+durations eviction times. CPU and memory requests, fractions of a trace machine, are scaled to the
+nodes of the target cluster, which is added to the scenario.
+
+Supported formats:
+  google-borg  task_events table of the Google cluster data 2011
+  alibaba      batch_task table of the Alibaba cluster trace 2018
+  azure        vmtable of the Azure public dataset`,
+		Example: `  keg scenario import task_events.csv --format google-borg --from 1h --to 2h --sample 0.01 -o borg.yaml
+  keg scenario import batch_task.csv --format alibaba --limit 500 --nodes 20 --node-cpu 8 --node-memory 32Gi`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := trace.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+			cpu, err := resource.ParseQuantity(nodeCPU)
+			if err != nil {
+				return fmt.Errorf("invalid --node-cpu %q: %w", nodeCPU, err)
+			}
+			memory, err := resource.ParseQuantity(nodeMemory)
+			if err != nil {
+				return fmt.Errorf("invalid --node-memory %q: %w", nodeMemory, err)
+			}
+			if name == "" {
+				name = string(f)
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			tasks, err := trace.Parse(file, f)
+			if err != nil {
+				return fmt.Errorf("failed to parse trace %s: %w", args[0], err)
+			}
+			scenario, err := trace.ToScenario(tasks,
+				trace.WithName(name),
+				trace.WithWindow(from, to),
+				trace.WithSample(sample, seed),
+				trace.WithLimit(limit),
+				trace.WithCluster(nodes, cpu, memory),
+				trace.WithImage(image))
+			if err != nil {
+				return err
+			}
+			log.Infof("imported %d of %d task(s) from %s", len(scenario.Events.Pods), len(tasks), args[0])
+			return writeScenario(scenario, outputFile)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", "Trace format (google-borg, alibaba, azure)")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write the scenario to a file instead of stdout")
+	cmd.Flags().StringVar(&name, "name", "", "Name of the scenario and prefix of the pod names (default the format)")
+	cmd.Flags().DurationVar(&from, "from", 0, "Keep the tasks submitted from this trace time")
+	cmd.Flags().DurationVar(&to, "to", 0, "Keep the tasks submitted before this trace time (default the end of the trace)")
+	cmd.Flags().Float64Var(&sample, "sample", 1, "Fraction of the tasks to keep, sampled at random")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Seed of the sampling")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of tasks to keep (default no limit)")
+	cmd.Flags().IntVar(&nodes, "nodes", 10, "Number of nodes of the target cluster")
+	cmd.Flags().StringVar(&nodeCPU, "node-cpu", "4", "CPU of a target node, a trace machine is scaled to it")
+	cmd.Flags().StringVar(&nodeMemory, "node-memory", "16Gi", "Memory of a target node, a trace machine is scaled to it")
+	cmd.Flags().StringVar(&image, "image", trace.DefaultImage, "Container image of the pods")
+	_ = cmd.MarkFlagRequired("format")
+	return cmd
+}
+
+// newGenerateCommand creates the generate sub-command.
+func newGenerateCommand(log *logger.Logger) *cobra.Command {
+	var outputFile string
+	var seed int64
+
+	cmd := &cobra.Command{
+		Use:   "generate SPEC",
+		Short: "Generate a scenario from a workload spec",
+		Long: `Generate a full scenario from a compact spec of workload classes with mix ratios, an arrival
+distribution, lifetime and request distributions per class, and a cluster size. Every draw is fixed in
+the generated scenario, so it can be checked into git and every run replays the same pods.`,
+		Example: `  keg scenario generate workload.yaml -o scenario.yaml
+  keg scenario generate workload.yaml --seed 7 -o scenario-7.yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			spec, err := generator.LoadSpec(args[0])
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("seed") {
+				spec.Seed = seed
+			}
+			scenario, err := generator.Generate(spec)
+			if err != nil {
+				return fmt.Errorf("invalid generator spec %s: %w", args[0], err)
+			}
+			log.Infof("generated %d pod(s) with seed %d", len(scenario.Events.Pods), spec.Seed)
+			return writeScenario(scenario, outputFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write the scenario to a file instead of stdout")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Seed of the generator (default the spec seed)")
+	return cmd
+}
+
+// writeScenario writes the scenario to the file, or to stdout if no file is given.
+func writeScenario(scenario *simulation.Scenario, file string) error {
+	if file != "" {
+		return simulation.WriteScenario(file, scenario)
+	}
+	data, err := simulation.MarshalScenario(scenario)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
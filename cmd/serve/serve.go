@@ -0,0 +1,135 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/maczg/kube-event-generator/pkg/server"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/maczg/kube-event-generator/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout bounds the time the runs have to end once the server is interrupted.
+const shutdownTimeout = 2 * time.Minute
+
+// NewCommand creates the serve command.
+func NewCommand(log *logger.Logger) *cobra.Command {
+	var addr string
+	var resultsDir string
+	var concurrency int
+	var schedulerUrl string
+	var offlineMode bool
+	var strategy string
+	var isolate bool
+	var cleanup bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run simulations submitted over an HTTP API",
+		Long: `Start a long-running server accepting scenarios over a REST API, to drive keg from notebooks
+and CI pipelines. Submitted scenarios are validated, queued and run one at a time, or --concurrency
+at a time. The API exposes the status and live stats of each run, pauses, resumes and stops it, and
+serves its results once it ends:
+
+  POST /api/v1/simulations                      submit a YAML scenario (?set=name=value&seed=N)
+  GET  /api/v1/simulations                      list the runs
+  GET  /api/v1/simulations/{id}                 status of a run
+  GET  /api/v1/simulations/{id}/stats           live stats of a running run
+  POST /api/v1/simulations/{id}/pause           pause a running run
+  POST /api/v1/simulations/{id}/resume          resume a paused run
+  POST /api/v1/simulations/{id}/stop            stop a queued or running run
+  GET  /api/v1/simulations/{id}/results         zip of the results of an ended run
+  GET  /api/v1/simulations/{id}/results/{file}  a single results file
+
+The API has no authentication and listens on localhost by default; expose it with --addr only on a
+trusted network. Submitted scenarios cannot import other files.
+With --offline every run has its own in-process fake cluster made of the scenario nodes. Concurrent
+runs against a cluster need --isolate, so that each run only accounts its own pods; they share the
+scheduler, so scenarios with scheduler events are refused when --concurrency is above 1.
+On SIGINT or SIGTERM the server stops accepting scenarios and stops the runs. A second signal exits
+immediately.`,
+		Example: `  keg serve --offline --concurrency 4
+  keg serve --addr 0.0.0.0:9090 --isolate --concurrency 2 --cleanup
+  curl --data-binary @scenario.yaml 'localhost:8080/api/v1/simulations?set=rate=2&seed=42'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if concurrency < 1 {
+				return fmt.Errorf("--concurrency must be at least 1, got %d", concurrency)
+			}
+			if concurrency > 1 && !offlineMode && !isolate {
+				return errors.New("concurrent runs against a cluster need --isolate")
+			}
+
+			var clusterFunc server.ClusterFunc
+			if offlineMode {
+				if _, err := offline.ParseStrategy(strategy); err != nil {
+					return err
+				}
+				clusterFunc = func(ctx context.Context, scenario *simulation.Scenario) (server.Cluster, error) {
+					cluster, err := util.NewOfflineCluster(ctx, log, scenario, strategy)
+					if err != nil {
+						return server.Cluster{}, err
+					}
+					return server.Cluster{Clientset: cluster.Clientset(), Manager: cluster.Scheduler()}, nil
+				}
+			} else {
+				clientset, err := kubernetes.GetClientset()
+				if err != nil {
+					return err
+				}
+				target := server.Cluster{Clientset: clientset, Manager: kubernetes.NewHTTPKubeSchedulerManager(schedulerUrl)}
+				clusterFunc = func(ctx context.Context, scenario *simulation.Scenario) (server.Cluster, error) {
+					return target, nil
+				}
+			}
+
+			opts := []server.ServerOpt{server.WithResultsDir(resultsDir), server.WithConcurrency(concurrency)}
+			if !offlineMode {
+				opts = append(opts, server.WithSharedScheduler())
+			}
+			if cleanup {
+				opts = append(opts, server.WithCleanup())
+			}
+			if isolate {
+				opts = append(opts, server.WithSimulationOpts(simulation.WithIsolatedNamespace()))
+			}
+			srv := server.NewServer(log, clusterFunc, opts...)
+			httpServer := &http.Server{Addr: addr, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+			stopSignals := util.OnInterrupt(func() {
+				log.Warnln("interrupted, stopping the runs (interrupt again to force exit)")
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+					defer cancel()
+					if err := srv.Shutdown(ctx); err != nil {
+						log.Errorf("failed to stop the runs: %v", err)
+					}
+					_ = httpServer.Shutdown(ctx)
+				}()
+			})
+			defer stopSignals()
+
+			log.Infof("serving the simulation API on %s, results written to %s", addr, resultsDir)
+			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8080", "Address the API listens on")
+	cmd.Flags().StringVar(&resultsDir, "results-dir", "results/serve", "Directory of the results of the runs, one subdirectory per run")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of runs executed at once")
+	cmd.Flags().StringVar(&schedulerUrl, "scheduler-url", "http://localhost:1212/api/v1/schedulerconfiguration", "URL of the scheduler configuration API")
+	cmd.Flags().BoolVar(&offlineMode, "offline", false, "Run each scenario against its own in-process fake cluster")
+	cmd.Flags().StringVar(&strategy, "strategy", string(offline.StrategyLeastAllocated), "Scoring strategy of the offline scheduler (least-allocated, most-allocated)")
+	cmd.Flags().BoolVar(&isolate, "isolate", false, "Run each simulation in its own namespace, deleted when it ends")
+	cmd.Flags().BoolVar(&cleanup, "cleanup", false, "Delete the objects created by each run when it ends")
+	return cmd
+}
@@ -1,11 +1,23 @@
 package simulation
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/maczg/kube-event-generator/pkg/dashboard"
 	"github.com/maczg/kube-event-generator/pkg/kubernetes"
 	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/multicluster"
+	"github.com/maczg/kube-event-generator/pkg/offline"
 	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/maczg/kube-event-generator/pkg/sweep"
+	"github.com/maczg/kube-event-generator/pkg/util"
 	"github.com/spf13/cobra"
+	k8s "k8s.io/client-go/kubernetes"
+	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // NewCommand creates the cluster command.
@@ -27,40 +39,396 @@ func NewCommand(log *logger.Logger) *cobra.Command {
 func newStartCommand(log *logger.Logger) *cobra.Command {
 	var scenarioFile string
 	var saveMetrics bool
+	var params []string
+	var repeat int
+	var seed int64
+	var schedulerUrl string
+	var resetPods bool
+	var dryRun bool
+	var output string
+	var offlineMode bool
+	var strategy string
+	var termination util.TerminationFlags
+	var cleanup bool
+	var isolate bool
+	var tui bool
+	var contexts []string
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start a simulation",
-		Long:  "Start a simulation based on a predefined scenario",
+		Long: `Start a simulation based on a predefined scenario.
+With --repeat N the scenario runs N times, each repetition with a seed derived from --seed, and the
+mean and 95% confidence interval of the key metrics are written to aggregate.csv.
+With --dry-run the planned timeline is printed without connecting to a cluster.
+With --offline the scenario runs against an in-process fake cluster made of the scenario nodes,
+with a built-in scheduler: no cluster, KWOK or scheduler simulator is needed.
+The simulation ends once every pod with an evict time is deleted, or when the first end condition
+set by --duration, --timeout, --until-dispatched, --pending-idle or the scenario termination is met.
+On SIGINT or SIGTERM the simulation stops dispatching events, its stats are exported, the objects it
+created are deleted with --cleanup and the scheduler configuration found at start is restored.
+A second signal exits immediately.
+With --isolate the simulation runs in its own namespace, keg-<simulation id>-<suffix>, deleted when it ends:
+the pods of the cluster are left alone and only the pods of the simulation are accounted.
+With --tui a live dashboard shows the node allocations, the pending queue, the events dispatched and
+the recent failures instead of the logs. p pauses and resumes the simulation, q stops it like SIGINT.
+--context runs against a context of the kubeconfig instead of the current one. Repeated, the scenario
+runs against every context in parallel: the simulations share a seed and start together, so the same
+pods arrive at the same time on every cluster. Each context may set the URL of its scheduler
+configuration API as context=url, it defaults to --scheduler-url. The results of each cluster and
+comparison.csv, one row per cluster, are written to results/<scenario>-<time>.`,
+		Example: `  keg simulation start --scenario scenario.yaml --dry-run
+  keg simulation start --scenario scenario.yaml --seed 42
+  keg simulation start --scenario scenario.yaml --offline --strategy most-allocated
+  keg simulation start --scenario scenario.yaml --duration 10m --pending-idle 30s
+  keg simulation start --scenario scenario.yaml --repeat 10 --seed 42
+  keg simulation start --scenario scenario.yaml --isolate
+  keg simulation start --scenario scenario.yaml --offline --tui
+  keg simulation start --scenario scenario.yaml --context upstream --context fork=http://localhost:1213/api/v1/schedulerconfiguration`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			overrides, err := simulation.ParseParamOverrides(params)
+			if err != nil {
+				return err
+			}
 
-			scenario, err := simulation.LoadFromYaml(scenarioFile)
+			diags, err := simulation.ValidateFile(scenarioFile, simulation.WithLoadOpts(simulation.WithParams(overrides)))
 			if err != nil {
-				log.Errorf("failed to load scenario from file %s: %v", scenarioFile, err)
+				log.Errorf("failed to read scenario file %s: %v", scenarioFile, err)
 				return err
 			}
+			for _, d := range diags {
+				log.Warnf("%s:%s", scenarioFile, d.String())
+			}
+			if diags.HasErrors() {
+				return fmt.Errorf("scenario %s is invalid, run 'keg scenario validate' for details", scenarioFile)
+			}
 
-			clientset, err := kubernetes.GetClientset()
+			if repeat < 1 {
+				return fmt.Errorf("--repeat must be at least 1, got %d", repeat)
+			}
+			clusters, err := parseContexts(contexts, schedulerUrl)
 			if err != nil {
-				return nil
+				return err
+			}
+			if len(clusters) > 0 && offlineMode {
+				return errors.New("--context selects live clusters, it cannot be used with --offline")
 			}
-			sim := simulation.NewSimulation(scenario, clientset, kubernetes.NewHTTPKubeSchedulerManager("http://localhost:1212"), log)
-			if err := sim.Start(cmd.Context()); err != nil {
-				log.Errorf("failed to start simulation: %v", err)
+			if len(clusters) > 1 && repeat > 1 {
+				return errors.New("--repeat runs against a single cluster, it cannot be used with several --context")
+			}
+			if tui && !dryRun {
+				if repeat > 1 {
+					return errors.New("--tui shows a single simulation, it cannot be used with --repeat")
+				}
+				if len(clusters) > 1 {
+					return errors.New("--tui shows a single simulation, it cannot be used with several --context")
+				}
+				if !dashboard.Supported() {
+					return dashboard.ErrNoTerminal
+				}
+			}
+
+			scenario, err := simulation.LoadFromYaml(scenarioFile, simulation.WithParams(overrides))
+			if err != nil {
+				log.Errorf("failed to load scenario from file %s: %v", scenarioFile, err)
 				return err
 			}
-			if saveMetrics {
-				stats := sim.GetStats()
-				err = stats.ExportCSV("results/" + strings.ReplaceAll(strings.ToLower(sim.GetID()), " ", "_"))
+			if cmd.Flags().Changed("seed") {
+				scenario.Seed = &seed
+			}
+
+			scenario.Termination = scenario.Termination.Merge(termination.Termination())
+
+			if dryRun {
+				timeline, err := scenario.Timeline()
 				if err != nil {
 					return err
 				}
+				return timeline.Write(os.Stdout, output)
+			}
+
+			var simOpts []simulation.SimulationOpt
+			if isolate {
+				simOpts = append(simOpts, simulation.WithIsolatedNamespace())
+			}
+
+			if len(clusters) > 1 {
+				dir := runDir(scenario)
+				opts := []multicluster.RunnerOpt{
+					multicluster.WithOutputDir(dir),
+					multicluster.WithParams(overrides),
+					multicluster.WithTermination(termination.Termination()),
+					multicluster.WithSimulationOpts(simOpts...),
+					multicluster.WithCleanup(cleanup),
+				}
+				if scenario.Seed != nil {
+					opts = append(opts, multicluster.WithSeed(*scenario.Seed))
+				}
+				targets := make([]multicluster.Cluster, 0, len(clusters))
+				for _, c := range clusters {
+					clientset, err := kubernetes.GetClientsetForContext(c.name)
+					if err != nil {
+						return fmt.Errorf("context %s: %w", c.name, err)
+					}
+					targets = append(targets, multicluster.Cluster{
+						Name:      c.name,
+						Clientset: clientset,
+						Manager:   kubernetes.NewHTTPKubeSchedulerManager(c.schedulerURL),
+					})
+				}
+				return runClusters(cmd.Context(), log, multicluster.NewRunner(log, targets, opts...), scenarioFile, dir)
+			}
+
+			var clientset k8s.Interface
+			var manager kubernetes.SchedulerManager
+			var reset sweep.ResetFunc
+			if offlineMode {
+				cluster, err := util.NewOfflineCluster(cmd.Context(), log, scenario, strategy)
+				if err != nil {
+					return err
+				}
+				clientset, manager, reset = cluster.Clientset(), cluster.Scheduler(), cluster.Reset
+			} else {
+				kubeContext := ""
+				if len(clusters) == 1 {
+					kubeContext, schedulerUrl = clusters[0].name, clusters[0].schedulerURL
+				}
+				if clientset, err = kubernetes.GetClientsetForContext(kubeContext); err != nil {
+					return err
+				}
+				httpManager := kubernetes.NewHTTPKubeSchedulerManager(schedulerUrl)
+				manager = httpManager
+				reset = httpManager.ResetToDefaults
+			}
+
+			if repeat > 1 {
+				dir := runDir(scenario)
+				opts := []sweep.RunnerOpt{
+					sweep.WithOutputDir(dir),
+					sweep.WithBaseParams(overrides),
+					sweep.WithRepetitions(repeat),
+					sweep.WithReset(reset),
+					sweep.WithTermination(termination.Termination()),
+					sweep.WithSimulationOpts(simOpts...),
+					// an offline cluster is reset instead
+					sweep.WithCleanup(resetPods && !offlineMode),
+				}
+				if scenario.Seed != nil {
+					opts = append(opts, sweep.WithSeed(*scenario.Seed))
+				}
+				return runRepetitions(cmd.Context(), log, sweep.NewRunner(log, clientset, manager, opts...), scenarioFile, dir, reset)
+			}
+
+			log.Infof("simulation ends on: %s", scenario.Termination)
+			sim := simulation.NewSimulation(scenario, clientset, manager, log, simOpts...)
+			var interrupted atomic.Bool
+			stopSignals := util.OnInterrupt(func() {
+				log.Warnln("interrupted, stopping the simulation (interrupt again to force exit)")
+				interrupted.Store(true)
+				go func() { _ = sim.Stop(context.Background()) }()
+			})
+			defer stopSignals()
+
+			var stopDashboard func()
+			if tui {
+				stopDashboard = runDashboard(cmd.Context(), log, sim, scenario.Metadata.Name+" · "+sim.GetID())
+			}
+			runErr := sim.Start(cmd.Context())
+			if stopDashboard != nil {
+				stopDashboard()
+			}
+			if runErr != nil {
+				log.Errorf("failed to start simulation: %v", runErr)
+			} else {
+				log.Infof("simulation ended: %s", sim.EndReason())
+			}
+
+			// the cleanup runs even if the simulation failed or the command context is done
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+			defer cancel()
+			var errs []error
+			if cleanup {
+				errs = append(errs, sim.DeleteCreated(cleanupCtx))
+			}
+			if saveMetrics && runErr == nil {
+				errs = append(errs, saveResults(sim, scenario, scenarioFile))
 			}
-			return nil
+			if interrupted.Load() {
+				errs = append(errs, sim.RestoreScheduler(cleanupCtx))
+			}
+			return errors.Join(append([]error{runErr}, errs...)...)
 		},
 	}
 
 	cmd.Flags().StringVar(&scenarioFile, "scenario", "scenario.yaml", "Path to the scenario file (YAML format)")
 	cmd.Flags().BoolVar(&saveMetrics, "metrics", true, "Enable metrics")
+	cmd.Flags().StringArrayVar(&params, "set", nil, "Override a scenario parameter (name=value), can be repeated")
+	cmd.Flags().IntVar(&repeat, "repeat", 1, "Number of repetitions of the scenario")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Seed of the scenario distributions (default the scenario seed, or random)")
+	cmd.Flags().StringVar(&schedulerUrl, "scheduler-url", "http://localhost:1212/api/v1/schedulerconfiguration", "URL of the scheduler configuration API")
+	cmd.Flags().BoolVar(&resetPods, "reset-pods", true, "Delete the objects created by each repetition once it ends")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the planned timeline without running the simulation")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format of --dry-run (table, json)")
+	cmd.Flags().BoolVar(&offlineMode, "offline", false, "Run against an in-process fake cluster with a built-in scheduler")
+	termination.Register(cmd)
+	cmd.Flags().BoolVar(&cleanup, "cleanup", false, "Delete the objects created by the simulation when it ends or is interrupted")
+	cmd.Flags().BoolVar(&isolate, "isolate", false, "Run the simulation in its own namespace, deleted when it ends")
+	cmd.Flags().StringVar(&strategy, "strategy", string(offline.StrategyLeastAllocated), "Scoring strategy of the offline scheduler (least-allocated, most-allocated)")
+	cmd.Flags().BoolVar(&tui, "tui", false, "Show a live dashboard of the simulation instead of the logs")
+	cmd.Flags().StringArrayVar(&contexts, "context", nil, "Kubeconfig context to run against, as context or context=scheduler-url; repeat to run against several clusters in parallel")
 	return cmd
 }
+
+// runDashboard draws the dashboard of the simulation until the returned function is called.
+func runDashboard(ctx context.Context, log *logger.Logger, sim simulation.Simulation, title string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := dashboard.NewDashboard(log, sim, title).Run(ctx); err != nil {
+			log.Errorf("failed to draw the dashboard: %v", err)
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// cleanupTimeout bounds the cleanup of a simulation once it ends.
+const cleanupTimeout = time.Minute
+
+// saveResults exports the stats and the results metadata of a simulation.
+func saveResults(sim simulation.Simulation, scenario *simulation.Scenario, scenarioFile string) error {
+	dir := "results/" + strings.ReplaceAll(strings.ToLower(sim.GetID()), " ", "_")
+	if err := sim.GetStats().ExportCSV(dir); err != nil {
+		return err
+	}
+	return simulation.WriteResultsMetadata(dir, simulation.NewResultsMetadata(sim, scenario, scenarioFile))
+}
+
+// runDir returns the results directory of the repetitions or the clusters of a scenario.
+func runDir(scenario *simulation.Scenario) string {
+	return "results/" + strings.ReplaceAll(strings.ToLower(scenario.Metadata.Name), " ", "_") +
+		"-" + time.Now().Format("20060102_150405")
+}
+
+// clusterContext is a kubeconfig context set with --context and the URL of its scheduler configuration API.
+type clusterContext struct {
+	name         string
+	schedulerURL string
+}
+
+// parseContexts parses the --context values, context or context=scheduler-url.
+func parseContexts(values []string, defaultSchedulerURL string) ([]clusterContext, error) {
+	contexts := make([]clusterContext, 0, len(values))
+	seen := make(map[string]bool)
+	for _, value := range values {
+		name, url, found := strings.Cut(value, "=")
+		if !found {
+			url = defaultSchedulerURL
+		}
+		if name == "" || url == "" {
+			return nil, fmt.Errorf("invalid --context %q, expected context or context=scheduler-url", value)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("--context %s is set more than once", name)
+		}
+		seen[name] = true
+		contexts = append(contexts, clusterContext{name: name, schedulerURL: url})
+	}
+	return contexts, nil
+}
+
+// runClusters runs the scenario against the clusters in parallel and logs the key metrics of each one,
+// relative to the first. On interrupt, the simulations end, the results so far are written and the
+// scheduler configurations are restored.
+func runClusters(ctx context.Context, log *logger.Logger, runner *multicluster.Runner, scenarioFile, dir string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var interrupted atomic.Bool
+	stopSignals := util.OnInterrupt(func() {
+		log.Warnln("interrupted, stopping the simulations (interrupt again to force exit)")
+		interrupted.Store(true)
+		cancel()
+	})
+	defer stopSignals()
+
+	results, err := runner.Run(ctx, scenarioFile)
+	if interrupted.Load() {
+		log.Warnf("simulations interrupted, results so far written to %s", dir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	for _, metric := range sweep.Metrics {
+		values := make([]string, 0, len(results))
+		for i, r := range results {
+			if r.Err != nil {
+				values = append(values, r.Cluster+" failed")
+				continue
+			}
+			value := fmt.Sprintf("%s %.4f", r.Cluster, metric.Value(r.Summary))
+			if baseline := results[0]; i > 0 && baseline.Err == nil && metric.Value(baseline.Summary) != 0 {
+				value += fmt.Sprintf(" (%+.1f%%)", 100*(metric.Value(r.Summary)/metric.Value(baseline.Summary)-1))
+			}
+			values = append(values, value)
+		}
+		log.Infof("%s: %s", metric.Name, strings.Join(values, ", "))
+	}
+	log.Infof("%d cluster(s), %d failed, results written to %s", len(results), failed, dir)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cluster(s) failed", failed, len(results))
+	}
+	return nil
+}
+
+// runRepetitions runs the repetitions of the scenario and logs the mean and 95% confidence interval of the key metrics.
+// On interrupt, the running repetition ends, the results so far are written and the cluster is reset.
+func runRepetitions(ctx context.Context, log *logger.Logger, runner *sweep.Runner, scenarioFile, dir string, reset sweep.ResetFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var interrupted atomic.Bool
+	stopSignals := util.OnInterrupt(func() {
+		log.Warnln("interrupted, stopping the repetitions (interrupt again to force exit)")
+		interrupted.Store(true)
+		cancel()
+	})
+	defer stopSignals()
+
+	results, err := runner.Run(ctx, scenarioFile, nil)
+	if interrupted.Load() {
+		log.Warnf("%d repetition(s) run before the interrupt, results written to %s", len(results), dir)
+		resetCtx, cancelReset := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancelReset()
+		return reset(resetCtx)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	for _, a := range sweep.AggregateResults(results) {
+		for i, metric := range sweep.Metrics {
+			log.Infof("%s: %.4f ± %.4f (95%% CI, n=%d)", metric.Name, a.Estimates[i].Mean, a.Estimates[i].CI95, a.Repetitions)
+		}
+	}
+	log.Infof("%d repetition(s), %d failed, results written to %s", len(results), failed, dir)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repetition(s) failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package sweep
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/maczg/kube-event-generator/pkg/sweep"
+	"github.com/maczg/kube-event-generator/pkg/util"
+	"github.com/spf13/cobra"
+	k8s "k8s.io/client-go/kubernetes"
+)
+
+// NewCommand creates the sweep command.
+func NewCommand(log *logger.Logger) *cobra.Command {
+	var scenarioFile string
+	var weights []string
+	var params []string
+	var set []string
+	var outputDir string
+	var schedulerUrl string
+	var resetPods bool
+	var dryRun bool
+	var repeat int
+	var seed int64
+	var offlineMode bool
+	var strategy string
+	var termination util.TerminationFlags
+	var isolate bool
+
+	cmd := &cobra.Command{
+		Use:   "sweep",
+		Short: "Run a scenario over a matrix of scheduler weights and parameters",
+		Long: `Run a scenario once per combination of scheduler plugin weights and scenario parameters.
+Runs are sequential: the objects created by each run are deleted once it ends, and the scheduler is
+reset to its default weights before the next one. Objects of other simulations are left alone.
+Each run exports its stats into its own subdirectory, and summary.csv aggregates all runs.
+With --repeat N every combination runs N times, and aggregate.csv has the mean and 95% confidence
+interval of the key metrics per combination.
+With --offline the runs use an in-process fake cluster made of the scenario nodes and a built-in scheduler.
+On SIGINT or SIGTERM the running simulation ends, the summary of the runs so far is written and the
+cluster is reset. A second signal exits immediately.
+With --isolate every run has its own namespace, deleted when it ends.`,
+		Example: `  keg sweep --scenario scenario.yaml --weight NodeResourcesFit=1..10 --param rate=0.5,1,2`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			axes := make([]sweep.Axis, 0, len(weights)+len(params))
+			for _, spec := range weights {
+				axis, err := sweep.ParseAxis(sweep.AxisWeight, spec)
+				if err != nil {
+					return err
+				}
+				axes = append(axes, axis)
+			}
+			for _, spec := range params {
+				axis, err := sweep.ParseAxis(sweep.AxisParam, spec)
+				if err != nil {
+					return err
+				}
+				axes = append(axes, axis)
+			}
+			if len(axes) == 0 {
+				return fmt.Errorf("no axis given, use --weight or --param")
+			}
+			if repeat < 1 {
+				return fmt.Errorf("--repeat must be at least 1, got %d", repeat)
+			}
+
+			overrides, err := simulation.ParseParamOverrides(set)
+			if err != nil {
+				return err
+			}
+
+			runs := sweep.Matrix(axes)
+			if dryRun {
+				return printMatrix(axes, runs)
+			}
+
+			first := make(map[string]string)
+			for k, v := range overrides {
+				first[k] = v
+			}
+			for k, v := range runs[0].Params {
+				first[k] = v
+			}
+			diags, err := simulation.ValidateFile(scenarioFile, simulation.WithLoadOpts(simulation.WithParams(first)))
+			if err != nil {
+				return err
+			}
+			for _, d := range diags {
+				log.Warnf("%s:%s", scenarioFile, d.String())
+			}
+			if diags.HasErrors() {
+				return fmt.Errorf("scenario %s is invalid, run 'keg scenario validate' for details", scenarioFile)
+			}
+
+			var clientset k8s.Interface
+			var manager kubernetes.SchedulerManager
+			var reset sweep.ResetFunc
+			if offlineMode {
+				scenario, err := simulation.LoadFromYaml(scenarioFile, simulation.WithParams(first))
+				if err != nil {
+					return err
+				}
+				cluster, err := util.NewOfflineCluster(cmd.Context(), log, scenario, strategy)
+				if err != nil {
+					return err
+				}
+				clientset, manager, reset = cluster.Clientset(), cluster.Scheduler(), cluster.Reset
+			} else {
+				if clientset, err = kubernetes.GetClientset(); err != nil {
+					return err
+				}
+				httpManager := kubernetes.NewHTTPKubeSchedulerManager(schedulerUrl)
+				manager = httpManager
+				reset = httpManager.ResetToDefaults
+			}
+
+			if outputDir == "" {
+				outputDir = "results/sweep-" + time.Now().Format("20060102_150405")
+			}
+
+			opts := []sweep.RunnerOpt{
+				sweep.WithOutputDir(outputDir),
+				sweep.WithBaseParams(overrides),
+				sweep.WithRepetitions(repeat),
+				sweep.WithReset(reset),
+				sweep.WithTermination(termination.Termination()),
+				// an offline cluster is reset instead
+				sweep.WithCleanup(resetPods && !offlineMode),
+			}
+			if isolate {
+				opts = append(opts, sweep.WithSimulationOpts(simulation.WithIsolatedNamespace()))
+			}
+			if cmd.Flags().Changed("seed") {
+				opts = append(opts, sweep.WithSeed(seed))
+			}
+			runner := sweep.NewRunner(log, clientset, manager, opts...)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			var interrupted atomic.Bool
+			stopSignals := util.OnInterrupt(func() {
+				log.Warnln("interrupted, stopping the sweep (interrupt again to force exit)")
+				interrupted.Store(true)
+				cancel()
+			})
+			defer stopSignals()
+
+			results, err := runner.Run(ctx, scenarioFile, axes)
+			if interrupted.Load() {
+				log.Warnf("sweep interrupted after %d run(s), summary written to %s/%s", len(results), outputDir, sweep.SummaryFile)
+				// leaves the cluster as the next run would find it
+				resetCtx, cancelReset := context.WithTimeout(context.Background(), time.Minute)
+				defer cancelReset()
+				return reset(resetCtx)
+			}
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, r := range results {
+				if r.Err != nil {
+					failed++
+				}
+			}
+			log.Infof("sweep done: %d run(s), %d failed, summary written to %s/%s", len(results), failed, outputDir, sweep.SummaryFile)
+			if failed > 0 {
+				return fmt.Errorf("%d of %d run(s) failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&scenarioFile, "scenario", "scenario.yaml", "Path to the scenario file (YAML format)")
+	cmd.Flags().StringArrayVar(&weights, "weight", nil, "Plugin weight axis, e.g. NodeResourcesFit=1..10 or ImageLocality=1,5,10 (repeatable)")
+	cmd.Flags().StringArrayVar(&params, "param", nil, "Scenario parameter axis, e.g. rate=0.5,1,2 (repeatable)")
+	cmd.Flags().StringArrayVar(&set, "set", nil, "Override a scenario parameter for all runs (name=value), can be repeated")
+	cmd.Flags().StringVarP(&outputDir, "output", "o", "", "Output directory (default results/sweep-<timestamp>)")
+	cmd.Flags().StringVar(&schedulerUrl, "scheduler-url", "http://localhost:1212/api/v1/schedulerconfiguration", "URL of the scheduler configuration API")
+	cmd.Flags().BoolVar(&resetPods, "reset-pods", true, "Delete the objects created by each run once it ends")
+	cmd.Flags().IntVar(&repeat, "repeat", 1, "Number of repetitions of every combination")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Base seed of the repetitions (default random)")
+	cmd.Flags().BoolVar(&offlineMode, "offline", false, "Run against an in-process fake cluster with a built-in scheduler")
+	termination.Register(cmd)
+	cmd.Flags().StringVar(&strategy, "strategy", string(offline.StrategyLeastAllocated), "Scoring strategy of the offline scheduler (least-allocated, most-allocated)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the runs of the matrix without running them")
+	cmd.Flags().BoolVar(&isolate, "isolate", false, "Run every simulation in its own namespace, deleted when it ends")
+	return cmd
+}
+
+// printMatrix prints one line per run with its axis values.
+func printMatrix(axes []sweep.Axis, runs []sweep.Run) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := []string{"run"}
+	for _, axis := range axes {
+		header = append(header, axis.Column())
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, run := range runs {
+		fmt.Fprintln(w, run.Name()+"\t"+strings.Join(run.Values, "\t"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func withContainerStatus(pod *v1.Pod, phase v1.PodPhase, status v1.ContainerStatus) *v1.Pod {
+	pod.Status.Phase = phase
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{status}
+	return pod
+}
+
+func TestStats_UpdatePodCompletion(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		recorded bool
+		expected PodCompletion
+	}{
+		{
+			name:     "running",
+			pod:      withContainerStatus(createTestPod("running", "node-1", pod1Cpu, pod1Memory), v1.PodRunning, v1.ContainerStatus{}),
+			recorded: false,
+		},
+		{
+			name: "succeeded",
+			pod: withContainerStatus(createTestPod("succeeded", "node-1", pod1Cpu, pod1Memory), v1.PodSucceeded, v1.ContainerStatus{
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Completed"}},
+			}),
+			recorded: true,
+			expected: PodCompletion{Phase: v1.PodSucceeded, Reason: "Completed"},
+		},
+		{
+			name: "failed",
+			pod: withContainerStatus(createTestPod("failed", "node-1", pod1Cpu, pod1Memory), v1.PodFailed, v1.ContainerStatus{
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 137}},
+			}),
+			recorded: true,
+			expected: PodCompletion{Phase: v1.PodFailed, Reason: "Error", ExitCode: 137},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := NewStats()
+			assert.Equal(t, tt.recorded, stats.UpdatePodCompletion(tt.pod))
+			completion, ok := stats.Completions[NewKey(tt.pod)]
+			assert.Equal(t, tt.recorded, ok)
+			if !tt.recorded {
+				return
+			}
+			assert.Equal(t, tt.expected.Phase, completion.Phase)
+			assert.Equal(t, tt.expected.Reason, completion.Reason)
+			assert.Equal(t, tt.expected.ExitCode, completion.ExitCode)
+			assert.False(t, completion.At.IsZero())
+
+			// a completion is recorded once
+			assert.False(t, stats.UpdatePodCompletion(tt.pod))
+		})
+	}
+}
+
+func TestStats_UpdatePodRestarts(t *testing.T) {
+	stats := NewStats()
+	healthy := withContainerStatus(createTestPod("healthy", "node-1", pod1Cpu, pod1Memory), v1.PodRunning, v1.ContainerStatus{})
+	crashing := createTestPod("crashing", "node-1", pod1Cpu, pod1Memory)
+	crashing.Status.ContainerStatuses = []v1.ContainerStatus{{RestartCount: 2}, {RestartCount: 1}}
+
+	stats.UpdatePodRestarts(healthy)
+	stats.UpdatePodRestarts(crashing)
+	assert.Equal(t, map[Key]int32{NewKey(crashing): 3}, stats.Restarts)
+}
+
+func TestStats_ExportCSVCompletions(t *testing.T) {
+	stats := NewStats()
+	failed := withContainerStatus(createTestPod("failed", "node-1", pod1Cpu, pod1Memory), v1.PodFailed, v1.ContainerStatus{
+		RestartCount: 2,
+		State:        v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 2}},
+	})
+	stats.UpdatePodCompletion(failed)
+	stats.UpdatePodRestarts(failed)
+
+	dir := t.TempDir()
+	require.NoError(t, stats.ExportCSV(dir))
+
+	f, err := os.Open(filepath.Join(dir, PodCompletionsKey+".csv"))
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"pod_uid", "pod_name", "phase", "reason", "exit_code", "restarts", "timestamp"}, records[0])
+	assert.Equal(t, []string{"faileduid", "failed", "Failed", "Error", "2", "2"}, records[1][:6])
+}
@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Preemption records a pod preempted by the scheduler to make room for a pod of higher priority.
+type Preemption struct {
+	// Victim is the preempted pod
+	Victim         Key
+	VictimPriority int32
+	// Node is the node the victim was running on
+	Node string
+	// Preemptor is the pod nominated to the node of the victim. It is unset until its nomination is seen.
+	Preemptor         Key
+	PreemptorPriority int32
+	// At is when the preemption was observed
+	At time.Time
+}
+
+// PrioritySummary describes the time the pods of a priority spent pending.
+type PrioritySummary struct {
+	Priority int32
+	// Pods is the number of pods of the priority that left the pending queue
+	Pods int
+	// Mean, P50, P95 and Max describe the time the pods spent pending
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+	Max  time.Duration
+}
+
+// podPriority returns the priority of a pod, 0 if unset as the scheduler assumes.
+func podPriority(pod *v1.Pod) int32 {
+	if pod.Spec.Priority != nil {
+		return *pod.Spec.Priority
+	}
+	return 0
+}
+
+// preempted reports whether the scheduler marked the pod as a preemption victim.
+func preempted(pod *v1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.DisruptionTarget && c.Status == v1.ConditionTrue && c.Reason == v1.PodReasonPreemptionByScheduler {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatePodPriority records the priority of a pod.
+func (s *Stats) UpdatePodPriority(pod *v1.Pod) {
+	s.Priorities[NewKey(pod)] = podPriority(pod)
+}
+
+// UpdatePreemption records a pod preempted by the scheduler, read from its DisruptionTarget condition.
+// The victim is attributed to the pending pod of higher priority nominated to its node, if any. It
+// returns false if the pod is not a victim or is already recorded.
+func (s *Stats) UpdatePreemption(pod *v1.Pod) bool {
+	if !preempted(pod) {
+		return false
+	}
+	key := NewKey(pod)
+	for _, p := range s.Preemptions {
+		if p.Victim == key {
+			return false
+		}
+	}
+
+	preemption := Preemption{Victim: key, VictimPriority: podPriority(pod), Node: pod.Spec.NodeName, At: time.Now()}
+	// the scheduler nominates the preemptor after marking the victims, but an earlier nomination of the
+	// same pod to the node may still be pending
+	for candidate, node := range s.Nominations {
+		if node != preemption.Node || s.Priorities[candidate] <= preemption.VictimPriority {
+			continue
+		}
+		if _, pending := s.PendingQ[candidate]; !pending {
+			continue
+		}
+		if preemption.Preemptor == (Key{}) || s.Priorities[candidate] > preemption.PreemptorPriority {
+			preemption.Preemptor, preemption.PreemptorPriority = candidate, s.Priorities[candidate]
+		}
+	}
+	s.Preemptions = append(s.Preemptions, preemption)
+	return true
+}
+
+// UpdateNomination records the node a pod is nominated to by a preemption, and attributes the victims
+// on that node still without a preemptor to it.
+func (s *Stats) UpdateNomination(pod *v1.Pod) {
+	node := pod.Status.NominatedNodeName
+	key := NewKey(pod)
+	if node == "" || s.Nominations[key] == node {
+		return
+	}
+	s.Nominations[key] = node
+
+	priority := podPriority(pod)
+	for i, p := range s.Preemptions {
+		if p.Node == node && p.Preemptor == (Key{}) && p.VictimPriority < priority {
+			s.Preemptions[i].Preemptor, s.Preemptions[i].PreemptorPriority = key, priority
+		}
+	}
+}
+
+// IsPreempted reports whether a pod was preempted by the scheduler.
+func (s *Stats) IsPreempted(key Key) bool {
+	for _, p := range s.Preemptions {
+		if p.Victim == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SummarizeByPriority describes the pending durations of the pods grouped by priority, from the highest.
+func (s *Stats) SummarizeByPriority() []PrioritySummary {
+	byPriority := make(map[int32][]time.Duration)
+	for k, d := range s.PendingDurations {
+		priority := s.Priorities[k]
+		byPriority[priority] = append(byPriority[priority], d)
+	}
+
+	summaries := make([]PrioritySummary, 0, len(byPriority))
+	for priority, pending := range byPriority {
+		summary := PrioritySummary{Priority: priority, Pods: len(pending)}
+		summary.Mean, summary.P50, summary.P95, summary.Max = describeDurations(pending)
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Priority > summaries[j].Priority })
+	return summaries
+}
+
+// exportPreemptions writes the preemptions and the pending durations by priority to dir.
+func (s *Stats) exportPreemptions(dir string) error {
+	filePreemptions, err := os.Create(fmt.Sprintf("%s/%s.csv", dir, PreemptionsKey))
+	if err != nil {
+		return err
+	}
+	defer filePreemptions.Close()
+
+	writer := csv.NewWriter(filePreemptions)
+	header := []string{"victim_uid", "victim_name", "victim_priority", "node_name", "preemptor_uid", "preemptor_name", "preemptor_priority", "timestamp"}
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+	for _, p := range s.Preemptions {
+		row := []string{
+			p.Victim.GetUID(), p.Victim.GetName(), strconv.Itoa(int(p.VictimPriority)), p.Node,
+			p.Preemptor.GetUID(), p.Preemptor.GetName(), "", p.At.Format(TimeFormat),
+		}
+		if p.Preemptor != (Key{}) {
+			row[6] = strconv.Itoa(int(p.PreemptorPriority))
+		}
+		if err = writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return err
+	}
+
+	filePending, err := os.Create(fmt.Sprintf("%s/%s.csv", dir, PendingByPriorityKey))
+	if err != nil {
+		return err
+	}
+	defer filePending.Close()
+
+	writer = csv.NewWriter(filePending)
+	header = []string{"priority", "pods", "pending_mean_milliseconds", "pending_p50_milliseconds", "pending_p95_milliseconds", "pending_max_milliseconds"}
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+	for _, p := range s.SummarizeByPriority() {
+		row := []string{
+			strconv.Itoa(int(p.Priority)), strconv.Itoa(p.Pods),
+			strconv.FormatInt(p.Mean.Milliseconds(), 10), strconv.FormatInt(p.P50.Milliseconds(), 10),
+			strconv.FormatInt(p.P95.Milliseconds(), 10), strconv.FormatInt(p.Max.Milliseconds(), 10),
+		}
+		if err = writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
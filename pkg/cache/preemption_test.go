@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func withPriority(pod *v1.Pod, priority int32) *v1.Pod {
+	pod.Spec.Priority = &priority
+	return pod
+}
+
+func markPreempted(pod *v1.Pod) *v1.Pod {
+	victim := pod.DeepCopy()
+	victim.Status.Conditions = append(victim.Status.Conditions, v1.PodCondition{
+		Type:   v1.DisruptionTarget,
+		Status: v1.ConditionTrue,
+		Reason: v1.PodReasonPreemptionByScheduler,
+	})
+	return victim
+}
+
+func nominate(pod *v1.Pod, node string) *v1.Pod {
+	nominated := pod.DeepCopy()
+	nominated.Status.NominatedNodeName = node
+	return nominated
+}
+
+func TestStats_UpdatePreemption(t *testing.T) {
+	tests := []struct {
+		name string
+		// nominatedFirst nominates the preemptor before the victim is marked
+		nominatedFirst bool
+	}{
+		{name: "victim first", nominatedFirst: false},
+		{name: "nomination first", nominatedFirst: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := NewStats()
+			victim := withPriority(createTestPod("victim", "node-1", pod1Cpu, pod1Memory), 10)
+			preemptor := withPriority(createTestPod("preemptor", "", pod2Cpu, pod2Memory), 1000)
+			for _, pod := range []*v1.Pod{victim, preemptor} {
+				stats.UpdatePodPriority(pod)
+			}
+			stats.UpdatePendingQ(preemptor, AddPodToPendingQ)
+
+			if tt.nominatedFirst {
+				stats.UpdateNomination(nominate(preemptor, "node-1"))
+			}
+			assert.True(t, stats.UpdatePreemption(markPreempted(victim)))
+			if !tt.nominatedFirst {
+				stats.UpdateNomination(nominate(preemptor, "node-1"))
+			}
+			// a victim is recorded once
+			assert.False(t, stats.UpdatePreemption(markPreempted(victim)))
+
+			require.Len(t, stats.Preemptions, 1)
+			p := stats.Preemptions[0]
+			assert.Equal(t, NewKey(victim), p.Victim)
+			assert.Equal(t, int32(10), p.VictimPriority)
+			assert.Equal(t, "node-1", p.Node)
+			assert.Equal(t, NewKey(preemptor), p.Preemptor)
+			assert.Equal(t, int32(1000), p.PreemptorPriority)
+			assert.True(t, stats.IsPreempted(NewKey(victim)))
+			assert.False(t, stats.IsPreempted(NewKey(preemptor)))
+		})
+	}
+}
+
+func TestStats_UpdatePreemptionNotVictim(t *testing.T) {
+	stats := NewStats()
+	pod := createTestPod("evicted", "node-1", pod1Cpu, pod1Memory)
+	assert.False(t, stats.UpdatePreemption(pod))
+	assert.Empty(t, stats.Preemptions)
+}
+
+func TestStats_SummarizeByPriority(t *testing.T) {
+	stats := NewStats()
+	for i, d := range []time.Duration{time.Second, 3 * time.Second} {
+		key := Key{Name: string(rune('a' + i))}
+		stats.Priorities[key] = 1000
+		stats.PendingDurations[key] = d
+	}
+	stats.PendingDurations[Key{Name: "c"}] = 10 * time.Second
+
+	summaries := stats.SummarizeByPriority()
+	require.Len(t, summaries, 2)
+	assert.Equal(t, PrioritySummary{Priority: 1000, Pods: 2, Mean: 2 * time.Second, P50: time.Second, P95: 3 * time.Second, Max: 3 * time.Second}, summaries[0])
+	assert.Equal(t, PrioritySummary{Priority: 0, Pods: 1, Mean: 10 * time.Second, P50: 10 * time.Second, P95: 10 * time.Second, Max: 10 * time.Second}, summaries[1])
+}
+
+func TestStats_ExportCSVPreemptions(t *testing.T) {
+	stats := NewStats()
+	victim := withPriority(createTestPod("victim", "node-1", pod1Cpu, pod1Memory), 10)
+	stats.UpdatePodPriority(victim)
+	stats.UpdatePreemption(markPreempted(victim))
+	stats.PendingDurations[NewKey(victim)] = 1500 * time.Millisecond
+
+	dir := t.TempDir()
+	require.NoError(t, stats.ExportCSV(dir))
+
+	read := func(name string) [][]string {
+		f, err := os.Open(filepath.Join(dir, name+".csv"))
+		require.NoError(t, err)
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		require.NoError(t, err)
+		return records
+	}
+
+	preemptions := read(PreemptionsKey)
+	require.Len(t, preemptions, 2)
+	// the preemptor is unknown until its nomination is seen
+	assert.Equal(t, []string{"victimuid", "victim", "10", "node-1", "", "", ""}, preemptions[1][:7])
+
+	pending := read(PendingByPriorityKey)
+	require.Len(t, pending, 2)
+	assert.Equal(t, []string{"10", "1", "1500", "1500", "1500", "1500"}, pending[1])
+}
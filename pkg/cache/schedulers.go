@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// SchedulerSummary describes how the pods of a scheduler, by spec.schedulerName, were placed.
+type SchedulerSummary struct {
+	Scheduler string
+	// Pods is the number of pods of the scheduler seen
+	Pods int
+	// Scheduled is the number of pods that left the pending queue
+	Scheduled int
+	// Pending is the number of pods still pending
+	Pending int
+	// Unschedulable is the number of pods the scheduler failed to place at least once
+	Unschedulable int
+	// Nodes is the number of distinct nodes the pods were bound to
+	Nodes int
+	// Mean, P50, P95 and Max describe the time the pods spent pending
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+	Max  time.Duration
+}
+
+// schedulerName returns the scheduler of a pod, default-scheduler if unset as the API server defaults it.
+func schedulerName(pod *v1.Pod) string {
+	if pod.Spec.SchedulerName == "" {
+		return v1.DefaultSchedulerName
+	}
+	return pod.Spec.SchedulerName
+}
+
+// unschedulable returns the message of the PodScheduled condition of a pod the scheduler failed to place.
+func unschedulable(pod *v1.Pod) (string, bool) {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse && c.Reason == v1.PodReasonUnschedulable {
+			return c.Message, true
+		}
+	}
+	return "", false
+}
+
+// UpdatePodScheduler records the scheduler of a pod, the node it is bound to and whether its scheduler
+// failed to place it.
+func (s *Stats) UpdatePodScheduler(pod *v1.Pod) {
+	key := NewKey(pod)
+	s.Schedulers[key] = schedulerName(pod)
+	if pod.Spec.NodeName != "" {
+		s.Placements[key] = pod.Spec.NodeName
+	}
+	if message, ok := unschedulable(pod); ok {
+		s.Unschedulable[key] = message
+	}
+}
+
+// SummarizeBySchedulers describes the placement of the pods grouped by scheduler, sorted by name.
+func (s *Stats) SummarizeBySchedulers() []SchedulerSummary {
+	pending := make(map[string][]time.Duration)
+	byScheduler := make(map[string]*SchedulerSummary)
+	nodes := make(map[string]map[string]bool)
+	for key, name := range s.Schedulers {
+		summary, ok := byScheduler[name]
+		if !ok {
+			summary = &SchedulerSummary{Scheduler: name}
+			byScheduler[name] = summary
+			nodes[name] = make(map[string]bool)
+		}
+		summary.Pods++
+		if d, ok := s.PendingDurations[key]; ok {
+			summary.Scheduled++
+			pending[name] = append(pending[name], d)
+		}
+		if _, ok := s.PendingQ[key]; ok {
+			summary.Pending++
+		}
+		if _, ok := s.Unschedulable[key]; ok {
+			summary.Unschedulable++
+		}
+		if node, ok := s.Placements[key]; ok {
+			nodes[name][node] = true
+		}
+	}
+
+	summaries := make([]SchedulerSummary, 0, len(byScheduler))
+	for name, summary := range byScheduler {
+		summary.Nodes = len(nodes[name])
+		summary.Mean, summary.P50, summary.P95, summary.Max = describeDurations(pending[name])
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Scheduler < summaries[j].Scheduler })
+	return summaries
+}
+
+// describeDurations sorts the durations and returns their mean, median, 95th percentile and maximum,
+// zero if there are none.
+func describeDurations(durations []time.Duration) (mean, p50, p95, max time.Duration) {
+	if len(durations) == 0 {
+		return 0, 0, 0, 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return meanDuration(durations), percentile(durations, 50), percentile(durations, 95), durations[len(durations)-1]
+}
+
+// exportSchedulers writes the summaries by scheduler and the placement of the pods to dir.
+func (s *Stats) exportSchedulers(dir string) error {
+	fileSchedulers, err := os.Create(fmt.Sprintf("%s/%s.csv", dir, SchedulersKey))
+	if err != nil {
+		return err
+	}
+	defer fileSchedulers.Close()
+
+	writer := csv.NewWriter(fileSchedulers)
+	header := []string{
+		"scheduler", "pods", "scheduled", "pending", "unschedulable", "nodes",
+		"pending_mean_milliseconds", "pending_p50_milliseconds", "pending_p95_milliseconds", "pending_max_milliseconds",
+	}
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+	for _, summary := range s.SummarizeBySchedulers() {
+		row := []string{
+			summary.Scheduler, strconv.Itoa(summary.Pods), strconv.Itoa(summary.Scheduled),
+			strconv.Itoa(summary.Pending), strconv.Itoa(summary.Unschedulable), strconv.Itoa(summary.Nodes),
+			strconv.FormatInt(summary.Mean.Milliseconds(), 10), strconv.FormatInt(summary.P50.Milliseconds(), 10),
+			strconv.FormatInt(summary.P95.Milliseconds(), 10), strconv.FormatInt(summary.Max.Milliseconds(), 10),
+		}
+		if err = writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return err
+	}
+
+	filePlacements, err := os.Create(fmt.Sprintf("%s/%s.csv", dir, PlacementsKey))
+	if err != nil {
+		return err
+	}
+	defer filePlacements.Close()
+
+	keys := make([]Key, 0, len(s.Schedulers))
+	for key := range s.Schedulers {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].GetName() < keys[j].GetName() })
+
+	writer = csv.NewWriter(filePlacements)
+	header = []string{"pod_uid", "pod_name", "scheduler", "node_name", "unschedulable_message"}
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		row := []string{key.GetUID(), key.GetName(), s.Schedulers[key], s.Placements[key], s.Unschedulable[key]}
+		if err = writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func withScheduler(pod *v1.Pod, name string) *v1.Pod {
+	pod.Spec.SchedulerName = name
+	return pod
+}
+
+func markUnschedulable(pod *v1.Pod, message string) *v1.Pod {
+	marked := pod.DeepCopy()
+	marked.Status.Conditions = append(marked.Status.Conditions, v1.PodCondition{
+		Type:    v1.PodScheduled,
+		Status:  v1.ConditionFalse,
+		Reason:  v1.PodReasonUnschedulable,
+		Message: message,
+	})
+	return marked
+}
+
+func TestStats_UpdatePodScheduler(t *testing.T) {
+	tests := []struct {
+		name              string
+		pod               *v1.Pod
+		wantScheduler     string
+		wantPlacement     string
+		wantUnschedulable string
+	}{
+		{name: "default scheduler", pod: createTestPod("a", "", pod1Cpu, pod1Memory), wantScheduler: v1.DefaultSchedulerName},
+		{name: "bound", pod: withScheduler(createTestPod("b", "node-1", pod1Cpu, pod1Memory), "binpack"), wantScheduler: "binpack", wantPlacement: "node-1"},
+		{
+			name:              "unschedulable",
+			pod:               markUnschedulable(withScheduler(createTestPod("c", "", pod1Cpu, pod1Memory), "binpack"), "0/1 nodes fit"),
+			wantScheduler:     "binpack",
+			wantUnschedulable: "0/1 nodes fit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := NewStats()
+			stats.UpdatePodScheduler(tt.pod)
+			key := NewKey(tt.pod)
+			assert.Equal(t, tt.wantScheduler, stats.Schedulers[key])
+			assert.Equal(t, tt.wantPlacement, stats.Placements[key])
+			assert.Equal(t, tt.wantUnschedulable, stats.Unschedulable[key])
+		})
+	}
+}
+
+func TestStats_SummarizeBySchedulers(t *testing.T) {
+	stats := NewStats()
+	pods := []*v1.Pod{
+		createTestPod("default-1", "node-1", pod1Cpu, pod1Memory),
+		withScheduler(createTestPod("binpack-1", "node-1", pod1Cpu, pod1Memory), "binpack"),
+		withScheduler(createTestPod("binpack-2", "node-2", pod1Cpu, pod1Memory), "binpack"),
+		markUnschedulable(withScheduler(createTestPod("binpack-3", "", pod1Cpu, pod1Memory), "binpack"), "0/2 nodes fit"),
+	}
+	for _, pod := range pods {
+		stats.UpdatePodScheduler(pod)
+	}
+	stats.PendingDurations[NewKey(pods[0])] = time.Second
+	stats.PendingDurations[NewKey(pods[1])] = 2 * time.Second
+	stats.PendingDurations[NewKey(pods[2])] = 4 * time.Second
+	stats.UpdatePendingQ(pods[3], AddPodToPendingQ)
+
+	summaries := stats.SummarizeBySchedulers()
+	require.Len(t, summaries, 2)
+	assert.Equal(t, SchedulerSummary{
+		Scheduler: "binpack", Pods: 3, Scheduled: 2, Pending: 1, Unschedulable: 1, Nodes: 2,
+		Mean: 3 * time.Second, P50: 2 * time.Second, P95: 4 * time.Second, Max: 4 * time.Second,
+	}, summaries[0])
+	assert.Equal(t, SchedulerSummary{
+		Scheduler: v1.DefaultSchedulerName, Pods: 1, Scheduled: 1, Nodes: 1,
+		Mean: time.Second, P50: time.Second, P95: time.Second, Max: time.Second,
+	}, summaries[1])
+}
+
+func TestStats_ExportCSVSchedulers(t *testing.T) {
+	stats := NewStats()
+	bound := withScheduler(createTestPod("bound", "node-1", pod1Cpu, pod1Memory), "binpack")
+	stats.UpdatePodScheduler(bound)
+	stats.PendingDurations[NewKey(bound)] = 1500 * time.Millisecond
+	stats.UpdatePodScheduler(markUnschedulable(createTestPod("pending", "", pod1Cpu, pod1Memory), "0/1 nodes fit"))
+
+	dir := t.TempDir()
+	require.NoError(t, stats.ExportCSV(dir))
+
+	read := func(name string) [][]string {
+		f, err := os.Open(filepath.Join(dir, name+".csv"))
+		require.NoError(t, err)
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		require.NoError(t, err)
+		return records
+	}
+
+	schedulers := read(SchedulersKey)
+	require.Len(t, schedulers, 3)
+	assert.Equal(t, []string{"binpack", "1", "1", "0", "0", "1", "1500", "1500", "1500", "1500"}, schedulers[1])
+	assert.Equal(t, []string{v1.DefaultSchedulerName, "1", "0", "0", "1", "0", "0", "0", "0", "0"}, schedulers[2])
+
+	placements := read(PlacementsKey)
+	require.Len(t, placements, 3)
+	assert.Equal(t, []string{"bounduid", "bound", "binpack", "node-1", ""}, placements[1])
+	assert.Equal(t, []string{"pendinguid", "pending", v1.DefaultSchedulerName, "", "0/1 nodes fit"}, placements[2])
+}
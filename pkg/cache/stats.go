@@ -9,16 +9,24 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
-	defaultTimeFormat         = "2006-01-02T15:04:05.000"
+	// TimeFormat is the format of the timestamps of the exported CSV files
+	TimeFormat                = "2006-01-02T15:04:05.000"
 	PodPendingDurationKey     = "pod_pending_durations"
 	PodRunningDurationKey     = "pod_running_durations"
 	PodQueueLengthKey         = "pod_queue_length"
 	AllocationHistoryKey      = "allocation_history"
 	AllocationRatioHistoryKey = "allocation_ratio_history"
 	FreeHistoryKey            = "free_resource_history"
+	PodWorkloadsKey           = "pod_workloads"
+	PodCompletionsKey         = "pod_completions"
+	PreemptionsKey            = "preemptions"
+	PendingByPriorityKey      = "pending_by_priority"
+	SchedulersKey             = "schedulers"
+	PlacementsKey             = "placements"
 )
 
 // PendingQAction represents the action to be performed on the pending queue.
@@ -37,19 +45,23 @@ type PodEvent struct {
 	EventType string
 	CPUReq    string
 	MemReq    string
+	Workload  string
 }
 
 func (p *PodEvent) String() string {
-	return fmt.Sprintf("[PodEvent] podName: %s, nodeName: %s, phase: %s, eventType: %s, cpuReq: %s, memReq: %s}",
-		p.PodName, p.NodeName, p.Phase, p.EventType, p.CPUReq, p.MemReq)
+	return fmt.Sprintf("[PodEvent] podName: %s, nodeName: %s, phase: %s, eventType: %s, cpuReq: %s, memReq: %s, workload: %s}",
+		p.PodName, p.NodeName, p.Phase, p.EventType, p.CPUReq, p.MemReq, p.Workload)
 }
 
 // NewPodEvent creates a new PodEvent from a v1.Pod object and an event type.
 func NewPodEvent(pod *v1.Pod, eventType string) PodEvent {
-	cpu := pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU]
-	mem := pod.Spec.Containers[0].Resources.Requests[v1.ResourceMemory]
+	var cpu, mem resource.Quantity
+	if len(pod.Spec.Containers) > 0 {
+		cpu = pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU]
+		mem = pod.Spec.Containers[0].Resources.Requests[v1.ResourceMemory]
+	}
 
-	return PodEvent{
+	podEvent := PodEvent{
 		PodName:   pod.Name,
 		NodeName:  pod.Spec.NodeName,
 		Phase:     string(pod.Status.Phase),
@@ -57,6 +69,11 @@ func NewPodEvent(pod *v1.Pod, eventType string) PodEvent {
 		CPUReq:    cpu.String(),
 		MemReq:    mem.String(),
 	}
+	if ref, ok := NewWorkloadRef(pod); ok {
+		podEvent.Workload = ref.String()
+	}
+
+	return podEvent
 }
 
 type Stats struct {
@@ -75,6 +92,37 @@ type Stats struct {
 	// ResourceFreeHistory is a history of the free resource on the cluster nodes.
 	ResourceFreeHistory map[Key][]Record[v1.ResourceList]
 	PodEventHistory     []Record[PodEvent]
+	// PodWorkloads is a map of pod to the workload owning it. Bare pods are not tracked.
+	PodWorkloads map[Key]WorkloadRef
+	// Completions is a map of pod to how it ended on its own, succeeded or failed.
+	Completions map[Key]PodCompletion
+	// Restarts is a map of pod to the restarts of its containers. Pods never restarted are not tracked.
+	Restarts map[Key]int32
+	// Priorities is a map of pod to its priority, 0 if unset.
+	Priorities map[Key]int32
+	// Nominations is a map of pod to the node it was nominated to by a preemption.
+	Nominations map[Key]string
+	// Preemptions are the pods preempted by the scheduler, in the order they were seen.
+	Preemptions []Preemption
+	// Schedulers is a map of pod to its scheduler, default-scheduler if unset.
+	Schedulers map[Key]string
+	// Placements is a map of pod to the node it was bound to.
+	Placements map[Key]string
+	// Unschedulable is a map of pod to the last reason its scheduler failed to place it. Pods always
+	// placed at the first attempt are not tracked.
+	Unschedulable map[Key]string
+}
+
+// PodCompletion records how a pod ended on its own.
+type PodCompletion struct {
+	// Phase is Succeeded or Failed
+	Phase v1.PodPhase
+	// Reason is the reason of the pod status, or of its first terminated container
+	Reason string
+	// ExitCode is the exit code of the first terminated container
+	ExitCode int32
+	// At is when the completion was observed
+	At time.Time
 }
 
 // NewStats creates a new Stats object with initialized maps and slices.
@@ -88,6 +136,15 @@ func NewStats() *Stats {
 		AllocationRatioHistory: make(map[Key][]Record[map[v1.ResourceName]float64]),
 		ResourceFreeHistory:    make(map[Key][]Record[v1.ResourceList]),
 		PodEventHistory:        make([]Record[PodEvent], 0),
+		PodWorkloads:           make(map[Key]WorkloadRef),
+		Completions:            make(map[Key]PodCompletion),
+		Restarts:               make(map[Key]int32),
+		Priorities:             make(map[Key]int32),
+		Nominations:            make(map[Key]string),
+		Preemptions:            make([]Preemption, 0),
+		Schedulers:             make(map[Key]string),
+		Placements:             make(map[Key]string),
+		Unschedulable:          make(map[Key]string),
 	}
 }
 
@@ -99,6 +156,59 @@ func (s *Stats) UpdatePodEvent(podEvent PodEvent) {
 	})
 }
 
+// UpdatePodWorkload records the workload owning the pod, if any.
+func (s *Stats) UpdatePodWorkload(pod *v1.Pod) {
+	if ref, ok := NewWorkloadRef(pod); ok {
+		s.PodWorkloads[NewKey(pod)] = ref
+	}
+}
+
+// UpdatePodRestarts records the restarts of the containers of a pod, if any.
+func (s *Stats) UpdatePodRestarts(pod *v1.Pod) {
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	if restarts > 0 {
+		s.Restarts[NewKey(pod)] = restarts
+	}
+}
+
+// UpdatePodCompletion records how a pod that succeeded or failed ended. It returns false if the pod has
+// not ended or its completion is already recorded.
+func (s *Stats) UpdatePodCompletion(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+		return false
+	}
+	key := NewKey(pod)
+	if _, ok := s.Completions[key]; ok {
+		return false
+	}
+
+	completion := PodCompletion{Phase: pod.Status.Phase, Reason: pod.Status.Reason, At: time.Now()}
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil {
+			if completion.Reason == "" {
+				completion.Reason = terminated.Reason
+			}
+			completion.ExitCode = terminated.ExitCode
+			break
+		}
+	}
+	s.Completions[key] = completion
+	return true
+}
+
+// GetWorkloadPods returns the pods grouped by the workload owning them.
+func (s *Stats) GetWorkloadPods() map[WorkloadRef][]Key {
+	pods := make(map[WorkloadRef][]Key)
+	for key, ref := range s.PodWorkloads {
+		pods[ref] = append(pods[ref], key)
+	}
+
+	return pods
+}
+
 func (s *Stats) UpdateHistory(nodeStore NodeStore) {
 	key := NewKey(nodeStore.Node)
 	if _, ok := s.AllocationHistory[key]; !ok {
@@ -248,7 +358,7 @@ func (s *Stats) ExportCSV(dir string) error {
 	})
 
 	for _, value := range podQHistory {
-		_ = writer.Write([]string{value.At.Format(defaultTimeFormat), strconv.Itoa(value.Value)})
+		_ = writer.Write([]string{value.At.Format(TimeFormat), strconv.Itoa(value.Value)})
 	}
 
 	writer.Flush()
@@ -276,7 +386,7 @@ func (s *Stats) ExportCSV(dir string) error {
 
 		for _, record := range hist {
 			row := make([]string, len(header))
-			row[0] = record.At.Format(defaultTimeFormat)
+			row[0] = record.At.Format(TimeFormat)
 
 			for i, resourceType := range header[1:] {
 				if quantity, ok := record.Value[v1.ResourceName(resourceType)]; ok {
@@ -317,7 +427,7 @@ func (s *Stats) ExportCSV(dir string) error {
 
 		for _, record := range hist {
 			row := make([]string, len(header))
-			row[0] = record.At.Format(defaultTimeFormat)
+			row[0] = record.At.Format(TimeFormat)
 
 			for i, resourceType := range header[1:] {
 				if ratio, ok := record.Value[v1.ResourceName(resourceType)]; ok {
@@ -358,7 +468,7 @@ func (s *Stats) ExportCSV(dir string) error {
 
 		for _, record := range hist {
 			row := make([]string, len(header))
-			row[0] = record.At.Format(defaultTimeFormat)
+			row[0] = record.At.Format(TimeFormat)
 
 			for i, resourceType := range header[1:] {
 				if quantity, ok := record.Value[v1.ResourceName(resourceType)]; ok {
@@ -384,7 +494,7 @@ func (s *Stats) ExportCSV(dir string) error {
 
 	defer fileEventHistory.Close()
 	writer = csv.NewWriter(fileEventHistory)
-	header = []string{"timestamp", "pod_name", "node_name", "phase", "event_type", "cpu_req", "mem_req"}
+	header = []string{"timestamp", "pod_name", "node_name", "phase", "event_type", "cpu_req", "mem_req", "workload"}
 
 	if err = writer.Write(header); err != nil {
 		return err
@@ -392,13 +502,14 @@ func (s *Stats) ExportCSV(dir string) error {
 
 	for _, record := range s.PodEventHistory {
 		row := []string{
-			record.At.Format(defaultTimeFormat),
+			record.At.Format(TimeFormat),
 			record.Value.PodName,
 			record.Value.NodeName,
 			record.Value.Phase,
 			record.Value.EventType,
 			record.Value.CPUReq,
 			record.Value.MemReq,
+			record.Value.Workload,
 		}
 		if err = writer.Write(row); err != nil {
 			return err
@@ -407,5 +518,64 @@ func (s *Stats) ExportCSV(dir string) error {
 
 	writer.Flush()
 
-	return nil
+	// pod workloads
+	filePodWorkloads, err := os.Create(fmt.Sprintf("%s/%s.csv", dir, PodWorkloadsKey))
+	if err != nil {
+		return err
+	}
+
+	defer filePodWorkloads.Close()
+	writer = csv.NewWriter(filePodWorkloads)
+	header = []string{"pod_uid", "pod_name", "workload_kind", "workload_namespace", "workload_name"}
+
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+
+	for k, ref := range s.PodWorkloads {
+		if err = writer.Write([]string{k.GetUID(), k.GetName(), ref.Kind, ref.Namespace, ref.Name}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	// pod completions and restarts
+	filePodCompletions, err := os.Create(fmt.Sprintf("%s/%s.csv", dir, PodCompletionsKey))
+	if err != nil {
+		return err
+	}
+
+	defer filePodCompletions.Close()
+	writer = csv.NewWriter(filePodCompletions)
+	header = []string{"pod_uid", "pod_name", "phase", "reason", "exit_code", "restarts", "timestamp"}
+
+	if err = writer.Write(header); err != nil {
+		return err
+	}
+
+	pods := make(map[Key]struct{})
+	for k := range s.Completions {
+		pods[k] = struct{}{}
+	}
+	for k := range s.Restarts {
+		pods[k] = struct{}{}
+	}
+	for k := range pods {
+		row := []string{k.GetUID(), k.GetName(), "", "", "", strconv.Itoa(int(s.Restarts[k])), ""}
+		if c, ok := s.Completions[k]; ok {
+			row[2], row[3], row[4] = string(c.Phase), c.Reason, strconv.Itoa(int(c.ExitCode))
+			row[6] = c.At.Format(TimeFormat)
+		}
+		if err = writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	if err := s.exportPreemptions(dir); err != nil {
+		return err
+	}
+	return s.exportSchedulers(dir)
 }
@@ -18,16 +18,30 @@ import (
 type Store struct {
 	mu *sync.RWMutex
 	// log       logger.Logger
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 	// nodesInfo is a map of node name to nodeInfo
 	nodesInfo map[string]*NodeStore
 	// stats contains the cluster state statistics
 	stats  *Stats
 	stopCh chan struct{}
+	// namespace restricts the pods watched, all namespaces if empty
+	namespace string
+	// stopped freezes the stats: informer handlers may still run after the store is stopped
+	stopped bool
+}
+
+// StoreOpt configures a Store.
+type StoreOpt func(*Store)
+
+// WithNamespace restricts the pods watched by the store to a namespace. Nodes are always watched.
+func WithNamespace(namespace string) StoreOpt {
+	return func(s *Store) {
+		s.namespace = namespace
+	}
 }
 
 // NewStore creates a new Store instance and starts the informers immediately.
-func NewStore(clientset *kubernetes.Clientset) *Store {
+func NewStore(clientset kubernetes.Interface, opts ...StoreOpt) *Store {
 	ni := &Store{
 		mu:        &sync.RWMutex{},
 		clientset: clientset,
@@ -35,6 +49,9 @@ func NewStore(clientset *kubernetes.Clientset) *Store {
 		stats:     NewStats(),
 		stopCh:    make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(ni)
+	}
 
 	return ni
 }
@@ -44,7 +61,8 @@ func NewStore(clientset *kubernetes.Clientset) *Store {
 func (s *Store) Start() {
 	logger.Default().Info("starting store")
 
-	factory := informers.NewSharedInformerFactory(s.clientset, 0)
+	// the namespace does not apply to nodes, which are cluster-scoped
+	factory := informers.NewSharedInformerFactoryWithOptions(s.clientset, 0, informers.WithNamespace(s.namespace))
 	nodeInformer := factory.Core().V1().Nodes().Informer()
 	podInformer := factory.Core().V1().Pods().Informer()
 
@@ -64,9 +82,13 @@ func (s *Store) Start() {
 
 // Stop stops the store and updates the history with the current state of nodes.
 func (s *Store) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for _, nodeInfo := range s.nodesInfo {
 		s.stats.UpdateHistory(nodeInfo.Copy())
 	}
+	s.stopped = true
 	close(s.stopCh)
 	logger.Default().Info("store stopped")
 }
@@ -77,6 +99,9 @@ func (s *Store) onAddNode(obj interface{}) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
 
 	newNodeInfo := NewNodeStore(node)
 	s.nodesInfo[node.Name] = newNodeInfo
@@ -90,6 +115,9 @@ func (s *Store) onUpdateNode(oldObj, newObj interface{}) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
 
 	if n, exists := s.nodesInfo[newNode.Name]; exists {
 		n.UpdateNodeSpec(newNode)
@@ -106,6 +134,9 @@ func (s *Store) onDeleteNode(obj interface{}) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
 	delete(s.nodesInfo, node.Name)
 }
 
@@ -114,8 +145,14 @@ func (s *Store) addPod(obj interface{}) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
 
 	s.stats.UpdatePodEvent(NewPodEvent(pod, "add"))
+	s.stats.UpdatePodWorkload(pod)
+	s.stats.UpdatePodPriority(pod)
+	s.stats.UpdatePodScheduler(pod)
 
 	if pod.Status.Phase == v1.PodPending {
 		logger.Default().Debugf("[onAdd] pod %s added to pending queue", pod.Name)
@@ -138,10 +175,20 @@ func (s *Store) updatePod(oldObj, newObj interface{}) {
 	oldPodNodeName := oldPod.Spec.NodeName
 	newPodNodeName := newPod.Spec.NodeName
 
-	s.stats.UpdatePodEvent(NewPodEvent(newPod, "update"))
-
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+
+	s.stats.UpdatePodEvent(NewPodEvent(newPod, "update"))
+	s.stats.UpdatePodWorkload(newPod)
+	s.stats.UpdatePodPriority(newPod)
+	s.stats.UpdatePodScheduler(newPod)
+	s.stats.UpdateNomination(newPod)
+	if s.stats.UpdatePreemption(newPod) {
+		logger.Default().Debugf("[onUpdate] pod %s preempted on node %s", newPod.Name, newPodNodeName)
+	}
 
 	if newPod.Status.Phase == v1.PodPending {
 		if _, ok := s.stats.PendingQ[NewKey(newPod)]; !ok {
@@ -149,6 +196,13 @@ func (s *Store) updatePod(oldObj, newObj interface{}) {
 			s.stats.UpdatePendingQ(newPod, AddPodToPendingQ)
 		}
 	}
+	// a pending pod that ends, e.g. marked failed, leaves the pending queue without being scheduled
+	if (newPod.Status.Phase == v1.PodFailed || newPod.Status.Phase == v1.PodSucceeded) && newPodNodeName == "" {
+		if _, ok := s.stats.PendingQ[NewKey(newPod)]; ok {
+			logger.Default().Debugf("[onUpdate] pod %s ended while pending, removing from pending queue", newPod.Name)
+			s.stats.UpdatePendingQ(newPod, RemovePodFromPendingQ)
+		}
+	}
 	// pod is running on another node or from "" to newPodNodeName
 	if oldPodNodeName != newPodNodeName {
 		// from pending to running so remove from pending queue and track pendingTime
@@ -185,15 +239,31 @@ func (s *Store) updatePod(oldObj, newObj interface{}) {
 			logger.Default().Warnf("[onUpdate] pod %s has newNodePodName empty. Deleted?", newPod.Name)
 		}
 	}
+
+	s.stats.UpdatePodRestarts(newPod)
+	// a pod that ends on its own no longer uses the resources of its node
+	if s.stats.UpdatePodCompletion(newPod) && newPodNodeName != "" {
+		logger.Default().Debugf("[onUpdate] pod %s ended with phase %s", newPod.Name, newPod.Status.Phase)
+		s.stats.RunningDurations[NewKey(newPod)] = time.Since(newPod.GetCreationTimestamp().Time)
+		if nodeInfo, ok := s.nodesInfo[newPodNodeName]; ok {
+			nodeInfo.deletePod(newPod)
+			s.stats.UpdateHistory(nodeInfo.Copy())
+		}
+	}
 }
 
 func (s *Store) deletePod(obj interface{}) {
 	pod := obj.(*v1.Pod)
-	s.stats.UpdatePodEvent(NewPodEvent(pod, "delete"))
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+
+	s.stats.UpdatePodEvent(NewPodEvent(pod, "delete"))
 	logger.Default().Debugf("[onDelete] pod %s deleted", pod.Name)
+	s.stats.UpdatePreemption(pod)
 
 	key := NewKey(pod)
 
@@ -234,6 +304,32 @@ func (s *Store) GetStats() Stats {
 	return *s.stats
 }
 
+// ReadStats calls fn with the statistics of the cluster, which must not be modified nor kept after fn
+// returns. Unlike GetStats, the maps of the statistics are safe to read while the store is running.
+func (s *Store) ReadStats(fn func(stats *Stats)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fn(s.stats)
+}
+
+// ReadSnapshot calls fn with the statistics and the nodes of the cluster, sorted by name, which must not
+// be modified nor kept after fn returns.
+func (s *Store) ReadSnapshot(fn func(stats *Stats, nodes []*NodeStore)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	nodes := make([]*NodeStore, 0, len(s.nodesInfo))
+	for _, nodeStatus := range s.nodesInfo {
+		nodes = append(nodes, nodeStatus)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node.Name < nodes[j].Node.Name
+	})
+
+	fn(s.stats, nodes)
+}
+
 // GetNodeInfo returns the NodeStore for the given node name.
 func (s *Store) GetNodeInfo(nodeName string) NodeStore {
 	s.mu.RLock()
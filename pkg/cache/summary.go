@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Summary aggregates the stats of a simulation run into a few figures.
+type Summary struct {
+	// ScheduledPods is the number of pods that left the pending queue
+	ScheduledPods int
+	// PendingPods is the number of pods still pending at the end of the run
+	PendingPods int
+	// PendingMean, PendingP50, PendingP95 and PendingMax describe the time pods spent pending
+	PendingMean time.Duration
+	PendingP50  time.Duration
+	PendingP95  time.Duration
+	PendingMax  time.Duration
+	// RunningMean is the mean time pods spent running
+	RunningMean time.Duration
+	// MaxQueueLength is the maximum length of the pending queue
+	MaxQueueLength int
+	// CPUAllocation and MemoryAllocation are the mean allocation ratios over all node samples
+	CPUAllocation    float64
+	MemoryAllocation float64
+	// Makespan is the time between the first and the last pod event
+	Makespan time.Duration
+	// SucceededPods and FailedPods are the numbers of pods that ended on their own
+	SucceededPods int
+	FailedPods    int
+	// Restarts is the total number of container restarts
+	Restarts int
+	// Preemptions is the number of pods preempted by the scheduler
+	Preemptions int
+}
+
+// Summarize computes the summary of the stats.
+func (s *Stats) Summarize() Summary {
+	summary := Summary{
+		ScheduledPods: len(s.PendingDurations),
+		PendingPods:   len(s.PendingQ),
+	}
+
+	pending := make([]time.Duration, 0, len(s.PendingDurations))
+	for _, d := range s.PendingDurations {
+		pending = append(pending, d)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i] < pending[j] })
+	if len(pending) > 0 {
+		summary.PendingMean = meanDuration(pending)
+		summary.PendingP50 = percentile(pending, 50)
+		summary.PendingP95 = percentile(pending, 95)
+		summary.PendingMax = pending[len(pending)-1]
+	}
+
+	running := make([]time.Duration, 0, len(s.RunningDurations))
+	for _, d := range s.RunningDurations {
+		running = append(running, d)
+	}
+	if len(running) > 0 {
+		summary.RunningMean = meanDuration(running)
+	}
+
+	for _, r := range s.PendingQHistory {
+		if r.Value > summary.MaxQueueLength {
+			summary.MaxQueueLength = r.Value
+		}
+	}
+
+	var cpu, mem float64
+	samples := 0
+	for _, history := range s.AllocationRatioHistory {
+		for _, r := range history {
+			cpu += r.Value[v1.ResourceCPU]
+			mem += r.Value[v1.ResourceMemory]
+			samples++
+		}
+	}
+	if samples > 0 {
+		summary.CPUAllocation = cpu / float64(samples)
+		summary.MemoryAllocation = mem / float64(samples)
+	}
+
+	if n := len(s.PodEventHistory); n > 1 {
+		summary.Makespan = s.PodEventHistory[n-1].At.Sub(s.PodEventHistory[0].At)
+	}
+
+	for _, c := range s.Completions {
+		if c.Phase == v1.PodSucceeded {
+			summary.SucceededPods++
+		} else {
+			summary.FailedPods++
+		}
+	}
+	for _, r := range s.Restarts {
+		summary.Restarts += int(r)
+	}
+	summary.Preemptions = len(s.Preemptions)
+
+	return summary
+}
+
+// SummaryHeader returns the CSV header matching Summary.Row.
+func SummaryHeader() []string {
+	return []string{
+		"scheduled_pods", "pending_pods",
+		"pending_mean_s", "pending_p50_s", "pending_p95_s", "pending_max_s",
+		"running_mean_s", "max_queue_length",
+		"cpu_allocation", "memory_allocation", "makespan_s",
+		"succeeded_pods", "failed_pods", "restarts", "preemptions",
+	}
+}
+
+// Row returns the summary as a CSV row. Durations are in seconds.
+func (s Summary) Row() []string {
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+	}
+	ratio := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 4, 64)
+	}
+	return []string{
+		strconv.Itoa(s.ScheduledPods), strconv.Itoa(s.PendingPods),
+		seconds(s.PendingMean), seconds(s.PendingP50), seconds(s.PendingP95), seconds(s.PendingMax),
+		seconds(s.RunningMean), strconv.Itoa(s.MaxQueueLength),
+		ratio(s.CPUAllocation), ratio(s.MemoryAllocation), seconds(s.Makespan),
+		strconv.Itoa(s.SucceededPods), strconv.Itoa(s.FailedPods), strconv.Itoa(s.Restarts),
+		strconv.Itoa(s.Preemptions),
+	}
+}
+
+func meanDuration(durations []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
+
+// percentile returns the p-th percentile (nearest rank) of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
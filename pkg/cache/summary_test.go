@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestStats_Summarize(t *testing.T) {
+	stats := NewStats()
+	for i := 1; i <= 20; i++ {
+		stats.PendingDurations[Key{Name: string(rune('a' + i))}] = time.Duration(i) * time.Second
+	}
+	stats.RunningDurations[Key{Name: "a"}] = 10 * time.Second
+	stats.RunningDurations[Key{Name: "b"}] = 20 * time.Second
+	stats.PendingQ[Key{Name: "z"}] = &v1.Pod{}
+	stats.PendingQHistory = []Record[int]{{Value: 1}, {Value: 4}, {Value: 2}}
+
+	node := Key{Name: "node-1"}
+	stats.AllocationRatioHistory[node] = []Record[map[v1.ResourceName]float64]{
+		{Value: map[v1.ResourceName]float64{v1.ResourceCPU: 0.2, v1.ResourceMemory: 0.4}},
+		{Value: map[v1.ResourceName]float64{v1.ResourceCPU: 0.6, v1.ResourceMemory: 0.8}},
+	}
+
+	start := time.Now()
+	stats.PodEventHistory = []Record[PodEvent]{{At: start}, {At: start.Add(90 * time.Second)}}
+
+	stats.Completions[Key{Name: "a"}] = PodCompletion{Phase: v1.PodSucceeded}
+	stats.Completions[Key{Name: "b"}] = PodCompletion{Phase: v1.PodSucceeded}
+	stats.Completions[Key{Name: "c"}] = PodCompletion{Phase: v1.PodFailed}
+	stats.Restarts[Key{Name: "d"}] = 3
+	stats.Restarts[Key{Name: "e"}] = 1
+	stats.Preemptions = []Preemption{{Victim: Key{Name: "f"}}}
+
+	summary := stats.Summarize()
+	assert.Equal(t, 20, summary.ScheduledPods)
+	assert.Equal(t, 1, summary.PendingPods)
+	assert.Equal(t, 10500*time.Millisecond, summary.PendingMean)
+	assert.Equal(t, 10*time.Second, summary.PendingP50)
+	assert.Equal(t, 19*time.Second, summary.PendingP95)
+	assert.Equal(t, 20*time.Second, summary.PendingMax)
+	assert.Equal(t, 15*time.Second, summary.RunningMean)
+	assert.Equal(t, 4, summary.MaxQueueLength)
+	assert.InDelta(t, 0.4, summary.CPUAllocation, 1e-9)
+	assert.InDelta(t, 0.6, summary.MemoryAllocation, 1e-9)
+	assert.Equal(t, 90*time.Second, summary.Makespan)
+	assert.Equal(t, 2, summary.SucceededPods)
+	assert.Equal(t, 1, summary.FailedPods)
+	assert.Equal(t, 4, summary.Restarts)
+	assert.Equal(t, 1, summary.Preemptions)
+
+	assert.Len(t, summary.Row(), len(SummaryHeader()))
+}
+
+func TestStats_SummarizeEmpty(t *testing.T) {
+	summary := NewStats().Summarize()
+	assert.Equal(t, Summary{}, summary)
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadRef identifies the workload (Deployment, ReplicaSet, StatefulSet, Job, ...) owning a pod.
+type WorkloadRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// String returns the workload reference as kind/namespace/name.
+func (w WorkloadRef) String() string {
+	return w.Kind + "/" + w.Namespace + "/" + w.Name
+}
+
+// NewWorkloadRef returns the workload owning the pod, resolved from its controller ownerReference.
+// Pods owned by a ReplicaSet created by a Deployment are attributed to the Deployment.
+// It returns false if the pod has no controller.
+func NewWorkloadRef(pod *v1.Pod) (WorkloadRef, bool) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return WorkloadRef{}, false
+	}
+
+	ref := WorkloadRef{
+		Kind:      owner.Kind,
+		Namespace: pod.Namespace,
+		Name:      owner.Name,
+	}
+
+	// ReplicaSets created by a Deployment are named <deployment>-<pod-template-hash>.
+	if owner.Kind == "ReplicaSet" {
+		if hash, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok && strings.HasSuffix(owner.Name, "-"+hash) {
+			ref.Kind = "Deployment"
+			ref.Name = strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+	}
+
+	return ref, true
+}
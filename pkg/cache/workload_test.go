@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createOwnedPod(name, ownerKind, ownerName string, labels map[string]string) *v1.Pod {
+	controller := true
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: ownerKind, Name: ownerName, Controller: &controller},
+			},
+		},
+	}
+}
+
+func TestNewWorkloadRef(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		expected WorkloadRef
+		found    bool
+	}{
+		{
+			name:     "deployment",
+			pod:      createOwnedPod("web-5d8f7c-abcde", "ReplicaSet", "web-5d8f7c", map[string]string{"pod-template-hash": "5d8f7c"}),
+			expected: WorkloadRef{Kind: "Deployment", Namespace: "default", Name: "web"},
+			found:    true,
+		},
+		{
+			name:     "standalone replicaset",
+			pod:      createOwnedPod("rs-abcde", "ReplicaSet", "rs", nil),
+			expected: WorkloadRef{Kind: "ReplicaSet", Namespace: "default", Name: "rs"},
+			found:    true,
+		},
+		{
+			name:     "statefulset",
+			pod:      createOwnedPod("db-0", "StatefulSet", "db", nil),
+			expected: WorkloadRef{Kind: "StatefulSet", Namespace: "default", Name: "db"},
+			found:    true,
+		},
+		{
+			name:     "job",
+			pod:      createOwnedPod("batch-xyz", "Job", "batch", nil),
+			expected: WorkloadRef{Kind: "Job", Namespace: "default", Name: "batch"},
+			found:    true,
+		},
+		{
+			name:  "bare pod",
+			pod:   createTestPod("bare", "", pod1Cpu, pod1Memory),
+			found: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, ok := NewWorkloadRef(tt.pod)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.expected, ref)
+		})
+	}
+}
+
+func TestStats_UpdatePodWorkload(t *testing.T) {
+	stats := NewStats()
+	stats.UpdatePodWorkload(createOwnedPod("db-0", "StatefulSet", "db", nil))
+	stats.UpdatePodWorkload(createOwnedPod("db-1", "StatefulSet", "db", nil))
+	stats.UpdatePodWorkload(createTestPod("bare", "", pod1Cpu, pod1Memory))
+
+	pods := stats.GetWorkloadPods()
+	assert.Len(t, pods, 1)
+	assert.Len(t, pods[WorkloadRef{Kind: "StatefulSet", Namespace: "default", Name: "db"}], 2)
+}
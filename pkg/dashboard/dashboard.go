@@ -0,0 +1,256 @@
+// Package dashboard draws a live terminal dashboard of a running simulation.
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/term"
+)
+
+// ErrNoTerminal is returned when the output of the dashboard is not a terminal.
+var ErrNoTerminal = errors.New("the dashboard needs a terminal")
+
+const (
+	// enterScreen switches to the alternate screen and hides the cursor
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	// exitScreen shows the cursor and switches back to the main screen
+	exitScreen = "\x1b[?25h\x1b[?1049l"
+	// keptErrors bounds the logged errors kept for the failures panel
+	keptErrors = 50
+)
+
+// Source is the simulation shown by the dashboard.
+type Source interface {
+	ReadProgress(fn func(p simulation.Progress))
+	Pause() error
+	Resume() error
+	Paused() bool
+}
+
+// Dashboard draws the progress of a simulation on the terminal until it ends. Keys pause and resume the
+// simulation, or stop it like an interrupt.
+type Dashboard struct {
+	logger  *logger.Logger
+	source  Source
+	title   string
+	refresh time.Duration
+	in      *os.File
+	out     *os.File
+
+	mu      sync.Mutex
+	logged  []Failure
+	stops   int
+	restore func()
+}
+
+// DashboardOpt configures a Dashboard.
+type DashboardOpt func(*Dashboard)
+
+// WithRefresh sets the interval between two frames, 500ms by default.
+func WithRefresh(d time.Duration) DashboardOpt {
+	return func(db *Dashboard) {
+		if d > 0 {
+			db.refresh = d
+		}
+	}
+}
+
+// NewDashboard creates a dashboard of source drawn on the standard output. While it is drawn, the logs
+// written to the terminal are muted and the logged errors are shown among the failures.
+func NewDashboard(log *logger.Logger, source Source, title string, opts ...DashboardOpt) *Dashboard {
+	d := &Dashboard{
+		logger:  log,
+		source:  source,
+		title:   title,
+		refresh: 500 * time.Millisecond,
+		in:      os.Stdin,
+		out:     os.Stdout,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Supported reports whether the standard output is a terminal the dashboard can be drawn on.
+func Supported() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Run draws the dashboard until the context is done, then restores the terminal and the logs.
+func (d *Dashboard) Run(ctx context.Context) error {
+	if !term.IsTerminal(int(d.out.Fd())) {
+		return ErrNoTerminal
+	}
+
+	restoreLogs := d.captureLogs()
+	var restoreInput func()
+	keys := make(chan byte)
+	if fd := int(d.in.Fd()); term.IsTerminal(fd) {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			restoreLogs()
+			return fmt.Errorf("failed to read the keys: %w", err)
+		}
+		restoreInput = func() { _ = term.Restore(fd, state) }
+		// the reader stays blocked on the input once the dashboard ends, until the process exits
+		go d.readKeys(keys)
+	}
+	fmt.Fprint(d.out, enterScreen)
+
+	var once sync.Once
+	restore := func() {
+		once.Do(func() {
+			fmt.Fprint(d.out, exitScreen)
+			if restoreInput != nil {
+				restoreInput()
+			}
+			restoreLogs()
+		})
+	}
+	d.mu.Lock()
+	d.restore = restore
+	d.mu.Unlock()
+	defer restore()
+
+	ticker := time.NewTicker(d.refresh)
+	defer ticker.Stop()
+	for {
+		d.draw()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case key := <-keys:
+			d.handleKey(key)
+		}
+	}
+}
+
+// draw writes a frame over the previous one.
+func (d *Dashboard) draw() {
+	width, height, err := term.GetSize(int(d.out.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+
+	d.mu.Lock()
+	v := view{title: d.title, state: "running", logged: append([]Failure(nil), d.logged...)}
+	stopping := d.stops > 0
+	d.mu.Unlock()
+	switch {
+	case stopping:
+		v.state = "stopping"
+	case d.source.Paused():
+		v.state = "paused"
+	}
+
+	var lines []string
+	d.source.ReadProgress(func(p simulation.Progress) {
+		v.progress = p
+		lines = render(v, width, height)
+	})
+	// in raw mode a line feed does not return the carriage; each line clears what is left of the previous frame
+	fmt.Fprint(d.out, "\x1b[H"+strings.Join(lines, "\x1b[K\r\n")+"\x1b[K\x1b[J")
+}
+
+// readKeys sends the keys typed on the input.
+func (d *Dashboard) readKeys(keys chan<- byte) {
+	buf := make([]byte, 1)
+	for {
+		if _, err := d.in.Read(buf); err != nil {
+			return
+		}
+		keys <- buf[0]
+	}
+}
+
+// handleKey pauses or resumes the simulation on p, and stops it on q or Ctrl-C.
+func (d *Dashboard) handleKey(key byte) {
+	switch key {
+	case 'p', 'P', ' ':
+		var err error
+		if d.source.Paused() {
+			err = d.source.Resume()
+		} else {
+			err = d.source.Pause()
+		}
+		if err != nil {
+			d.addFailure(Failure{At: time.Now(), Message: err.Error()})
+		}
+	case 'q', 'Q', 3:
+		d.stop()
+	}
+}
+
+// stop interrupts the process, which stops the simulation like a SIGINT would. The terminal is restored
+// before the second stop, on which the process exits.
+func (d *Dashboard) stop() {
+	d.mu.Lock()
+	d.stops++
+	second := d.stops > 1
+	restore := d.restore
+	d.mu.Unlock()
+	if second && restore != nil {
+		restore()
+	}
+
+	process, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = process.Signal(os.Interrupt)
+	}
+	if err != nil {
+		d.addFailure(Failure{At: time.Now(), Message: fmt.Sprintf("failed to stop the simulation: %v", err)})
+	}
+}
+
+func (d *Dashboard) addFailure(f Failure) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.logged = append(d.logged, f)
+	if len(d.logged) > keptErrors {
+		d.logged = d.logged[len(d.logged)-keptErrors:]
+	}
+}
+
+// captureLogs mutes the logs written to the terminal and records the logged errors, until restore is called.
+func (d *Dashboard) captureLogs() (restore func()) {
+	out := d.logger.Out
+	hooks := make(logrus.LevelHooks)
+	for level, levelHooks := range d.logger.Hooks {
+		hooks[level] = append(levelHooks[:0:0], levelHooks...)
+	}
+
+	if out == os.Stdout || out == os.Stderr {
+		d.logger.Logger.SetOutput(io.Discard)
+	}
+	d.logger.AddHook(&errorHook{dashboard: d})
+	return func() {
+		d.logger.ReplaceHooks(hooks)
+		d.logger.Logger.SetOutput(out)
+	}
+}
+
+// errorHook records the logged errors as failures.
+type errorHook struct {
+	dashboard *Dashboard
+}
+
+func (h *errorHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+func (h *errorHook) Fire(entry *logrus.Entry) error {
+	h.dashboard.addFailure(Failure{At: entry.Time, Message: entry.Message})
+	return nil
+}
@@ -0,0 +1,77 @@
+package dashboard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource is a simulation that can be paused once started.
+type fakeSource struct {
+	started bool
+	paused  bool
+}
+
+func (s *fakeSource) ReadProgress(fn func(p simulation.Progress)) { fn(simulation.Progress{}) }
+func (s *fakeSource) Paused() bool                                { return s.paused }
+
+func (s *fakeSource) Pause() error {
+	if !s.started {
+		return errors.New("simulation is not running")
+	}
+	s.paused = true
+	return nil
+}
+
+func (s *fakeSource) Resume() error {
+	s.paused = false
+	return nil
+}
+
+func TestDashboard_PauseKey(t *testing.T) {
+	source := &fakeSource{}
+	d := NewDashboard(logger.New(), source, "test")
+
+	d.handleKey('p')
+	assert.False(t, source.Paused())
+	require.Len(t, d.logged, 1, "the failed pause is shown")
+	assert.Equal(t, "simulation is not running", d.logged[0].Message)
+
+	source.started = true
+	d.handleKey('p')
+	assert.True(t, source.Paused())
+	d.handleKey(' ')
+	assert.False(t, source.Paused())
+	d.handleKey('x')
+	assert.False(t, source.Paused())
+}
+
+func TestDashboard_CaptureLogs(t *testing.T) {
+	log := logger.New()
+	d := NewDashboard(log, &fakeSource{}, "test")
+
+	restore := d.captureLogs()
+	log.Info("not a failure")
+	log.Errorf("event %s failed", "e-1")
+	restore()
+	log.Error("after the dashboard")
+
+	require.Len(t, d.logged, 1)
+	assert.Equal(t, "event e-1 failed", d.logged[0].Message)
+	assert.Empty(t, log.Hooks, "the hooks are restored")
+
+	for i := 0; i < 2*keptErrors; i++ {
+		d.addFailure(Failure{Message: "more"})
+	}
+	assert.Len(t, d.logged, keptErrors)
+}
+
+func TestDashboard_NoTerminal(t *testing.T) {
+	d := NewDashboard(logger.New(), &fakeSource{}, "test")
+	// the test output is not a terminal
+	assert.ErrorIs(t, d.Run(t.Context()), ErrNoTerminal)
+}
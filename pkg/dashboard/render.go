@@ -0,0 +1,258 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// minWidth is the width the dashboard is laid out for on narrower terminals
+	minWidth = 60
+	// maxFailures bounds the failures shown
+	maxFailures = 5
+	// maxNameWidth bounds the width of the node name column
+	maxNameWidth = 24
+)
+
+// sparkTicks are the levels of the pending queue sparkline.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// Failure is a failure shown by the dashboard.
+type Failure struct {
+	At      time.Time
+	Message string
+}
+
+// view is what a frame of the dashboard shows.
+type view struct {
+	title string
+	// state is running, paused or stopping
+	state    string
+	progress simulation.Progress
+	// logged are the errors logged during the simulation
+	logged []Failure
+}
+
+// render lays out the view in at most height lines of width columns.
+func render(v view, width, height int) []string {
+	if width < minWidth {
+		width = minWidth
+	}
+	p := v.progress
+	var lines []string
+
+	lines = append(lines, spread(fmt.Sprintf("keg · %s", v.title),
+		fmt.Sprintf("%s  %s", strings.ToUpper(v.state), p.Elapsed.Truncate(time.Second)), width))
+	lines = append(lines, eventsLine(p, width), podsLine(p), "")
+
+	failures := recentFailures(p.Stats, v.logged)
+	// header, events, pods, blank, blank, queue title, sparkline, blank, failures title, footer
+	nodeRows := height - 10 - max(len(failures), 1)
+	lines = append(lines, nodeLines(p.Nodes, width, nodeRows)...)
+
+	lines = append(lines, "", queueTitle(p.Stats), sparkline(p.Stats, p.Now, width), "", "RECENT FAILURES")
+	if len(failures) == 0 {
+		lines = append(lines, "  none")
+	}
+	for _, f := range failures {
+		lines = append(lines, fmt.Sprintf("  %s  %s", f.At.Format("15:04:05"), f.Message))
+	}
+	lines = append(lines, "p pause/resume · q stop")
+
+	if height > 0 && len(lines) > height {
+		// keep the footer
+		lines = append(lines[:height-1], lines[len(lines)-1])
+	}
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	return lines
+}
+
+// eventsLine shows the events dispatched versus the events still queued.
+func eventsLine(p simulation.Progress, width int) string {
+	total := p.DispatchedEvents + p.QueuedEvents
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(p.DispatchedEvents) / float64(total)
+	}
+	return fmt.Sprintf("events  %s  %d dispatched · %d queued · %d failed",
+		bar(ratio, min(width/3, 30)), p.DispatchedEvents, p.QueuedEvents, p.FailedEvents)
+}
+
+// podsLine counts the pods by state.
+func podsLine(p simulation.Progress) string {
+	running := 0
+	for _, node := range p.Nodes {
+		running += len(node.RunningPods)
+	}
+	if p.Stats == nil {
+		return fmt.Sprintf("pods    %d running", running)
+	}
+
+	unschedulable := 0
+	for key := range p.Stats.PendingQ {
+		if _, ok := p.Stats.Unschedulable[key]; ok {
+			unschedulable++
+		}
+	}
+	failed := 0
+	for _, completion := range p.Stats.Completions {
+		if completion.Phase == v1.PodFailed {
+			failed++
+		}
+	}
+	restarts := int32(0)
+	for _, n := range p.Stats.Restarts {
+		restarts += n
+	}
+	return fmt.Sprintf("pods    %d running · %d pending · %d unschedulable · %d failed · %d restarts",
+		running, len(p.Stats.PendingQ), unschedulable, failed, restarts)
+}
+
+// nodeLines shows the CPU and memory allocation of the nodes in at most rows lines, title included.
+func nodeLines(nodes []*cache.NodeStore, width, rows int) []string {
+	nameWidth := len("NODE")
+	for _, node := range nodes {
+		nameWidth = max(nameWidth, len(node.Node.Name))
+	}
+	nameWidth = min(nameWidth, maxNameWidth)
+	// name, two bars with their brackets and percentages, and the pod count
+	barWidth := max((width-nameWidth-len("  []  100%  []  100%  999 pods"))/2, 5)
+
+	lines := []string{fmt.Sprintf("%-*s  %-*s  %-*s", nameWidth, "NODE", barWidth+7, "CPU", barWidth+7, "MEMORY")}
+	if len(nodes) == 0 {
+		return append(lines, "  no node yet")
+	}
+
+	shown := len(nodes)
+	if shown > rows-1 {
+		// the last row tells how many nodes are hidden
+		shown = max(rows-2, 1)
+	}
+	for _, node := range nodes[:shown] {
+		cpu := node.AllocatedRatio[v1.ResourceCPU]
+		memory := node.AllocatedRatio[v1.ResourceMemory]
+		lines = append(lines, fmt.Sprintf("%-*s  %s %3.0f%%  %s %3.0f%%  %d pods",
+			nameWidth, truncate(node.Node.Name, nameWidth), bar(cpu, barWidth), cpu*100, bar(memory, barWidth), memory*100, len(node.RunningPods)))
+	}
+	if hidden := len(nodes) - shown; hidden > 0 {
+		lines = append(lines, fmt.Sprintf("  … %d more node(s)", hidden))
+	}
+	return lines
+}
+
+// bar draws ratio as a bar of width cells.
+func bar(ratio float64, width int) string {
+	ratio = min(max(ratio, 0), 1)
+	filled := int(ratio*float64(width) + 0.5)
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
+
+// queueTitle shows the current and maximum length of the pending queue.
+func queueTitle(stats *cache.Stats) string {
+	if stats == nil || len(stats.PendingQHistory) == 0 {
+		return "PENDING QUEUE"
+	}
+	peak := 0
+	for _, record := range stats.PendingQHistory {
+		peak = max(peak, record.Value)
+	}
+	return fmt.Sprintf("PENDING QUEUE  now %d · max %d", len(stats.PendingQ), peak)
+}
+
+// sparkline draws the length of the pending queue from its first record to now, one cell per time slice
+// showing the longest queue of the slice.
+func sparkline(stats *cache.Stats, now time.Time, width int) string {
+	if stats == nil || len(stats.PendingQHistory) == 0 {
+		return "  no pod pending yet"
+	}
+	history := stats.PendingQHistory
+	cells := width - 2
+	start := history[0].At
+	span := now.Sub(start)
+	if span <= 0 {
+		span = time.Nanosecond
+	}
+
+	peaks := make([]int, cells)
+	peak := 0
+	i, last := 0, 0
+	for cell := range peaks {
+		end := start.Add(span * time.Duration(cell+1) / time.Duration(cells))
+		// the queue keeps the length of the last record of the previous slices
+		value := last
+		for ; i < len(history) && history[i].At.Before(end); i++ {
+			value = max(value, history[i].Value)
+			last = history[i].Value
+		}
+		peaks[cell] = value
+		peak = max(peak, value)
+	}
+
+	var b strings.Builder
+	b.WriteString("  ")
+	for _, value := range peaks {
+		if value == 0 || peak == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		level := (value*len(sparkTicks) - 1) / peak
+		b.WriteRune(sparkTicks[level])
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// recentFailures returns the most recent pod failures and logged errors, latest first.
+func recentFailures(stats *cache.Stats, logged []Failure) []Failure {
+	failures := append([]Failure(nil), logged...)
+	if stats != nil {
+		for key, completion := range stats.Completions {
+			if completion.Phase != v1.PodFailed {
+				continue
+			}
+			message := fmt.Sprintf("pod %s failed", key.GetName())
+			if completion.Reason != "" {
+				message += ": " + completion.Reason
+			}
+			if completion.ExitCode != 0 {
+				message += fmt.Sprintf(" (exit %d)", completion.ExitCode)
+			}
+			failures = append(failures, Failure{At: completion.At, Message: message})
+		}
+	}
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].At.After(failures[j].At)
+	})
+	if len(failures) > maxFailures {
+		failures = failures[:maxFailures]
+	}
+	return failures
+}
+
+// spread writes left and right at both ends of a line of width columns.
+func spread(left, right string, width int) string {
+	gap := width - len([]rune(left)) - len([]rune(right))
+	if gap < 1 {
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// truncate cuts s to width columns.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
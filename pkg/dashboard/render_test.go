@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newNode(name string, pods int) *cache.NodeStore {
+	node := cache.NewNodeStore(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("4"),
+			v1.ResourceMemory: resource.MustParse("4Gi"),
+		}},
+	})
+	for i := 0; i < pods; i++ {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-pod-%d", name, i), UID: types.UID(fmt.Sprintf("%s-%d", name, i))},
+			Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1"),
+				v1.ResourceMemory: resource.MustParse("512Mi"),
+			}}}}},
+		}
+		node.RunningPods[cache.NewKey(pod)] = pod
+	}
+	node.UpdateAllocated()
+	return node
+}
+
+func TestBar(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  string
+	}{
+		{ratio: 0, want: "[░░░░]"},
+		{ratio: 0.5, want: "[██░░]"},
+		{ratio: 1, want: "[████]"},
+		{ratio: 1.5, want: "[████]"},
+		{ratio: -1, want: "[░░░░]"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, bar(tt.ratio, 4), tt.ratio)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := func(at time.Duration, value int) cache.Record[int] {
+		return cache.Record[int]{At: start.Add(at), Value: value}
+	}
+
+	tests := []struct {
+		name    string
+		history []cache.Record[int]
+		want    string
+	}{
+		{name: "empty", want: "  no pod pending yet"},
+		{
+			name:    "one cell per slice",
+			history: []cache.Record[int]{record(0, 1), record(time.Second, 2), record(2*time.Second, 4), record(3500*time.Millisecond, 0)},
+			want:    "  ▂▄██",
+		},
+		{
+			name:    "length kept between records",
+			history: []cache.Record[int]{record(0, 8), record(1500*time.Millisecond, 0)},
+			want:    "  ██",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := cache.NewStats()
+			stats.PendingQHistory = tt.history
+			// 4 cells of a second
+			assert.Equal(t, tt.want, sparkline(stats, start.Add(4*time.Second), 6))
+		})
+	}
+}
+
+func TestRecentFailures(t *testing.T) {
+	now := time.Now()
+	stats := cache.NewStats()
+	stats.Completions[cache.Key{UID: "1", Name: "done"}] = cache.PodCompletion{Phase: v1.PodSucceeded, At: now}
+	stats.Completions[cache.Key{UID: "2", Name: "crashed"}] = cache.PodCompletion{Phase: v1.PodFailed, Reason: "Error", ExitCode: 2, At: now.Add(-time.Second)}
+	logged := []Failure{
+		{At: now.Add(-2 * time.Second), Message: "event execution failed"},
+		{At: now, Message: "latest"},
+	}
+
+	failures := recentFailures(stats, logged)
+	require.Len(t, failures, 3)
+	assert.Equal(t, "latest", failures[0].Message)
+	assert.Equal(t, "pod crashed failed: Error (exit 2)", failures[1].Message)
+	assert.Equal(t, "event execution failed", failures[2].Message)
+
+	for i := 0; i < 2*maxFailures; i++ {
+		logged = append(logged, Failure{At: now, Message: "more"})
+	}
+	assert.Len(t, recentFailures(stats, logged), maxFailures)
+}
+
+func TestRender(t *testing.T) {
+	stats := cache.NewStats()
+	pending := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pending", UID: "p"}}
+	stats.PendingQ[cache.NewKey(pending)] = pending
+	stats.Unschedulable[cache.NewKey(pending)] = "no node fits"
+	stats.Restarts[cache.Key{UID: "r", Name: "restarted"}] = 3
+
+	v := view{
+		title: "web",
+		state: "paused",
+		progress: simulation.Progress{
+			Elapsed:          90*time.Second + 300*time.Millisecond,
+			Now:              time.Now(),
+			QueuedEvents:     3,
+			DispatchedEvents: 9,
+			FailedEvents:     1,
+			Stats:            stats,
+			Nodes:            []*cache.NodeStore{newNode("node-1", 2), newNode("node-2", 4)},
+		},
+	}
+
+	lines := render(v, 100, 40)
+	frame := strings.Join(lines, "\n")
+	for _, line := range lines {
+		assert.LessOrEqual(t, len([]rune(line)), 100, line)
+	}
+	assert.True(t, strings.HasPrefix(lines[0], "keg · web"))
+	assert.True(t, strings.HasSuffix(lines[0], "PAUSED  1m30s"), lines[0])
+	assert.Contains(t, frame, "9 dispatched · 3 queued · 1 failed")
+	assert.Contains(t, frame, "pods    6 running · 1 pending · 1 unschedulable · 0 failed · 3 restarts")
+	assert.Regexp(t, `node-1\s+\[█+░+\]\s+50%\s+\[█+░+\]\s+25%\s+2 pods`, frame)
+	assert.Regexp(t, `node-2\s+\[█+\]\s+100%\s+\[█+░+\]\s+50%\s+4 pods`, frame)
+	assert.Contains(t, frame, "no pod pending yet")
+	assert.Contains(t, frame, "RECENT FAILURES\n  none")
+	assert.Equal(t, "p pause/resume · q stop", lines[len(lines)-1])
+}
+
+func TestRender_ManyNodes(t *testing.T) {
+	var nodes []*cache.NodeStore
+	for i := 0; i < 30; i++ {
+		nodes = append(nodes, newNode(fmt.Sprintf("node-%02d", i), 0))
+	}
+	v := view{title: "large", state: "running", progress: simulation.Progress{Stats: cache.NewStats(), Nodes: nodes}}
+
+	lines := render(v, 80, 24)
+	assert.Len(t, lines, 24, "the frame fits the terminal")
+	assert.Contains(t, strings.Join(lines, "\n"), "… 19 more node(s)")
+	assert.Equal(t, "p pause/resume · q stop", lines[len(lines)-1])
+}
@@ -0,0 +1,94 @@
+package distribution
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+)
+
+// Type is the type of a distribution.
+type Type string
+
+const (
+	TypeExponential Type = "exponential"
+	TypeWeibull     Type = "weibull"
+	TypeUniform     Type = "uniform"
+	TypeConstant    Type = "constant"
+)
+
+// Spec declares a distribution and its parameters, e.g. in a scenario file.
+type Spec struct {
+	// Type is the type of the distribution
+	Type Type `yaml:"type" json:"type"`
+	// Rate is the rate λ of an exponential distribution, the mean is 1/λ
+	Rate float64 `yaml:"rate" json:"rate,omitempty"`
+	// Shape is the shape k of a Weibull distribution
+	Shape float64 `yaml:"shape" json:"shape,omitempty"`
+	// Scale is the scale λ of a Weibull distribution
+	Scale float64 `yaml:"scale" json:"scale,omitempty"`
+	// Min and Max bound a uniform distribution
+	Min float64 `yaml:"min" json:"min,omitempty"`
+	Max float64 `yaml:"max" json:"max,omitempty"`
+	// Value is the value of a constant distribution
+	Value float64 `yaml:"value" json:"value,omitempty"`
+}
+
+// Validate checks the parameters of the distribution.
+func (s Spec) Validate() error {
+	switch s.Type {
+	case TypeExponential:
+		if s.Rate <= 0 {
+			return fmt.Errorf("exponential rate must be positive, got %v", s.Rate)
+		}
+	case TypeWeibull:
+		if s.Shape <= 0 || s.Scale <= 0 {
+			return fmt.Errorf("weibull shape and scale must be positive, got shape %v and scale %v", s.Shape, s.Scale)
+		}
+	case TypeUniform:
+		if s.Min < 0 || s.Max < s.Min {
+			return fmt.Errorf("uniform bounds must satisfy 0 <= min <= max, got [%v, %v]", s.Min, s.Max)
+		}
+	case TypeConstant:
+		if s.Value < 0 {
+			return fmt.Errorf("constant value must not be negative, got %v", s.Value)
+		}
+	default:
+		return fmt.Errorf("unknown distribution type %q", s.Type)
+	}
+	return nil
+}
+
+// New returns the distribution declared by spec, drawing from rng.
+func New(spec Spec, rng *rand.Rand) (Distribution, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	switch spec.Type {
+	case TypeExponential:
+		return NewExponential(rng, spec.Rate), nil
+	case TypeWeibull:
+		return NewWeibull(rng, spec.Shape, spec.Scale), nil
+	case TypeUniform:
+		return NewUniform(rng, spec.Min, spec.Max), nil
+	default:
+		return NewConstant(spec.Value), nil
+	}
+}
+
+// DeriveSeed derives an independent seed from seed and a list of labels, e.g. an event name and a
+// repetition index. The same inputs always give the same seed.
+func DeriveSeed(seed int64, labels ...interface{}) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strconv.FormatInt(seed, 10)))
+	for _, label := range labels {
+		_, _ = h.Write([]byte{0})
+		_, _ = fmt.Fprint(h, label)
+	}
+	return int64(h.Sum64() &^ (1 << 63))
+}
+
+// NewRand returns a random source seeded with DeriveSeed(seed, labels...).
+func NewRand(seed int64, labels ...interface{}) *rand.Rand {
+	return rand.New(rand.NewSource(DeriveSeed(seed, labels...)))
+}
@@ -0,0 +1,36 @@
+package distribution
+
+import "math/rand"
+
+// Uniform models a continuous uniform distribution X ~ U(min, max).
+// The mean is (min+max)/2.
+type Uniform struct {
+	rng *rand.Rand
+	min float64
+	max float64
+}
+
+// NewUniform returns a Uniform distribution over [min, max).
+func NewUniform(rng *rand.Rand, min, max float64) *Uniform {
+	return &Uniform{rng: rng, min: min, max: max}
+}
+
+// Next draws the next sample from U(min, max).
+func (u *Uniform) Next() float64 {
+	return u.min + u.rng.Float64()*(u.max-u.min)
+}
+
+// Constant always returns the same value. It is useful to turn off randomness in a scenario.
+type Constant struct {
+	value float64
+}
+
+// NewConstant returns a Constant distribution.
+func NewConstant(value float64) *Constant {
+	return &Constant{value: value}
+}
+
+// Next returns the constant value.
+func (c *Constant) Next() float64 {
+	return c.value
+}
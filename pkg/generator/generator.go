@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/distribution"
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClassLabel is the pod label holding the workload class of a generated pod.
+const ClassLabel = "kube-event-generator/class"
+
+// DefaultImage is the container image of the generated pods.
+const DefaultImage = "registry.k8s.io/pause:3.9"
+
+// class is a workload class with its random sources.
+type class struct {
+	Class
+	lifetime distribution.Distribution
+	cpu      distribution.Distribution
+	memory   distribution.Distribution
+	count    int
+}
+
+// Generate generates a scenario from the spec. Every draw comes from a random source derived from the
+// spec seed, so the same spec always generates the same scenario. The arrivals, the class choices and
+// each distribution of each class draw from their own source: changing a class does not change the
+// arrivals or the pods of the other classes.
+func Generate(spec *Spec) (*simulation.Scenario, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	arrival, err := distribution.New(spec.Arrival, distribution.NewRand(spec.Seed, "arrival"))
+	if err != nil {
+		return nil, err
+	}
+	classes := make([]*class, 0, len(spec.Classes))
+	var totalWeight float64
+	for _, c := range spec.Classes {
+		gc := &class{Class: c}
+		if c.Lifetime != nil {
+			if gc.lifetime, err = distribution.New(*c.Lifetime, distribution.NewRand(spec.Seed, c.Name, "lifetime")); err != nil {
+				return nil, err
+			}
+		}
+		if gc.cpu, err = distribution.New(c.CPU, distribution.NewRand(spec.Seed, c.Name, "cpu")); err != nil {
+			return nil, err
+		}
+		if gc.memory, err = distribution.New(c.Memory, distribution.NewRand(spec.Seed, c.Name, "memory")); err != nil {
+			return nil, err
+		}
+		classes = append(classes, gc)
+		totalWeight += c.Weight
+	}
+	choice := distribution.NewRand(spec.Seed, "class")
+
+	seed := spec.Seed
+	scenario := &simulation.Scenario{
+		Seed: &seed,
+		Metadata: simulation.Metadata{
+			Name:        spec.Name,
+			Description: spec.Description,
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	for i := 0; i < spec.Cluster.Nodes; i++ {
+		scenario.Cluster.Nodes = append(scenario.Cluster.Nodes, spec.Cluster.node(spec.Name, i))
+	}
+
+	var at time.Duration
+	for i := 0; spec.Count == 0 || i < spec.Count; i++ {
+		if i > 0 {
+			at += seconds(arrival.Next())
+		}
+		if spec.Duration > 0 && at >= spec.Duration.Duration() {
+			break
+		}
+
+		c := pick(classes, totalWeight, choice)
+		name := fmt.Sprintf("%s-%d", c.Name, c.count)
+		c.count++
+
+		event := simulation.PodEvent{
+			Name:        name,
+			ArrivalTime: simulation.EventDuration(at),
+			PodSpec:     c.pod(spec.Namespace, name),
+			EventType:   simulation.PodEventTypeCreate,
+		}
+		if c.lifetime != nil {
+			event.EvictTime = simulation.EventDuration(max(seconds(c.lifetime.Next()), time.Millisecond))
+		}
+		scenario.Events.Pods = append(scenario.Events.Pods, event)
+	}
+	return scenario, nil
+}
+
+// pick draws a class according to the class weights.
+func pick(classes []*class, totalWeight float64, rng *rand.Rand) *class {
+	x := rng.Float64() * totalWeight
+	for _, c := range classes {
+		if x < c.Weight {
+			return c
+		}
+		x -= c.Weight
+	}
+	return classes[len(classes)-1]
+}
+
+// pod draws the requests of a pod of the class. Requests are at least 1m of CPU and 1Mi of memory.
+func (c *class) pod(namespace, name string) *v1.Pod {
+	cpu := max(int64(math.Round(c.cpu.Next())), 1)
+	memory := max(int64(math.Round(c.memory.Next())), 1)
+	image := c.Image
+	if image == "" {
+		image = DefaultImage
+	}
+
+	pod := kube.ObjectFactory.NewPod(namespace,
+		kube.WithPodLabels(c.Labels),
+		kube.WithPodLabels(map[string]string{ClassLabel: c.Name}),
+		kube.WithPodImage(image),
+		kube.WithPodResourceRequirements(v1.ResourceRequirements{Requests: v1.ResourceList{
+			v1.ResourceCPU:    *resource.NewMilliQuantity(cpu, resource.DecimalSI),
+			v1.ResourceMemory: *resource.NewQuantity(memory<<20, resource.BinarySI),
+		}}))
+	pod.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}
+	pod.Name = name
+	// the status and restart policy are set by the API server
+	pod.Status = v1.PodStatus{}
+	pod.Spec.RestartPolicy = ""
+	return pod
+}
+
+// node returns the i-th node of the cluster.
+func (c ClusterSpec) node(prefix string, i int) *v1.Node {
+	pods := c.Pods
+	if pods == 0 {
+		pods = 110
+	}
+	cpu, memory, maxPods := c.CPU.String(), c.Memory.String(), strconv.FormatInt(pods, 10)
+	node := kube.ObjectFactory.NewNode(kube.ObjectFactory.GenerateNodeName(prefix+"-node", i),
+		kube.WithNodeCapacity(cpu, memory, maxPods),
+		kube.WithNodeAllocatable(cpu, memory, maxPods))
+	node.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Node"}
+	return node
+}
+
+// seconds converts a number of seconds drawn from a distribution to a duration, rounded to the millisecond
+// to keep the scenario readable.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/distribution"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var specYaml = `
+name: mixed
+seed: 42
+count: 400
+arrival: {type: exponential, rate: 2}
+cluster: {nodes: 3, cpu: "8", memory: 32Gi}
+classes:
+  - name: web
+    weight: 3
+    lifetime: {type: uniform, min: 60, max: 120}
+    cpu: {type: uniform, min: 100, max: 500}
+    memory: {type: uniform, min: 128, max: 512}
+  - name: batch
+    weight: 1
+    cpu: {type: constant, value: 2000}
+    memory: {type: constant, value: 4096}
+    labels: {tier: batch}
+`
+
+func loadSpec(t *testing.T) *Spec {
+	file := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(specYaml), 0644))
+	spec, err := LoadSpec(file)
+	require.NoError(t, err)
+	return spec
+}
+
+func TestGenerate(t *testing.T) {
+	spec := loadSpec(t)
+	scenario, err := Generate(spec)
+	require.NoError(t, err)
+
+	assert.Equal(t, "mixed", scenario.Metadata.Name)
+	assert.Equal(t, int64(42), *scenario.Seed)
+	require.Len(t, scenario.Cluster.Nodes, 3)
+	assert.Equal(t, "mixed-node-2", scenario.Cluster.Nodes[2].Name)
+	assert.Equal(t, "8", scenario.Cluster.Nodes[0].Status.Allocatable.Cpu().String())
+	require.Len(t, scenario.Events.Pods, 400)
+
+	classes := map[string]int{}
+	var last simulation.EventDuration
+	for _, e := range scenario.Events.Pods {
+		class := e.PodSpec.Labels[ClassLabel]
+		classes[class]++
+		assert.GreaterOrEqual(t, e.ArrivalTime, last)
+		last = e.ArrivalTime
+
+		requests := e.PodSpec.Spec.Containers[0].Resources.Requests
+		switch class {
+		case "web":
+			assert.GreaterOrEqual(t, e.EvictTime.Duration(), time.Minute)
+			assert.LessOrEqual(t, e.EvictTime.Duration(), 2*time.Minute)
+			assert.GreaterOrEqual(t, requests.Cpu().MilliValue(), int64(100))
+			assert.LessOrEqual(t, requests.Cpu().MilliValue(), int64(500))
+		case "batch":
+			assert.Zero(t, e.EvictTime)
+			assert.Equal(t, "batch", e.PodSpec.Labels["tier"])
+			assert.True(t, requests.Cpu().Equal(resource.MustParse("2")))
+			assert.True(t, requests.Memory().Equal(resource.MustParse("4Gi")))
+		default:
+			t.Fatalf("unexpected class %q", class)
+		}
+	}
+	// 3:1 mix
+	assert.InDelta(t, 300, classes["web"], 40)
+	assert.Equal(t, "web-0", scenario.Events.Pods[0].Name)
+
+	// the same spec generates the same pods
+	again, err := Generate(spec)
+	require.NoError(t, err)
+	for i := range scenario.Events.Pods {
+		assert.Equal(t, scenario.Events.Pods[i].ArrivalTime, again.Events.Pods[i].ArrivalTime)
+		assert.Equal(t, scenario.Events.Pods[i].EvictTime, again.Events.Pods[i].EvictTime)
+		assert.Equal(t, scenario.Events.Pods[i].PodSpec, again.Events.Pods[i].PodSpec)
+	}
+
+	// the generated scenario is valid
+	data, err := simulation.MarshalScenario(scenario)
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(file, data, 0644))
+	diags, err := simulation.ValidateFile(file)
+	require.NoError(t, err)
+	assert.False(t, diags.HasErrors(), diags)
+}
+
+func TestGenerate_Duration(t *testing.T) {
+	spec := loadSpec(t)
+	spec.Count = 0
+	spec.Duration = simulation.EventDuration(time.Minute)
+
+	scenario, err := Generate(spec)
+	require.NoError(t, err)
+	// about 2 pods per second
+	assert.InDelta(t, 120, len(scenario.Events.Pods), 40)
+	for _, e := range scenario.Events.Pods {
+		assert.Less(t, e.ArrivalTime.Duration(), time.Minute)
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Spec)
+		err    string
+	}{
+		{name: "valid", modify: func(*Spec) {}},
+		{name: "no name", modify: func(s *Spec) { s.Name = "" }, err: "name is required"},
+		{name: "no stop", modify: func(s *Spec) { s.Count = 0 }, err: "count or duration is required"},
+		{name: "bad arrival", modify: func(s *Spec) { s.Arrival.Rate = 0 }, err: "arrival: exponential rate must be positive"},
+		{name: "no classes", modify: func(s *Spec) { s.Classes = nil }, err: "at least one class is required"},
+		{name: "duplicate class", modify: func(s *Spec) { s.Classes[1].Name = "web" }, err: `classes[1]: duplicate class "web"`},
+		{name: "zero weight", modify: func(s *Spec) { s.Classes[0].Weight = 0 }, err: "classes[0]: weight must be positive"},
+		{name: "bad lifetime", modify: func(s *Spec) { s.Classes[0].Lifetime = &distribution.Spec{Type: "normal"} },
+			err: `classes[0].lifetime: unknown distribution type "normal"`},
+		{name: "bad node size", modify: func(s *Spec) { s.Cluster.CPU = resource.Quantity{} }, err: "cluster.cpu and cluster.memory must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := loadSpec(t)
+			tt.modify(spec)
+			err := spec.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package generator generates scenarios from a compact spec of workload classes and distributions.
+package generator
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/maczg/kube-event-generator/pkg/distribution"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Spec describes a synthetic workload: when pods arrive, which class they belong to, and the cluster
+// they run on.
+type Spec struct {
+	// Name is the name of the generated scenario
+	Name string `yaml:"name" json:"name"`
+	// Description is the description of the generated scenario
+	Description string `yaml:"description" json:"description,omitempty"`
+	// Seed seeds all the draws of the generator
+	Seed int64 `yaml:"seed" json:"seed"`
+	// Count is the number of pods to generate. Zero means until Duration.
+	Count int `yaml:"count" json:"count,omitempty"`
+	// Duration stops the arrivals. Zero means until Count pods are generated.
+	Duration simulation.EventDuration `yaml:"duration" json:"duration,omitempty"`
+	// Namespace is the namespace of the pods, the namespace of the simulation if unset
+	Namespace string `yaml:"namespace" json:"namespace,omitempty"`
+	// Arrival draws the time in seconds between two consecutive pods, whatever their class
+	Arrival distribution.Spec `yaml:"arrival" json:"arrival"`
+	// Classes are the workload classes the pods are drawn from
+	Classes []Class `yaml:"classes" json:"classes"`
+	// Cluster is the cluster the pods run on
+	Cluster ClusterSpec `yaml:"cluster" json:"cluster"`
+}
+
+// Class is a workload class. Each pod belongs to a class drawn according to the class weights.
+type Class struct {
+	// Name is the name of the class, and the prefix of the names of its pods
+	Name string `yaml:"name" json:"name"`
+	// Weight is the mix ratio of the class, relative to the other classes
+	Weight float64 `yaml:"weight" json:"weight"`
+	// Lifetime draws the time in seconds a pod runs before it is evicted. No eviction if unset.
+	Lifetime *distribution.Spec `yaml:"lifetime" json:"lifetime,omitempty"`
+	// CPU draws the CPU request of a pod in millicores
+	CPU distribution.Spec `yaml:"cpu" json:"cpu"`
+	// Memory draws the memory request of a pod in mebibytes
+	Memory distribution.Spec `yaml:"memory" json:"memory"`
+	// Image is the container image of the pods, registry.k8s.io/pause:3.9 if unset
+	Image string `yaml:"image" json:"image,omitempty"`
+	// Labels are set on the pods, along with the class label
+	Labels map[string]string `yaml:"labels" json:"labels,omitempty"`
+}
+
+// ClusterSpec is a cluster of identical nodes.
+type ClusterSpec struct {
+	// Nodes is the number of nodes
+	Nodes int `yaml:"nodes" json:"nodes"`
+	// CPU is the CPU of a node
+	CPU resource.Quantity `yaml:"cpu" json:"cpu"`
+	// Memory is the memory of a node
+	Memory resource.Quantity `yaml:"memory" json:"memory"`
+	// Pods is the maximum number of pods of a node, 110 if unset
+	Pods int64 `yaml:"pods" json:"pods,omitempty"`
+}
+
+// LoadSpec reads a generator spec file.
+func LoadSpec(filename string) (*Spec, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse generator spec %s: %w", filename, err)
+	}
+	return &spec, nil
+}
+
+// Validate checks the spec.
+func (s *Spec) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if s.Count < 0 || s.Duration < 0 {
+		return fmt.Errorf("count and duration must not be negative")
+	}
+	if s.Count == 0 && s.Duration == 0 {
+		return fmt.Errorf("count or duration is required")
+	}
+	if err := s.Arrival.Validate(); err != nil {
+		return fmt.Errorf("arrival: %w", err)
+	}
+	if s.Count == 0 && s.Arrival.Type == distribution.TypeConstant && s.Arrival.Value == 0 {
+		return fmt.Errorf("arrival: a zero constant never reaches the duration, set a count")
+	}
+
+	if len(s.Classes) == 0 {
+		return fmt.Errorf("at least one class is required")
+	}
+	names := make(map[string]bool)
+	for i, c := range s.Classes {
+		if c.Name == "" {
+			return fmt.Errorf("classes[%d]: name is required", i)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("classes[%d]: duplicate class %q", i, c.Name)
+		}
+		names[c.Name] = true
+		if c.Weight <= 0 {
+			return fmt.Errorf("classes[%d]: weight must be positive, got %v", i, c.Weight)
+		}
+		if c.Lifetime != nil {
+			if err := c.Lifetime.Validate(); err != nil {
+				return fmt.Errorf("classes[%d].lifetime: %w", i, err)
+			}
+		}
+		if err := c.CPU.Validate(); err != nil {
+			return fmt.Errorf("classes[%d].cpu: %w", i, err)
+		}
+		if err := c.Memory.Validate(); err != nil {
+			return fmt.Errorf("classes[%d].memory: %w", i, err)
+		}
+	}
+
+	if s.Cluster.Nodes < 0 {
+		return fmt.Errorf("cluster.nodes must not be negative")
+	}
+	if s.Cluster.Nodes > 0 && (s.Cluster.CPU.Sign() <= 0 || s.Cluster.Memory.Sign() <= 0) {
+		return fmt.Errorf("cluster.cpu and cluster.memory must be positive")
+	}
+	return nil
+}
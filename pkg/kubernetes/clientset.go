@@ -2,7 +2,6 @@ package kubernetes
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
@@ -10,24 +9,41 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// GetRestConfig returns the in-cluster config, or the current context of the kubeconfig.
 func GetRestConfig() (*rest.Config, error) {
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		logrus.Warnf("%v", err)
-
-		kubeconfig := fmt.Sprintf("%s/.kube/config", os.Getenv("HOME"))
+	return GetRestConfigForContext("")
+}
 
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get kubeconfig: %v", err)
+// GetRestConfigForContext returns the config of a context of the kubeconfig, read from $KUBECONFIG or
+// ~/.kube/config. An empty context is the in-cluster config, or the current context out of a cluster.
+func GetRestConfigForContext(context string) (*rest.Config, error) {
+	if context == "" {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
 		}
+		logrus.Warnf("%v", err)
+	}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %v", err)
 	}
 
 	return config, nil
 }
 
+// GetClientset returns a clientset of the in-cluster config, or the current context of the kubeconfig.
 func GetClientset() (*kubernetes.Clientset, error) {
-	re, err := GetRestConfig()
+	return GetClientsetForContext("")
+}
+
+// GetClientsetForContext returns a clientset of a context of the kubeconfig, see GetRestConfigForContext.
+func GetClientsetForContext(context string) (*kubernetes.Clientset, error) {
+	re, err := GetRestConfigForContext(context)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rest config: %v", err)
 	}
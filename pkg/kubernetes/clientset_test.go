@@ -0,0 +1,55 @@
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: upstream
+clusters:
+  - name: upstream
+    cluster:
+      server: https://upstream.example:6443
+  - name: fork
+    cluster:
+      server: https://fork.example:6443
+contexts:
+  - name: upstream
+    context:
+      cluster: upstream
+  - name: fork
+    context:
+      cluster: fork
+users: []
+`
+
+func TestGetRestConfigForContext(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(testKubeconfig), 0600))
+	t.Setenv("KUBECONFIG", kubeconfig)
+	// out of a cluster
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	tests := []struct {
+		context string
+		host    string
+	}{
+		{context: "", host: "https://upstream.example:6443"},
+		{context: "upstream", host: "https://upstream.example:6443"},
+		{context: "fork", host: "https://fork.example:6443"},
+	}
+	for _, tt := range tests {
+		config, err := GetRestConfigForContext(tt.context)
+		require.NoError(t, err, tt.context)
+		assert.Equal(t, tt.host, config.Host, tt.context)
+	}
+
+	_, err := GetRestConfigForContext("missing")
+	assert.ErrorContains(t, err, `context "missing" does not exist`)
+}
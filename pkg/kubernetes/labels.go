@@ -0,0 +1,99 @@
+package kubernetes
+
+import (
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ManagedByLabel is the well-known label naming the tool that manages an object
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	// ManagedByValue is the value of ManagedByLabel on the objects created by keg
+	ManagedByValue = "kube-event-generator"
+	// SimulationLabel holds the ID of the simulation that created an object
+	SimulationLabel = "kube-event-generator/simulation"
+	// ScenarioLabel holds the name of the scenario of the simulation that created an object
+	ScenarioLabel = "kube-event-generator/scenario"
+	// EventNameLabel holds the name of the event that created an object
+	EventNameLabel = "kube-event-generator/event-name"
+	// EventIDLabel holds the ID of the event that created an object
+	EventIDLabel = "kube-event-generator/event-id"
+)
+
+// invalidLabelValueChars matches the characters not allowed in a label value.
+var invalidLabelValueChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Ownership identifies the simulation event that created an object.
+type Ownership struct {
+	SimulationID string
+	Scenario     string
+	EventName    string
+	EventID      string
+}
+
+// Labels returns the ownership labels. Their values are made valid label values, the unset ones are omitted.
+func (o Ownership) Labels() map[string]string {
+	l := map[string]string{ManagedByLabel: ManagedByValue}
+	for key, value := range o.values() {
+		if v := LabelValue(value); v != "" {
+			l[key] = v
+		}
+	}
+	return l
+}
+
+// Stamp sets the ownership labels of an object, and annotations with the original values.
+func (o Ownership) Stamp(obj metav1.Object) {
+	l := obj.GetLabels()
+	if l == nil {
+		l = make(map[string]string)
+	}
+	for key, value := range o.Labels() {
+		l[key] = value
+	}
+	obj.SetLabels(l)
+
+	a := obj.GetAnnotations()
+	if a == nil {
+		a = make(map[string]string)
+	}
+	for key, value := range o.values() {
+		if value != "" {
+			a[key] = value
+		}
+	}
+	obj.SetAnnotations(a)
+}
+
+func (o Ownership) values() map[string]string {
+	return map[string]string{
+		SimulationLabel: o.SimulationID,
+		ScenarioLabel:   o.Scenario,
+		EventNameLabel:  o.EventName,
+		EventIDLabel:    o.EventID,
+	}
+}
+
+// LabelValue turns s into a valid label value: invalid characters are replaced with dashes and the
+// value is truncated to 63 characters.
+func LabelValue(s string) string {
+	v := invalidLabelValueChars.ReplaceAllString(s, "-")
+	if len(v) > validation.LabelValueMaxLength {
+		v = v[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(v, "-_.")
+}
+
+// ManagedSelector returns the label selector of the objects created by keg.
+func ManagedSelector() string {
+	return labels.Set{ManagedByLabel: ManagedByValue}.String()
+}
+
+// SimulationSelector returns the label selector of the objects created by a simulation.
+func SimulationSelector(id string) string {
+	return labels.Set{ManagedByLabel: ManagedByValue, SimulationLabel: LabelValue(id)}.String()
+}
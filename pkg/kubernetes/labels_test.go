@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestLabelValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"sim-web-15_04_05_020106", "sim-web-15_04_05_020106"},
+		{"My Scenario", "My-Scenario"},
+		{"  web/api  ", "web-api"},
+		{strings.Repeat("a", 70), strings.Repeat("a", 63)},
+		{strings.Repeat("a", 62) + "_b", strings.Repeat("a", 62)},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := LabelValue(tt.in)
+			assert.Equal(t, tt.want, got)
+			assert.Empty(t, validation.IsValidLabelValue(got))
+		})
+	}
+}
+
+func TestOwnership_Stamp(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}}
+	Ownership{SimulationID: "sim-1", Scenario: "My Scenario", EventName: "web"}.Stamp(pod)
+
+	assert.Equal(t, map[string]string{
+		"app":           "web",
+		ManagedByLabel:  ManagedByValue,
+		SimulationLabel: "sim-1",
+		ScenarioLabel:   "My-Scenario",
+		EventNameLabel:  "web",
+	}, pod.Labels, "the labels of the spec are kept and the unset values omitted")
+	assert.Equal(t, "My Scenario", pod.Annotations[ScenarioLabel], "the annotations keep the original values")
+	assert.NotContains(t, pod.Annotations, EventIDLabel)
+}
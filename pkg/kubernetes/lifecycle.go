@@ -0,0 +1,65 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// OutcomeAnnotation sets how a running pod ends on its own: succeed, fail or crash-loop
+	OutcomeAnnotation = "kube-event-generator/outcome"
+	// OutcomeAfterAnnotation is the time a pod runs before its outcome. Crash-looping pods also wait
+	// this long before each restart.
+	OutcomeAfterAnnotation = "kube-event-generator/outcome-after"
+	// ExitCodeAnnotation is the exit code of the containers of a failed or crash-looping pod, 1 if unset
+	ExitCodeAnnotation = "kube-event-generator/exit-code"
+)
+
+const (
+	// OutcomeSucceed makes the pod succeed
+	OutcomeSucceed = "succeed"
+	// OutcomeFail makes the pod fail
+	OutcomeFail = "fail"
+	// OutcomeCrashLoop makes the containers of the pod crash and restart in a loop
+	OutcomeCrashLoop = "crash-loop"
+)
+
+// CrashLoopBackOffReason is the waiting reason of the containers of a crashed pod.
+const CrashLoopBackOffReason = "CrashLoopBackOff"
+
+// PodLifecycle is how a running pod ends, read from its annotations.
+type PodLifecycle struct {
+	Outcome  string
+	After    time.Duration
+	ExitCode int32
+}
+
+// LifecycleOf returns the lifecycle set by the annotations of a pod. ok is false if the pod has no outcome.
+func LifecycleOf(pod *v1.Pod) (l PodLifecycle, ok bool, err error) {
+	l.Outcome, ok = pod.Annotations[OutcomeAnnotation]
+	if !ok {
+		return l, false, nil
+	}
+	switch l.Outcome {
+	case OutcomeSucceed, OutcomeFail, OutcomeCrashLoop:
+	default:
+		return l, false, fmt.Errorf("unknown outcome %q", l.Outcome)
+	}
+	if after, set := pod.Annotations[OutcomeAfterAnnotation]; set {
+		if l.After, err = time.ParseDuration(after); err != nil {
+			return l, false, fmt.Errorf("invalid %s: %w", OutcomeAfterAnnotation, err)
+		}
+	}
+	l.ExitCode = 1
+	if code, set := pod.Annotations[ExitCodeAnnotation]; set {
+		parsed, err := strconv.ParseInt(code, 10, 32)
+		if err != nil {
+			return l, false, fmt.Errorf("invalid %s: %w", ExitCodeAnnotation, err)
+		}
+		l.ExitCode = int32(parsed)
+	}
+	return l, true, nil
+}
@@ -99,6 +99,9 @@ type PodOpt func(*v1.Pod)
 
 func WithPodLabels(labels map[string]string) PodOpt {
 	return func(p *v1.Pod) {
+		if p.Labels == nil {
+			p.Labels = make(map[string]string)
+		}
 		for k, v := range labels {
 			p.Labels[k] = v
 		}
@@ -158,6 +161,15 @@ func WithPodResourcesDetailed(reqCPU, reqMem, limCPU, limMem string) PodOpt {
 	}
 }
 
+func WithPodResourceRequirements(requirements v1.ResourceRequirements) PodOpt {
+	return func(p *v1.Pod) {
+		if len(p.Spec.Containers) == 0 {
+			return
+		}
+		p.Spec.Containers[0].Resources = *requirements.DeepCopy()
+	}
+}
+
 func WithPodImage(image string) PodOpt {
 	return func(p *v1.Pod) {
 		if len(p.Spec.Containers) == 0 {
@@ -258,7 +270,7 @@ func WithNodeAddresses(addresses []v1.NodeAddress) NodeOpt {
 	}
 }
 
-func (f *objectFactory) NewPodFromTemplate(template *v1.Pod, name string) *v1.Pod {
+func (f *objectFactory) NewPodFromTemplate(template *v1.Pod, name string, opts ...PodOpt) *v1.Pod {
 	pod := template.DeepCopy()
 	pod.Name = name
 	pod.ResourceVersion = ""
@@ -266,6 +278,11 @@ func (f *objectFactory) NewPodFromTemplate(template *v1.Pod, name string) *v1.Po
 	pod.CreationTimestamp = metav1.Time{}
 	pod.DeletionTimestamp = nil
 	pod.Status = v1.PodStatus{Phase: v1.PodPending}
+
+	for _, opt := range opts {
+		opt(pod)
+	}
+
 	return pod
 }
 
@@ -2,11 +2,22 @@ package kubernetes
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/maczg/kube-event-generator/pkg/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 )
 
+// waitDeletedInterval is the interval between two checks of WaitDeleted.
+const waitDeletedInterval = 500 * time.Millisecond
+
 func ResetPods(ctx context.Context, logger *logger.Logger) error {
 	clientset, err := GetClientset()
 	if err != nil {
@@ -45,3 +56,227 @@ func ResetKubeSchedulerWeights(ctx context.Context, logger *logger.Logger, sched
 	}
 	return nil
 }
+
+// Object is an object deleted by a selective reset.
+type Object struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (o Object) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s %s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// resetKind lists, gets and deletes the objects of a kind.
+type resetKind struct {
+	kind   string
+	list   func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error)
+	get    func(ctx context.Context, namespace, name string) error
+	delete func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error
+}
+
+// resetKinds returns the kinds deleted by a selective reset: workloads first, then pods, then namespaces
+// and priority classes.
+func resetKinds(clientset kubernetes.Interface) []resetKind {
+	apps, batch, core, scheduling := clientset.AppsV1(), clientset.BatchV1(), clientset.CoreV1(), clientset.SchedulingV1()
+	return []resetKind{
+		{
+			kind: "Deployment",
+			list: func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error) {
+				l, err := apps.Deployments(metav1.NamespaceAll).List(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				return objects(l.Items, func(i int) metav1.Object { return &l.Items[i] }), nil
+			},
+			get: func(ctx context.Context, namespace, name string) error {
+				_, err := apps.Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			},
+			delete: func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+				return apps.Deployments(namespace).Delete(ctx, name, opts)
+			},
+		},
+		{
+			kind: "StatefulSet",
+			list: func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error) {
+				l, err := apps.StatefulSets(metav1.NamespaceAll).List(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				return objects(l.Items, func(i int) metav1.Object { return &l.Items[i] }), nil
+			},
+			get: func(ctx context.Context, namespace, name string) error {
+				_, err := apps.StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			},
+			delete: func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+				return apps.StatefulSets(namespace).Delete(ctx, name, opts)
+			},
+		},
+		{
+			kind: "ReplicaSet",
+			list: func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error) {
+				l, err := apps.ReplicaSets(metav1.NamespaceAll).List(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				return objects(l.Items, func(i int) metav1.Object { return &l.Items[i] }), nil
+			},
+			get: func(ctx context.Context, namespace, name string) error {
+				_, err := apps.ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			},
+			delete: func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+				return apps.ReplicaSets(namespace).Delete(ctx, name, opts)
+			},
+		},
+		{
+			kind: "Job",
+			list: func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error) {
+				l, err := batch.Jobs(metav1.NamespaceAll).List(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				return objects(l.Items, func(i int) metav1.Object { return &l.Items[i] }), nil
+			},
+			get: func(ctx context.Context, namespace, name string) error {
+				_, err := batch.Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			},
+			delete: func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+				return batch.Jobs(namespace).Delete(ctx, name, opts)
+			},
+		},
+		{
+			kind: "Pod",
+			list: func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error) {
+				l, err := core.Pods(metav1.NamespaceAll).List(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				return objects(l.Items, func(i int) metav1.Object { return &l.Items[i] }), nil
+			},
+			get: func(ctx context.Context, namespace, name string) error {
+				_, err := core.Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+				return err
+			},
+			delete: func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+				return core.Pods(namespace).Delete(ctx, name, opts)
+			},
+		},
+		{
+			kind: "Namespace",
+			list: func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error) {
+				l, err := core.Namespaces().List(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				return objects(l.Items, func(i int) metav1.Object { return &l.Items[i] }), nil
+			},
+			get: func(ctx context.Context, namespace, name string) error {
+				_, err := core.Namespaces().Get(ctx, name, metav1.GetOptions{})
+				return err
+			},
+			delete: func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+				return core.Namespaces().Delete(ctx, name, opts)
+			},
+		},
+		{
+			kind: "PriorityClass",
+			list: func(ctx context.Context, opts metav1.ListOptions) ([]metav1.Object, error) {
+				l, err := scheduling.PriorityClasses().List(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				return objects(l.Items, func(i int) metav1.Object { return &l.Items[i] }), nil
+			},
+			get: func(ctx context.Context, namespace, name string) error {
+				_, err := scheduling.PriorityClasses().Get(ctx, name, metav1.GetOptions{})
+				return err
+			},
+			delete: func(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+				return scheduling.PriorityClasses().Delete(ctx, name, opts)
+			},
+		},
+	}
+}
+
+// objects returns the items of a list as objects.
+func objects[T any](items []T, at func(i int) metav1.Object) []metav1.Object {
+	objs := make([]metav1.Object, len(items))
+	for i := range items {
+		objs[i] = at(i)
+	}
+	return objs
+}
+
+// ResetSelected deletes the workloads, pods, namespaces and priority classes created by keg that match
+// a label selector and returns them. Objects without the managed-by label of keg are never deleted.
+// Objects controlled by another object, like the pods of a workload, are left to the garbage collector.
+// With dryRun the matching objects are only listed.
+func ResetSelected(ctx context.Context, clientset kubernetes.Interface, logger *logger.Logger, selector string, dryRun bool) ([]Object, error) {
+	if _, err := labels.Parse(selector); err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	if selector == "" {
+		selector = ManagedSelector()
+	} else {
+		selector += "," + ManagedSelector()
+	}
+	deletePolicy := metav1.DeletePropagationForeground
+	deleteOpts := metav1.DeleteOptions{PropagationPolicy: &deletePolicy}
+
+	var matched []Object
+	var errs []error
+	for _, k := range resetKinds(clientset) {
+		items, err := k.list(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return matched, fmt.Errorf("failed to list %ss: %w", strings.ToLower(k.kind), err)
+		}
+		for _, item := range items {
+			if metav1.GetControllerOf(item) != nil {
+				continue
+			}
+			o := Object{Kind: k.kind, Namespace: item.GetNamespace(), Name: item.GetName()}
+			matched = append(matched, o)
+			if dryRun {
+				continue
+			}
+			logger.Debugf("Deleting %s", o)
+			if err := k.delete(ctx, o.Namespace, o.Name, deleteOpts); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete %s: %w", o, err))
+			}
+		}
+	}
+	return matched, errors.Join(errs...)
+}
+
+// WaitDeleted waits until the objects no longer exist.
+func WaitDeleted(ctx context.Context, clientset kubernetes.Interface, objs []Object) error {
+	kinds := make(map[string]resetKind)
+	for _, k := range resetKinds(clientset) {
+		kinds[k.kind] = k
+	}
+	for _, o := range objs {
+		k, ok := kinds[o.Kind]
+		if !ok {
+			return fmt.Errorf("unknown kind %q", o.Kind)
+		}
+		err := wait.PollUntilContextCancel(ctx, waitDeletedInterval, true, func(ctx context.Context) (bool, error) {
+			err := k.get(ctx, o.Namespace, o.Name)
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed waiting for %s to be deleted: %w", o, err)
+		}
+	}
+	return nil
+}
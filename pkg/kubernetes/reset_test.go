@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResetSelected(t *testing.T) {
+	owned := Ownership{SimulationID: "sim-1"}.Labels()
+	other := Ownership{SimulationID: "sim-2"}.Labels()
+	meta := func(namespace, name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}
+	}
+	controller := true
+	workloadPod := &v1.Pod{ObjectMeta: meta("default", "web-abc", owned)}
+	workloadPod.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-abc", Controller: &controller}}
+
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: meta("default", "pod", owned)},
+		&v1.Pod{ObjectMeta: meta("default", "other", other)},
+		&v1.Pod{ObjectMeta: meta("default", "unlabelled", nil)},
+		&v1.Pod{ObjectMeta: meta("default", "unmanaged", map[string]string{SimulationLabel: "sim-1"})},
+		&v1.Namespace{ObjectMeta: meta("", "user", map[string]string{"env": "dev"})},
+		workloadPod,
+		&appsv1.Deployment{ObjectMeta: meta("default", "web", owned)},
+		&v1.Namespace{ObjectMeta: meta("", "keg-sim-1", owned)},
+		&schedulingv1.PriorityClass{ObjectMeta: meta("", "batch", owned), Value: 10},
+	)
+	ctx := context.Background()
+	want := []Object{
+		{Kind: "Deployment", Namespace: "default", Name: "web"},
+		{Kind: "Pod", Namespace: "default", Name: "pod"},
+		{Kind: "Namespace", Name: "keg-sim-1"},
+		{Kind: "PriorityClass", Name: "batch"},
+	}
+
+	objs, err := ResetSelected(ctx, clientset, logger.Default(), SimulationSelector("sim-1"), true)
+	require.NoError(t, err)
+	assert.Equal(t, want, objs, "the pods controlled by a workload are left to the workload")
+	pods, err := clientset.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, pods.Items, 5, "a dry run deletes nothing")
+
+	objs, err = ResetSelected(ctx, clientset, logger.Default(), SimulationSelector("sim-1"), false)
+	require.NoError(t, err)
+	assert.Equal(t, want, objs)
+	require.NoError(t, WaitDeleted(ctx, clientset, objs))
+	pods, err = clientset.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, pods.Items, 4, "the fake clientset has no garbage collector")
+
+	objs, err = ResetSelected(ctx, clientset, logger.Default(), "env=dev", false)
+	require.NoError(t, err)
+	assert.Empty(t, objs, "objects not created by keg are never deleted")
+	_, err = clientset.CoreV1().Namespaces().Get(ctx, "user", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	_, err = ResetSelected(ctx, clientset, logger.Default(), "a=b=c", false)
+	assert.Error(t, err)
+}
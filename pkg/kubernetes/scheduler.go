@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/maczg/kube-event-generator/pkg/logger"
 	"io"
+	v1 "k8s.io/api/core/v1"
 	kubescheduler "k8s.io/kube-scheduler/config/v1"
 	"net/http"
 )
@@ -47,6 +48,9 @@ type SchedulerManager interface {
 	// UpdatePluginWeights updates multiple plugin weights atomically.
 	UpdatePluginWeights(ctx context.Context, weights map[string]int32) error
 
+	// UpdateProfilePluginWeights updates multiple plugin weights of the profile with the given scheduler name.
+	UpdateProfilePluginWeights(ctx context.Context, profile string, weights map[string]int32) error
+
 	// GetConfiguration returns the current scheduler configuration.
 	GetConfiguration(ctx context.Context) (*kubescheduler.KubeSchedulerConfiguration, error)
 
@@ -63,34 +67,52 @@ type HTTPKubeSchedulerManager struct {
 	knownPlugins map[string]bool
 }
 
+// DefaultPlugins is the list of plugins enabled in the default scheduler configuration.
+var DefaultPlugins = []string{
+	SchedulingGates,
+	PrioritySort,
+	NodeUnschedulable,
+	NodeName,
+	TaintToleration,
+	NodeAffinity,
+	NodePorts,
+	NodeResourcesFit,
+	VolumeRestrictions,
+	EBSLimits,
+	GCEPDLimits,
+	NodeVolumeLimits,
+	AzureDiskLimits,
+	VolumeBinding,
+	VolumeZone,
+	PodTopologySpread,
+	InterPodAffinity,
+	DefaultPreemption,
+	NodeResourcesBalancedAllocation,
+	ImageLocality,
+	DefaultBinder,
+}
+
+// IsDefaultPlugin checks if a plugin is one of the default scheduler plugins.
+func IsDefaultPlugin(pluginName string) bool {
+	for _, p := range DefaultPlugins {
+		if p == pluginName {
+			return true
+		}
+	}
+	return false
+}
+
 // NewHTTPKubeSchedulerManager creates a new HTTPKubeSchedulerManager instance.
 func NewHTTPKubeSchedulerManager(baseURL string) *HTTPKubeSchedulerManager {
+	knownPlugins := make(map[string]bool, len(DefaultPlugins))
+	for _, p := range DefaultPlugins {
+		knownPlugins[p] = true
+	}
+
 	return &HTTPKubeSchedulerManager{
-		baseURL:    baseURL,
-		httpClient: &http.Client{},
-		knownPlugins: map[string]bool{
-			SchedulingGates:                 true,
-			PrioritySort:                    true,
-			NodeUnschedulable:               true,
-			NodeName:                        true,
-			TaintToleration:                 true,
-			NodeAffinity:                    true,
-			NodePorts:                       true,
-			NodeResourcesFit:                true,
-			VolumeRestrictions:              true,
-			EBSLimits:                       true,
-			GCEPDLimits:                     true,
-			NodeVolumeLimits:                true,
-			AzureDiskLimits:                 true,
-			VolumeBinding:                   true,
-			VolumeZone:                      true,
-			PodTopologySpread:               true,
-			InterPodAffinity:                true,
-			DefaultPreemption:               true,
-			NodeResourcesBalancedAllocation: true,
-			ImageLocality:                   true,
-			DefaultBinder:                   true,
-		},
+		baseURL:      baseURL,
+		httpClient:   &http.Client{},
+		knownPlugins: knownPlugins,
 	}
 }
 
@@ -130,13 +152,8 @@ func (h *HTTPKubeSchedulerManager) UpdatePluginWeights(ctx context.Context, weig
 	}
 
 	// Validate all plugins and weights first
-	for pluginName, weight := range weights {
-		if err := h.ValidatePluginName(pluginName); err != nil {
-			return fmt.Errorf("invalid plugin name %s: %w", pluginName, err)
-		}
-		if weight < 1 {
-			return fmt.Errorf("weight for plugin %s must be greater than 1", pluginName)
-		}
+	if err := h.validateWeights(weights); err != nil {
+		return err
 	}
 
 	// Update all plugin weights in the configuration
@@ -176,6 +193,63 @@ func (h *HTTPKubeSchedulerManager) UpdatePluginWeights(ctx context.Context, weig
 	return nil
 }
 
+// UpdateProfilePluginWeights updates multiple plugin weights of the profile with the given scheduler name.
+func (h *HTTPKubeSchedulerManager) UpdateProfilePluginWeights(ctx context.Context, profile string, weights map[string]int32) error {
+	config, err := h.GetConfiguration(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get scheduler configuration: %w", err)
+	}
+	if err := h.validateWeights(weights); err != nil {
+		return err
+	}
+
+	for _, p := range config.Profiles {
+		if ProfileSchedulerName(p) != profile {
+			continue
+		}
+		if p.Plugins == nil {
+			return fmt.Errorf("profile %s has no plugins", profile)
+		}
+		for pluginName, weight := range weights {
+			found := false
+			for j, plugin := range p.Plugins.MultiPoint.Enabled {
+				if plugin.Name == pluginName {
+					w := weight
+					p.Plugins.MultiPoint.Enabled[j].Weight = &w
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("plugin %s not found in profile %s", pluginName, profile)
+			}
+		}
+		return h.UpdateConfiguration(ctx, config)
+	}
+	return fmt.Errorf("profile %s not found in scheduler configuration", profile)
+}
+
+// validateWeights checks the plugin names and weights of a weight update.
+func (h *HTTPKubeSchedulerManager) validateWeights(weights map[string]int32) error {
+	for pluginName, weight := range weights {
+		if err := h.ValidatePluginName(pluginName); err != nil {
+			return fmt.Errorf("invalid plugin name %s: %w", pluginName, err)
+		}
+		if weight < 1 {
+			return fmt.Errorf("weight for plugin %s must be greater than 1", pluginName)
+		}
+	}
+	return nil
+}
+
+// ProfileSchedulerName returns the scheduler name of a profile, default-scheduler if unset.
+func ProfileSchedulerName(profile kubescheduler.KubeSchedulerProfile) string {
+	if profile.SchedulerName == nil || *profile.SchedulerName == "" {
+		return v1.DefaultSchedulerName
+	}
+	return *profile.SchedulerName
+}
+
 func (h *HTTPKubeSchedulerManager) GetConfiguration(ctx context.Context) (*kubescheduler.KubeSchedulerConfiguration, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL, http.NoBody)
 	if err != nil {
@@ -247,9 +321,9 @@ func (h *HTTPKubeSchedulerManager) RemoveKnownPlugin(pluginName string) {
 	delete(h.knownPlugins, pluginName)
 }
 
-// ResetToDefaults resets all plugin weights to default values as per Kubernetes default configuration.
-func (h *HTTPKubeSchedulerManager) ResetToDefaults(ctx context.Context) error {
-	weights := map[string]int32{
+// DefaultPluginWeights returns the plugin weights of the Kubernetes default scheduler configuration.
+func DefaultPluginWeights() map[string]int32 {
+	return map[string]int32{
 		// Plugins with specific weights in default config
 		TaintToleration:                 3,
 		NodeAffinity:                    2,
@@ -274,5 +348,9 @@ func (h *HTTPKubeSchedulerManager) ResetToDefaults(ctx context.Context) error {
 		DefaultPreemption:  1,
 		DefaultBinder:      1,
 	}
-	return h.UpdatePluginWeights(ctx, weights)
+}
+
+// ResetToDefaults resets all plugin weights to default values as per Kubernetes default configuration.
+func (h *HTTPKubeSchedulerManager) ResetToDefaults(ctx context.Context) error {
+	return h.UpdatePluginWeights(ctx, DefaultPluginWeights())
 }
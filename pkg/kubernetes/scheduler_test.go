@@ -0,0 +1,76 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubescheduler "k8s.io/kube-scheduler/config/v1"
+)
+
+// newSchedulerServer serves a scheduler configuration with a profile per scheduler name, storing the
+// configurations posted to it.
+func newSchedulerServer(t *testing.T, names ...string) (*httptest.Server, *kubescheduler.KubeSchedulerConfiguration) {
+	config := &kubescheduler.KubeSchedulerConfiguration{}
+	for _, name := range names {
+		name := name
+		weight := int32(1)
+		config.Profiles = append(config.Profiles, kubescheduler.KubeSchedulerProfile{
+			SchedulerName: &name,
+			Plugins: &kubescheduler.Plugins{MultiPoint: kubescheduler.PluginSet{Enabled: []kubescheduler.Plugin{
+				{Name: NodeResourcesFit, Weight: &weight},
+			}}},
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			require.NoError(t, json.NewEncoder(w).Encode(config))
+		case http.MethodPost:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(config))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, config
+}
+
+func TestHTTPKubeSchedulerManager_UpdateProfilePluginWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		weights map[string]int32
+		wantErr string
+	}{
+		{name: "second profile", profile: "binpack", weights: map[string]int32{NodeResourcesFit: 5}},
+		{name: "unknown profile", profile: "spread", weights: map[string]int32{NodeResourcesFit: 5}, wantErr: "profile spread not found"},
+		{name: "plugin not in profile", profile: "binpack", weights: map[string]int32{ImageLocality: 5}, wantErr: "plugin ImageLocality not found"},
+		{name: "invalid weight", profile: "binpack", weights: map[string]int32{NodeResourcesFit: 0}, wantErr: "must be greater"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, config := newSchedulerServer(t, "default-scheduler", "binpack")
+			manager := NewHTTPKubeSchedulerManager(server.URL)
+
+			err := manager.UpdateProfilePluginWeights(context.Background(), tt.profile, tt.weights)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int32(1), *config.Profiles[0].Plugins.MultiPoint.Enabled[0].Weight)
+			assert.Equal(t, int32(5), *config.Profiles[1].Plugins.MultiPoint.Enabled[0].Weight)
+		})
+	}
+}
+
+func TestProfileSchedulerName(t *testing.T) {
+	name := "binpack"
+	assert.Equal(t, "default-scheduler", ProfileSchedulerName(kubescheduler.KubeSchedulerProfile{}))
+	assert.Equal(t, "binpack", ProfileSchedulerName(kubescheduler.KubeSchedulerProfile{SchedulerName: &name}))
+}
@@ -0,0 +1,259 @@
+// Package multicluster runs a scenario against several clusters at once, to compare schedulers or
+// cluster versions on the same workload.
+package multicluster
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ComparisonFile is the name of the comparison of the clusters written in the output directory.
+const ComparisonFile = "comparison.csv"
+
+// cleanupTimeout bounds the cleanup of a simulation once it ends.
+const cleanupTimeout = time.Minute
+
+// Cluster is a cluster the scenario runs against.
+type Cluster struct {
+	// Name identifies the cluster in the results, e.g. its kubeconfig context
+	Name      string
+	Clientset kubernetes.Interface
+	Manager   kube.SchedulerManager
+}
+
+// Result is the outcome of the simulation of a cluster.
+type Result struct {
+	// Cluster is the name of the cluster
+	Cluster string
+	// Seed is the seed of the scenario distributions, the same for every cluster
+	Seed int64
+	// Dir is the results directory of the cluster
+	Dir string
+	// SimulationID is the ID of the simulation of the cluster
+	SimulationID string
+	// EndReason tells which end condition ended the simulation
+	EndReason simulation.EndReason
+	// Summary aggregates the stats of the simulation
+	Summary cache.Summary
+	// Err is set if the simulation failed
+	Err error
+}
+
+// Runner runs a scenario against several clusters in parallel. The simulations share a seed and start
+// their event clocks together, so the same pods arrive at the same time on every cluster, and each one
+// has its own stats.
+type Runner struct {
+	logger      *logger.Logger
+	clusters    []Cluster
+	outputDir   string
+	params      map[string]string
+	seed        *int64
+	termination *simulation.Termination
+	simOpts     []simulation.SimulationOpt
+	cleanup     bool
+
+	// newSimulation creates the simulation of a cluster
+	newSimulation func(cluster Cluster, scenario *simulation.Scenario, opts ...simulation.SimulationOpt) simulation.Simulation
+}
+
+// RunnerOpt configures a Runner.
+type RunnerOpt func(*Runner)
+
+// WithOutputDir sets the directory where the cluster subdirectories and the comparison are written.
+func WithOutputDir(dir string) RunnerOpt {
+	return func(r *Runner) {
+		r.outputDir = dir
+	}
+}
+
+// WithParams sets the parameter overrides of the scenario.
+func WithParams(params map[string]string) RunnerOpt {
+	return func(r *Runner) {
+		r.params = params
+	}
+}
+
+// WithSeed sets the seed of the scenario distributions. It defaults to the scenario seed, or a random one.
+func WithSeed(seed int64) RunnerOpt {
+	return func(r *Runner) {
+		r.seed = &seed
+	}
+}
+
+// WithTermination sets end conditions overriding the ones of the scenario.
+func WithTermination(t *simulation.Termination) RunnerOpt {
+	return func(r *Runner) {
+		r.termination = t
+	}
+}
+
+// WithSimulationOpts sets options applied to the simulation of every cluster.
+func WithSimulationOpts(opts ...simulation.SimulationOpt) RunnerOpt {
+	return func(r *Runner) {
+		r.simOpts = append(r.simOpts, opts...)
+	}
+}
+
+// WithCleanup deletes the objects created by each simulation once it ends.
+func WithCleanup(cleanup bool) RunnerOpt {
+	return func(r *Runner) {
+		r.cleanup = cleanup
+	}
+}
+
+// NewRunner creates a new multi-cluster runner.
+func NewRunner(log *logger.Logger, clusters []Cluster, opts ...RunnerOpt) *Runner {
+	r := &Runner{
+		logger:    log,
+		clusters:  clusters,
+		outputDir: "results/multicluster",
+		params:    map[string]string{},
+	}
+	r.newSimulation = func(cluster Cluster, scenario *simulation.Scenario, opts ...simulation.SimulationOpt) simulation.Simulation {
+		return simulation.NewSimulation(scenario, cluster.Clientset, cluster.Manager, r.logger, opts...)
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run runs the scenario against every cluster and writes the results of each one and their comparison. A
+// failed simulation is recorded in the comparison and does not stop the others. Once the context is
+// cancelled, the simulations end, their scheduler configuration is restored, the results so far are
+// written and the context error is returned.
+func (r *Runner) Run(ctx context.Context, scenarioFile string) ([]Result, error) {
+	// every cluster gets its own copy of the scenario, with the same seed so they draw the same arrivals
+	// and durations
+	scenarios := make([]*simulation.Scenario, len(r.clusters))
+	for i := range r.clusters {
+		scenario, err := simulation.LoadFromYaml(scenarioFile, simulation.WithParams(r.params))
+		if err != nil {
+			return nil, err
+		}
+		if r.seed == nil {
+			seed := scenario.EffectiveSeed()
+			r.seed = &seed
+			r.logger.Infof("using seed %d", seed)
+		}
+		seed := *r.seed
+		scenario.Seed = &seed
+		if r.termination != nil {
+			scenario.Termination = scenario.Termination.Merge(r.termination)
+		}
+		scenarios[i] = scenario
+	}
+
+	barrier := simulation.NewStartBarrier(len(r.clusters))
+	results := make([]Result, len(r.clusters))
+	var wg sync.WaitGroup
+	for i, cluster := range r.clusters {
+		wg.Add(1)
+		go func(i int, cluster Cluster) {
+			defer wg.Done()
+			results[i] = r.runOne(ctx, scenarioFile, scenarios[i], cluster, barrier)
+			if results[i].Err != nil {
+				r.logger.Errorf("cluster %s failed: %v", cluster.Name, results[i].Err)
+			} else {
+				r.logger.Infof("cluster %s ended: %s", cluster.Name, results[i].EndReason)
+			}
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	if err := WriteComparison(filepath.Join(r.outputDir, ComparisonFile), results); err != nil {
+		return results, err
+	}
+	return results, ctx.Err()
+}
+
+func (r *Runner) runOne(ctx context.Context, scenarioFile string, scenario *simulation.Scenario, cluster Cluster, barrier *simulation.StartBarrier) Result {
+	result := Result{Cluster: cluster.Name, Seed: *scenario.Seed, Dir: filepath.Join(r.outputDir, kube.LabelValue(cluster.Name))}
+
+	opts := append([]simulation.SimulationOpt{simulation.WithStartBarrier(barrier)}, r.simOpts...)
+	sim := r.newSimulation(cluster, scenario, opts...)
+	result.SimulationID = sim.GetID()
+	r.logger.Infof("cluster %s: simulation %s", cluster.Name, sim.GetID())
+	err := sim.Start(ctx)
+	result.EndReason = sim.EndReason()
+
+	// the cleanup runs even if the simulation failed or the context is done
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	if r.cleanup {
+		if err := sim.DeleteCreated(cleanupCtx); err != nil {
+			r.logger.Errorf("cluster %s: cleanup failed: %v", cluster.Name, err)
+		}
+	}
+	if ctx.Err() != nil {
+		if err := sim.RestoreScheduler(cleanupCtx); err != nil {
+			r.logger.Errorf("cluster %s: failed to restore the scheduler: %v", cluster.Name, err)
+		}
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	stats := sim.GetStats()
+	if stats == nil {
+		result.Err = fmt.Errorf("simulation %s has no stats", sim.GetID())
+		return result
+	}
+	result.Summary = stats.Summarize()
+
+	if err := stats.ExportCSV(result.Dir); err != nil {
+		result.Err = err
+		return result
+	}
+	if err := simulation.WriteResultsMetadata(result.Dir, simulation.NewResultsMetadata(sim, scenario, scenarioFile)); err != nil {
+		result.Err = err
+	}
+	return result
+}
+
+// WriteComparison writes one row per cluster with its summary and its error, if any.
+func WriteComparison(filename string, results []Result) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := append([]string{"cluster", "seed"}, cache.SummaryHeader()...)
+	header = append(header, "end_reason", "simulation_id", "error")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		row := append([]string{result.Cluster, strconv.FormatInt(result.Seed, 10)}, result.Summary.Row()...)
+		errMsg := ""
+		if result.Err != nil {
+			errMsg = result.Err.Error()
+		}
+		row = append(row, string(result.EndReason), result.SimulationID, errMsg)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,160 @@
+package multicluster
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var multiScenarioYaml = `
+params:
+  evict:
+    type: duration
+    default: 300ms
+metadata:
+  name: ab
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "2", memory: 2Gi, pods: "10" }
+    - metadata:
+        name: node-2
+      status:
+        allocatable: { cpu: "2", memory: 2Gi, pods: "10" }
+events:
+  pods:
+    - name: web
+      replicas: 2
+      spacing: 100ms
+      evictTime: ${evict}
+      podSpec:
+        spec:
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests: { cpu: 500m, memory: 256Mi }
+`
+
+// newClusters starts an offline cluster per strategy.
+func newClusters(t *testing.T, ctx context.Context, scenarioFile string, strategies ...offline.Strategy) []Cluster {
+	scenario, err := simulation.LoadFromYaml(scenarioFile)
+	require.NoError(t, err)
+
+	clusters := make([]Cluster, 0, len(strategies))
+	for _, strategy := range strategies {
+		cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes, offline.WithStrategy(strategy))
+		require.NoError(t, err)
+		cluster.Start(ctx)
+		clusters = append(clusters, Cluster{Name: string(strategy), Clientset: cluster.Clientset(), Manager: cluster.Scheduler()})
+	}
+	return clusters
+}
+
+func writeScenario(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	scenarioFile := filepath.Join(dir, "scenario.yaml")
+	require.NoError(t, os.WriteFile(scenarioFile, []byte(multiScenarioYaml), 0o644))
+	return dir, scenarioFile
+}
+
+func readComparison(t *testing.T, dir string) [][]string {
+	f, err := os.Open(filepath.Join(dir, ComparisonFile))
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	return rows
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir, scenarioFile := writeScenario(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clusters := newClusters(t, ctx, scenarioFile, offline.StrategyLeastAllocated, offline.StrategyMostAllocated)
+
+	out := filepath.Join(dir, "out")
+	runner := NewRunner(logger.Default(), clusters, WithOutputDir(out), WithSeed(42))
+	results, err := runner.Run(ctx, scenarioFile)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	for i, result := range results {
+		require.NoError(t, result.Err)
+		assert.Equal(t, clusters[i].Name, result.Cluster)
+		assert.Equal(t, int64(42), result.Seed)
+		assert.Equal(t, simulation.EndAllEvicted, result.EndReason)
+		assert.Equal(t, 2, result.Summary.ScheduledPods, "each cluster has its own stats")
+		assert.FileExists(t, filepath.Join(out, clusters[i].Name, "event_history.csv"))
+		assert.FileExists(t, filepath.Join(out, clusters[i].Name, simulation.ResultsMetadataFile))
+	}
+
+	rows := readComparison(t, out)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"cluster", "seed", "scheduled_pods"}, rows[0][:3])
+	assert.Equal(t, "error", rows[0][len(rows[0])-1])
+	assert.Equal(t, []string{"least-allocated", "42", "2"}, rows[1][:3])
+	assert.Equal(t, []string{"most-allocated", "42", "2"}, rows[2][:3])
+}
+
+func TestRunner_Interrupted(t *testing.T) {
+	dir, scenarioFile := writeScenario(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	clusters := newClusters(t, ctx, scenarioFile, offline.StrategyLeastAllocated, offline.StrategyMostAllocated)
+
+	runCtx, interrupt := context.WithCancel(ctx)
+	out := filepath.Join(dir, "out")
+	runner := NewRunner(logger.Default(), clusters, WithOutputDir(out), WithParams(map[string]string{"evict": "1h"}), WithCleanup(true))
+	go func() {
+		// interrupts once both clusters run the pods
+		for _, cluster := range clusters {
+			assert.Eventually(t, func() bool {
+				pods, err := cluster.Clientset.CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+				return err == nil && len(pods.Items) == 2
+			}, 5*time.Second, 20*time.Millisecond)
+		}
+		interrupt()
+	}()
+
+	results, err := runner.Run(runCtx, scenarioFile)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.Equal(t, simulation.EndContextDone, result.EndReason)
+		assert.Equal(t, 2, result.Summary.ScheduledPods)
+	}
+	for _, cluster := range clusters {
+		pods, err := cluster.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, pods.Items, "the pods created are deleted with cleanup")
+	}
+	assert.Len(t, readComparison(t, out), 3, "the comparison is written")
+}
+
+func TestWriteComparison(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nested", ComparisonFile)
+	require.NoError(t, WriteComparison(file, []Result{
+		{Cluster: "upstream", Seed: 7, SimulationID: "sim-1", EndReason: simulation.EndAllEvicted},
+		{Cluster: "fork", Seed: 7, Err: errors.New("boom")},
+	}))
+
+	rows := readComparison(t, filepath.Dir(file))
+	require.Len(t, rows, 3)
+	last := len(rows[0]) - 1
+	assert.Equal(t, []string{string(simulation.EndAllEvicted), "sim-1", ""}, rows[1][last-2:])
+	assert.Equal(t, "boom", rows[2][last])
+}
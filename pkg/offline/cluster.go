@@ -0,0 +1,179 @@
+// Package offline provides an in-process fake cluster with a built-in scheduler, to run simulations
+// without a Kubernetes API server, KWOK or the kube-scheduler-simulator.
+package offline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// priorityClassesResource is the resource of the priority classes in the object tracker.
+var priorityClassesResource = schedulingv1.SchemeGroupVersion.WithResource("priorityclasses")
+
+// Cluster is a fake cluster backed by client-go's fake clientset. Pods are created pending and bound
+// by the built-in scheduler, which also plays the kubelet: bound pods are running immediately. Running
+// pods with a lifecycle annotation then succeed, fail or crash-loop. The priority of the pods is resolved
+// from their priority class on creation. There are no controllers, so workloads do not create pods.
+type Cluster struct {
+	clientset *fake.Clientset
+	scheduler *Scheduler
+	kubelet   *Kubelet
+}
+
+// NewCluster creates a fake cluster with the given nodes.
+func NewCluster(log *logger.Logger, nodes []*v1.Node, opts ...SchedulerOpt) (*Cluster, error) {
+	clientset := fake.NewClientset()
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*v1.Pod)
+		initPod(pod)
+		if err := admitPriority(clientset.Tracker(), pod); err != nil {
+			return true, nil, err
+		}
+		// not handled, the object tracker stores the initialized pod
+		return false, nil, nil
+	})
+
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		if _, err := clientset.CoreV1().Nodes().Create(context.Background(), readyNode(node), metav1.CreateOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to create node %s: %w", node.Name, err)
+		}
+	}
+
+	return &Cluster{
+		clientset: clientset,
+		scheduler: NewScheduler(clientset, log, opts...),
+		kubelet:   NewKubelet(clientset, log),
+	}, nil
+}
+
+// Clientset returns the clientset of the cluster.
+func (c *Cluster) Clientset() kubernetes.Interface {
+	return c.clientset
+}
+
+// Scheduler returns the built-in scheduler of the cluster.
+func (c *Cluster) Scheduler() *Scheduler {
+	return c.scheduler
+}
+
+// Start runs the scheduler and the kubelet in the background until the context is done.
+func (c *Cluster) Start(ctx context.Context) {
+	go func() {
+		if err := c.scheduler.Run(ctx); err != nil {
+			c.scheduler.logger.Errorf("offline scheduler stopped: %v", err)
+		}
+	}()
+	go func() {
+		if err := c.kubelet.Run(ctx); err != nil {
+			c.kubelet.logger.Errorf("offline kubelet stopped: %v", err)
+		}
+	}()
+}
+
+// Reset deletes all the pods of the cluster and resets the scheduler plugin weights.
+func (c *Cluster) Reset(ctx context.Context) error {
+	pods, err := c.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if err := c.clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+	}
+	return c.scheduler.ResetToDefaults(ctx)
+}
+
+// initPod sets the fields the API server sets on creation.
+func initPod(pod *v1.Pod) {
+	if pod.Namespace == "" {
+		pod.Namespace = metav1.NamespaceDefault
+	}
+	if pod.UID == "" {
+		pod.UID = types.UID(uuid.NewString())
+	}
+	if pod.CreationTimestamp.IsZero() {
+		pod.CreationTimestamp = metav1.NewTime(time.Now())
+	}
+	if pod.Status.Phase == "" {
+		pod.Status.Phase = v1.PodPending
+	}
+}
+
+// admitPriority resolves the priority of a pod from its priority class, or from the global default class
+// if it has none, as the Priority admission plugin of the API server does. Unlike the plugin, it accepts
+// unknown classes if the pod sets its priority. The tracker is used as the clientset is locked while its
+// reactors run.
+func admitPriority(tracker k8stesting.ObjectTracker, pod *v1.Pod) error {
+	var class *schedulingv1.PriorityClass
+	if pod.Spec.PriorityClassName != "" {
+		obj, err := tracker.Get(priorityClassesResource, "", pod.Spec.PriorityClassName)
+		switch {
+		case err == nil:
+			class = obj.(*schedulingv1.PriorityClass)
+		case pod.Spec.Priority != nil:
+			// the fake cluster lacks the classes of a real one, pods setting their priority are trusted
+			return nil
+		default:
+			return apierrors.NewForbidden(v1.Resource("pods"), pod.Name, fmt.Errorf("no PriorityClass with name %s was found", pod.Spec.PriorityClassName))
+		}
+	} else {
+		obj, err := tracker.List(priorityClassesResource, schedulingv1.SchemeGroupVersion.WithKind("PriorityClass"), "")
+		if err != nil {
+			return err
+		}
+		list := obj.(*schedulingv1.PriorityClassList)
+		for i := range list.Items {
+			if list.Items[i].GlobalDefault {
+				class = &list.Items[i]
+				break
+			}
+		}
+	}
+	if class == nil {
+		return nil
+	}
+
+	if pod.Spec.Priority != nil && *pod.Spec.Priority != class.Value {
+		return apierrors.NewForbidden(v1.Resource("pods"), pod.Name, fmt.Errorf("the integer value of priority (%d) must not be provided in pod spec; priority admission controller computed %d from the given PriorityClass name", *pod.Spec.Priority, class.Value))
+	}
+	value := class.Value
+	pod.Spec.PriorityClassName = class.Name
+	pod.Spec.Priority = &value
+	if pod.Spec.PreemptionPolicy == nil && class.PreemptionPolicy != nil {
+		policy := *class.PreemptionPolicy
+		pod.Spec.PreemptionPolicy = &policy
+	}
+	return nil
+}
+
+// readyNode returns a copy of the node with a Ready condition, and its capacity as allocatable if unset.
+func readyNode(node *v1.Node) *v1.Node {
+	n := node.DeepCopy()
+	if len(n.Status.Allocatable) == 0 {
+		n.Status.Allocatable = n.Status.Capacity
+	}
+	for _, c := range n.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return n
+		}
+	}
+	n.Status.Conditions = append(n.Status.Conditions, v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue})
+	return n
+}
@@ -0,0 +1,196 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Kubelet ends the running pods with a lifecycle annotation, as the KWOK stages of docker/kwok.yaml do:
+// after kube-event-generator/outcome-after, the pod succeeds, fails, or its containers crash. Crashed
+// containers restart after the same time.
+type Kubelet struct {
+	clientset kubernetes.Interface
+	logger    *logger.Logger
+
+	mu     sync.Mutex
+	timers map[types.UID]*time.Timer
+}
+
+// NewKubelet creates a kubelet acting on the pods of clientset.
+func NewKubelet(clientset kubernetes.Interface, log *logger.Logger) *Kubelet {
+	return &Kubelet{
+		clientset: clientset,
+		logger:    log,
+		timers:    make(map[types.UID]*time.Timer),
+	}
+}
+
+// Run watches the pods and advances their lifecycle until the context is done.
+func (k *Kubelet) Run(ctx context.Context) error {
+	pods, err := k.clientset.CoreV1().Pods("").Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %w", err)
+	}
+	defer pods.Stop()
+	defer k.stopTimers()
+
+	// the watch only sends the changes made after it started
+	existing, err := k.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	for i := range existing.Items {
+		k.watch(ctx, &existing.Items[i])
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-pods.ResultChan():
+			if !ok {
+				return fmt.Errorf("pod watch closed")
+			}
+			pod, ok := event.Object.(*v1.Pod)
+			if !ok {
+				continue
+			}
+			if event.Type == watch.Deleted {
+				k.cancel(pod.UID)
+				continue
+			}
+			k.watch(ctx, pod)
+		}
+	}
+}
+
+// watch schedules the next lifecycle step of a running pod with a lifecycle, unless one is scheduled.
+func (k *Kubelet) watch(ctx context.Context, pod *v1.Pod) {
+	if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+		return
+	}
+	lifecycle, ok, err := kube.LifecycleOf(pod)
+	if err != nil {
+		k.logger.Warnf("offline kubelet: pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, scheduled := k.timers[pod.UID]; scheduled {
+		return
+	}
+	namespace, name, uid := pod.Namespace, pod.Name, pod.UID
+	k.timers[pod.UID] = time.AfterFunc(lifecycle.After, func() {
+		k.cancel(uid)
+		if err := k.advance(ctx, namespace, name, lifecycle); err != nil && ctx.Err() == nil && !apierrors.IsNotFound(err) {
+			k.logger.Errorf("offline kubelet: pod %s/%s: %v", namespace, name, err)
+		}
+	})
+}
+
+// advance applies the outcome of a running pod, or restarts its crashed containers.
+func (k *Kubelet) advance(ctx context.Context, namespace, name string, lifecycle kube.PodLifecycle) error {
+	pod, err := k.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	now := metav1.NewTime(time.Now())
+	next := pod.DeepCopy()
+	switch {
+	case crashed(pod):
+		setContainerStates(next, v1.ContainerState{Running: &v1.ContainerStateRunning{StartedAt: now}}, true, false)
+		setReady(next, v1.ConditionTrue, now)
+	case lifecycle.Outcome == kube.OutcomeSucceed:
+		next.Status.Phase = v1.PodSucceeded
+		setContainerStates(next, terminated(0, "Completed", now), false, false)
+		setReady(next, v1.ConditionFalse, now)
+	case lifecycle.Outcome == kube.OutcomeFail:
+		next.Status.Phase = v1.PodFailed
+		setContainerStates(next, terminated(lifecycle.ExitCode, "Error", now), false, false)
+		setReady(next, v1.ConditionFalse, now)
+	case lifecycle.Outcome == kube.OutcomeCrashLoop:
+		setContainerStates(next, v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: kube.CrashLoopBackOffReason}}, false, true)
+		for i := range next.Status.ContainerStatuses {
+			next.Status.ContainerStatuses[i].LastTerminationState = terminated(lifecycle.ExitCode, "Error", now)
+		}
+		setReady(next, v1.ConditionFalse, now)
+	}
+	_, err = k.clientset.CoreV1().Pods(namespace).Update(ctx, next, metav1.UpdateOptions{})
+	return err
+}
+
+// crashed reports whether the containers of the pod are waiting to restart after a crash.
+func crashed(pod *v1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason == kube.CrashLoopBackOffReason {
+			return true
+		}
+	}
+	return false
+}
+
+func terminated(exitCode int32, reason string, at metav1.Time) v1.ContainerState {
+	return v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: exitCode, Reason: reason, FinishedAt: at}}
+}
+
+// setContainerStates sets the state of all the containers of the pod, and counts a restart if restart is set.
+func setContainerStates(pod *v1.Pod, state v1.ContainerState, ready, restart bool) {
+	statuses := make(map[string]v1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+	pod.Status.ContainerStatuses = make([]v1.ContainerStatus, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		status := statuses[c.Name]
+		status.Name, status.Image = c.Name, c.Image
+		status.State = state
+		status.Ready = ready
+		if restart {
+			status.RestartCount++
+		}
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, status)
+	}
+}
+
+// setReady sets the Ready condition of the pod.
+func setReady(pod *v1.Pod, status v1.ConditionStatus, at metav1.Time) {
+	setCondition(pod, v1.PodCondition{Type: v1.PodReady, Status: status, LastTransitionTime: at})
+}
+
+// cancel stops the scheduled lifecycle step of a pod, if any.
+func (k *Kubelet) cancel(uid types.UID) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if timer, ok := k.timers[uid]; ok {
+		timer.Stop()
+		delete(k.timers, uid)
+	}
+}
+
+func (k *Kubelet) stopTimers() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for uid, timer := range k.timers {
+		timer.Stop()
+		delete(k.timers, uid)
+	}
+}
@@ -0,0 +1,82 @@
+package offline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func withOutcome(outcome string) kube.PodOpt {
+	return func(pod *v1.Pod) {
+		pod.Annotations = map[string]string{
+			kube.OutcomeAnnotation:      outcome,
+			kube.OutcomeAfterAnnotation: "100ms",
+			kube.ExitCodeAnnotation:     "3",
+		}
+	}
+}
+
+func TestKubelet_Outcome(t *testing.T) {
+	tests := []struct {
+		outcome string
+		check   func(t *testing.T, pod *v1.Pod) bool
+	}{
+		{kube.OutcomeSucceed, func(t *testing.T, pod *v1.Pod) bool {
+			return pod.Status.Phase == v1.PodSucceeded &&
+				assert.Equal(t, "Completed", pod.Status.ContainerStatuses[0].State.Terminated.Reason) &&
+				assert.Zero(t, pod.Status.ContainerStatuses[0].State.Terminated.ExitCode)
+		}},
+		{kube.OutcomeFail, func(t *testing.T, pod *v1.Pod) bool {
+			return pod.Status.Phase == v1.PodFailed &&
+				assert.Equal(t, int32(3), pod.Status.ContainerStatuses[0].State.Terminated.ExitCode)
+		}},
+		{kube.OutcomeCrashLoop, func(t *testing.T, pod *v1.Pod) bool {
+			// the containers crash and restart, the pod keeps running
+			return len(pod.Status.ContainerStatuses) > 0 && pod.Status.ContainerStatuses[0].RestartCount >= 2 &&
+				assert.Equal(t, v1.PodRunning, pod.Status.Phase) &&
+				assert.Equal(t, int32(3), pod.Status.ContainerStatuses[0].LastTerminationState.Terminated.ExitCode)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.outcome, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")})
+			require.NoError(t, err)
+			cluster.Start(ctx)
+
+			_, err = cluster.Clientset().CoreV1().Pods("default").Create(ctx, newPod("pod", "1", "1Gi", withOutcome(tt.outcome)), metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.Eventually(t, func() bool {
+				pod, err := cluster.Clientset().CoreV1().Pods("default").Get(ctx, "pod", metav1.GetOptions{})
+				return err == nil && tt.check(t, pod)
+			}, 3*time.Second, 20*time.Millisecond)
+		})
+	}
+}
+
+func TestKubelet_CompletionFreesNode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")})
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	clientset := cluster.Clientset()
+	_, err = clientset.CoreV1().Pods("default").Create(ctx, newPod("batch", "1500m", "1Gi", withOutcome(kube.OutcomeSucceed)), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = clientset.CoreV1().Pods("default").Create(ctx, newPod("next", "1500m", "1Gi"), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		pod, err := clientset.CoreV1().Pods("default").Get(ctx, "next", metav1.GetOptions{})
+		return err == nil && pod.Spec.NodeName == "node-a"
+	}, 3*time.Second, 20*time.Millisecond, "the pod is scheduled once the batch pod succeeds")
+}
@@ -0,0 +1,249 @@
+package offline
+
+import (
+	"fmt"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// defaultMaxPods is the pods allocatable of a node declaring none, the kubelet default.
+const defaultMaxPods = 110
+
+// nodeInfo is a node, the pods bound to it and the resources they request.
+type nodeInfo struct {
+	node        *v1.Node
+	allocatable resources
+	requested   resources
+	pods        []*v1.Pod
+}
+
+// resources is an amount of CPU (millicores), memory (bytes) and pods.
+type resources struct {
+	cpu    int64
+	memory int64
+	pods   int64
+}
+
+func (r resources) add(o resources) resources {
+	return resources{cpu: r.cpu + o.cpu, memory: r.memory + o.memory, pods: r.pods + o.pods}
+}
+
+func (r resources) sub(o resources) resources {
+	return resources{cpu: r.cpu - o.cpu, memory: r.memory - o.memory, pods: r.pods - o.pods}
+}
+
+// addPod binds a pod to the node.
+func (n *nodeInfo) addPod(pod *v1.Pod) {
+	n.pods = append(n.pods, pod)
+	n.requested = n.requested.add(podRequests(pod))
+}
+
+func newNodeInfo(node *v1.Node) *nodeInfo {
+	list := node.Status.Allocatable
+	if len(list) == 0 {
+		list = node.Status.Capacity
+	}
+	pods := int64(defaultMaxPods)
+	if q, ok := list[v1.ResourcePods]; ok {
+		pods = q.Value()
+	}
+	return &nodeInfo{
+		node: node,
+		allocatable: resources{
+			cpu:    list.Cpu().MilliValue(),
+			memory: list.Memory().Value(),
+			pods:   pods,
+		},
+	}
+}
+
+// podRequests returns the effective requests of a pod: the sum of its containers, or the largest init
+// container if it is larger.
+func podRequests(pod *v1.Pod) resources {
+	r := resources{pods: 1}
+	for _, c := range pod.Spec.Containers {
+		r.cpu += c.Resources.Requests.Cpu().MilliValue()
+		r.memory += c.Resources.Requests.Memory().Value()
+	}
+	for _, c := range pod.Spec.InitContainers {
+		r.cpu = max(r.cpu, c.Resources.Requests.Cpu().MilliValue())
+		r.memory = max(r.memory, c.Resources.Requests.Memory().Value())
+	}
+	return r
+}
+
+// filter returns nil if the pod can run on the node, or the reason it cannot.
+func filter(pod *v1.Pod, n *nodeInfo) error {
+	if n.node.Spec.Unschedulable {
+		return fmt.Errorf("node is unschedulable")
+	}
+
+	for i := range n.node.Spec.Taints {
+		taint := &n.node.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !tolerates(pod.Spec.Tolerations, taint) {
+			return fmt.Errorf("untolerated taint %s", taint.ToString())
+		}
+	}
+
+	if !matchesNodeSelector(pod, n.node) {
+		return fmt.Errorf("node selector or affinity does not match")
+	}
+
+	requests := podRequests(pod)
+	free := resources{
+		cpu:    n.allocatable.cpu - n.requested.cpu,
+		memory: n.allocatable.memory - n.requested.memory,
+		pods:   n.allocatable.pods - n.requested.pods,
+	}
+	switch {
+	case requests.cpu > free.cpu:
+		return fmt.Errorf("insufficient cpu")
+	case requests.memory > free.memory:
+		return fmt.Errorf("insufficient memory")
+	case requests.pods > free.pods:
+		return fmt.Errorf("too many pods")
+	}
+	return nil
+}
+
+func tolerates(tolerations []v1.Toleration, taint *v1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeSelector checks the node selector and the required node affinity of the pod.
+func matchesNodeSelector(pod *v1.Pod, node *v1.Node) bool {
+	if len(pod.Spec.NodeSelector) > 0 && !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false
+	}
+
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	// terms are ORed
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if matchesTerm(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTerm checks the label expressions of a node selector term. Field expressions are not supported.
+func matchesTerm(term v1.NodeSelectorTerm, node *v1.Node) bool {
+	if len(term.MatchExpressions) == 0 {
+		return false
+	}
+	selector := labels.NewSelector()
+	for _, expr := range term.MatchExpressions {
+		op, ok := map[v1.NodeSelectorOperator]selection.Operator{
+			v1.NodeSelectorOpIn:           selection.In,
+			v1.NodeSelectorOpNotIn:        selection.NotIn,
+			v1.NodeSelectorOpExists:       selection.Exists,
+			v1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+			v1.NodeSelectorOpGt:           selection.GreaterThan,
+			v1.NodeSelectorOpLt:           selection.LessThan,
+		}[expr.Operator]
+		if !ok {
+			return false
+		}
+		requirement, err := labels.NewRequirement(expr.Key, op, expr.Values)
+		if err != nil {
+			return false
+		}
+		selector = selector.Add(*requirement)
+	}
+	return selector.Matches(labels.Set(node.Labels))
+}
+
+// scorePlugin is a scoring plugin of the built-in scheduler, weighted by the scheduler plugin weights.
+type scorePlugin struct {
+	name  string
+	score func(pod *v1.Pod, n *nodeInfo, strategy Strategy) float64
+	// normalized plugins return a raw score, scaled across the feasible nodes
+	normalized bool
+}
+
+// scorePlugins are the scoring plugins of the built-in scheduler. Scores are between 0 and 100.
+var scorePlugins = []scorePlugin{
+	{name: kube.NodeResourcesFit, score: scoreResourcesFit},
+	{name: kube.NodeResourcesBalancedAllocation, score: scoreBalancedAllocation},
+	{name: kube.TaintToleration, score: scoreTaintToleration, normalized: true},
+	{name: kube.NodeAffinity, score: scoreNodeAffinity, normalized: true},
+}
+
+func isScorePlugin(name string) bool {
+	for _, p := range scorePlugins {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fractions returns the CPU and memory fractions of the node requested once the pod is bound.
+func fractions(pod *v1.Pod, n *nodeInfo) (float64, float64) {
+	requested := n.requested.add(podRequests(pod))
+	fraction := func(used, allocatable int64) float64 {
+		if allocatable <= 0 {
+			return 1
+		}
+		return min(float64(used)/float64(allocatable), 1)
+	}
+	return fraction(requested.cpu, n.allocatable.cpu), fraction(requested.memory, n.allocatable.memory)
+}
+
+func scoreResourcesFit(pod *v1.Pod, n *nodeInfo, strategy Strategy) float64 {
+	cpu, memory := fractions(pod, n)
+	if strategy == StrategyMostAllocated {
+		return 100 * (cpu + memory) / 2
+	}
+	return 100 * ((1 - cpu) + (1 - memory)) / 2
+}
+
+func scoreBalancedAllocation(pod *v1.Pod, n *nodeInfo, _ Strategy) float64 {
+	cpu, memory := fractions(pod, n)
+	diff := cpu - memory
+	if diff < 0 {
+		diff = -diff
+	}
+	return 100 * (1 - diff)
+}
+
+// scoreTaintToleration counts the PreferNoSchedule taints of the node not tolerated by the pod.
+func scoreTaintToleration(pod *v1.Pod, n *nodeInfo, _ Strategy) float64 {
+	count := 0
+	for i := range n.node.Spec.Taints {
+		taint := &n.node.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule && !tolerates(pod.Spec.Tolerations, taint) {
+			count++
+		}
+	}
+	return float64(count)
+}
+
+// scoreNodeAffinity sums the weights of the preferred node affinity terms matched by the node.
+func scoreNodeAffinity(pod *v1.Pod, n *nodeInfo, _ Strategy) float64 {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil {
+		return 0
+	}
+	var sum float64
+	for _, term := range affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if matchesTerm(term.Preference, n.node) {
+			sum += float64(term.Weight)
+		}
+	}
+	return sum
+}
@@ -0,0 +1,140 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// preempt makes room for a pod that fits no node by deleting pods of lower priority, as the
+// DefaultPreemption plugin does. The victims are chosen on the node where the highest priority among
+// them is the lowest, then where they are the fewest. They get a DisruptionTarget condition before they
+// are deleted, and preempt returns the node the pod is nominated to: it is bound once the victims are
+// gone. The node is empty if no preemption helps.
+func (s *Scheduler) preempt(ctx context.Context, pod *v1.Pod, nodes []*nodeInfo) (string, error) {
+	if pod.Spec.PreemptionPolicy != nil && *pod.Spec.PreemptionPolicy == v1.PreemptNever {
+		return "", nil
+	}
+
+	var best *nodeInfo
+	var bestVictims []*v1.Pod
+	for _, n := range nodes {
+		victims, ok := selectVictims(pod, n)
+		if !ok {
+			continue
+		}
+		if best == nil || fewerVictims(victims, bestVictims) {
+			best, bestVictims = n, victims
+		}
+	}
+	if best == nil {
+		return "", nil
+	}
+
+	for _, victim := range bestVictims {
+		if err := s.evict(ctx, victim); err != nil {
+			return "", fmt.Errorf("failed to preempt pod %s/%s: %w", victim.Namespace, victim.Name, err)
+		}
+		s.logger.Debugf("offline scheduler: pod %s/%s preempted by %s/%s on %s", victim.Namespace, victim.Name, pod.Namespace, pod.Name, best.node.Name)
+	}
+
+	// the room made on the node is held for the nominated pod until it is bound
+	remaining := make([]*v1.Pod, 0, len(best.pods))
+	for _, p := range best.pods {
+		if !contains(bestVictims, p) {
+			remaining = append(remaining, p)
+		}
+	}
+	best.pods = remaining
+	for _, victim := range bestVictims {
+		best.requested = best.requested.sub(podRequests(victim))
+	}
+	best.addPod(pod)
+	return best.node.Name, nil
+}
+
+// selectVictims returns the fewest pods of lower priority to delete from the node for the pod to fit,
+// trying to spare them from the highest priority. ok is false if deleting them all is not enough.
+func selectVictims(pod *v1.Pod, n *nodeInfo) (victims []*v1.Pod, ok bool) {
+	candidates := make([]*v1.Pod, 0)
+	for _, p := range n.pods {
+		if priority(p) < priority(pod) {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	trial := &nodeInfo{node: n.node, allocatable: n.allocatable, requested: n.requested}
+	for _, c := range candidates {
+		trial.requested = trial.requested.sub(podRequests(c))
+	}
+	if filter(pod, trial) != nil {
+		return nil, false
+	}
+
+	// spare the pods of highest priority first, the most recent ones first among equals
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pi, pj := priority(candidates[i]), priority(candidates[j])
+		if pi != pj {
+			return pi > pj
+		}
+		return candidates[j].CreationTimestamp.Before(&candidates[i].CreationTimestamp)
+	})
+	for _, c := range candidates {
+		trial.requested = trial.requested.add(podRequests(c))
+		if filter(pod, trial) != nil {
+			trial.requested = trial.requested.sub(podRequests(c))
+			victims = append(victims, c)
+		}
+	}
+	return victims, len(victims) > 0
+}
+
+// fewerVictims reports whether the victims a are preferred to b: their highest priority is lower, or
+// they are fewer.
+func fewerVictims(a, b []*v1.Pod) bool {
+	if ha, hb := highestPriority(a), highestPriority(b); ha != hb {
+		return ha < hb
+	}
+	return len(a) < len(b)
+}
+
+func highestPriority(pods []*v1.Pod) int32 {
+	highest := priority(pods[0])
+	for _, p := range pods[1:] {
+		highest = max(highest, priority(p))
+	}
+	return highest
+}
+
+func contains(pods []*v1.Pod, pod *v1.Pod) bool {
+	for _, p := range pods {
+		if p == pod {
+			return true
+		}
+	}
+	return false
+}
+
+// evict marks a victim with the DisruptionTarget condition kube-scheduler sets, then deletes it.
+func (s *Scheduler) evict(ctx context.Context, victim *v1.Pod) error {
+	marked := victim.DeepCopy()
+	marked.Status.Conditions = append(marked.Status.Conditions, v1.PodCondition{
+		Type:               v1.DisruptionTarget,
+		Status:             v1.ConditionTrue,
+		Reason:             v1.PodReasonPreemptionByScheduler,
+		Message:            fmt.Sprintf("%s: preempting to accommodate a higher priority pod", ProfileName),
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+	pods := s.clientset.CoreV1().Pods(victim.Namespace)
+	if _, err := pods.UpdateStatus(ctx, marked, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	return pods.Delete(ctx, victim.Name, metav1.DeleteOptions{})
+}
@@ -0,0 +1,187 @@
+package offline
+
+import (
+	"context"
+	"testing"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func withPreemptionPolicy(policy v1.PreemptionPolicy) kube.PodOpt {
+	return func(pod *v1.Pod) {
+		pod.Spec.PreemptionPolicy = &policy
+	}
+}
+
+func TestScheduler_Preemption(t *testing.T) {
+	tests := []struct {
+		name      string
+		preemptor *v1.Pod
+		victims   []string
+	}{
+		{
+			name:      "lowest priority first",
+			preemptor: newPod("high", "1", "1Gi", kube.WithPodPriorityClass("high", 1000)),
+			victims:   []string{"low"},
+		},
+		{
+			name:      "as many as needed",
+			preemptor: newPod("high", "2", "1Gi", kube.WithPodPriorityClass("high", 1000)),
+			victims:   []string{"low", "medium"},
+		},
+		{
+			name:      "not lower priority",
+			preemptor: newPod("peer", "2", "1Gi", kube.WithPodPriorityClass("medium", 100)),
+		},
+		{
+			name:      "never",
+			preemptor: newPod("high", "1", "1Gi", kube.WithPodPriorityClass("high", 1000), withPreemptionPolicy(v1.PreemptNever)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")})
+			require.NoError(t, err)
+			pods := cluster.Clientset().CoreV1().Pods("default")
+
+			for _, pod := range []*v1.Pod{
+				newPod("low", "1", "1Gi", kube.WithPodPriorityClass("low", 10)),
+				newPod("medium", "1", "1Gi", kube.WithPodPriorityClass("medium", 100)),
+			} {
+				_, err = pods.Create(ctx, pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+			_, err = pods.Create(ctx, tt.preemptor, metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+			for _, name := range []string{"low", "medium"} {
+				_, err := pods.Get(ctx, name, metav1.GetOptions{})
+				if containsName(tt.victims, name) {
+					assert.True(t, apierrors.IsNotFound(err), "%s should be preempted", name)
+				} else {
+					assert.NoError(t, err, "%s should not be preempted", name)
+				}
+			}
+
+			preemptor, err := pods.Get(ctx, tt.preemptor.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			if len(tt.victims) == 0 {
+				assert.Empty(t, preemptor.Status.NominatedNodeName)
+				assert.Empty(t, preemptor.Spec.NodeName)
+				return
+			}
+			assert.Equal(t, "node-a", preemptor.Status.NominatedNodeName)
+
+			// the preemptor is bound once the victims are gone
+			require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+			preemptor, err = pods.Get(ctx, tt.preemptor.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "node-a", preemptor.Spec.NodeName)
+			assert.Empty(t, preemptor.Status.NominatedNodeName)
+		})
+	}
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestScheduler_PreemptionMarksVictims(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")})
+	require.NoError(t, err)
+	clientset := cluster.Clientset()
+	pods := clientset.CoreV1().Pods("default")
+
+	_, err = pods.Create(ctx, newPod("low", "2", "1Gi"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+	watcher, err := pods.Watch(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	_, err = pods.Create(ctx, newPod("high", "1", "1Gi", kube.WithPodPriorityClass("high", 1000)), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+	// the victim is marked before it is deleted
+	for event := range watcher.ResultChan() {
+		pod := event.Object.(*v1.Pod)
+		if pod.Name != "low" {
+			continue
+		}
+		var condition *v1.PodCondition
+		for i := range pod.Status.Conditions {
+			if pod.Status.Conditions[i].Type == v1.DisruptionTarget {
+				condition = &pod.Status.Conditions[i]
+			}
+		}
+		if assert.NotNil(t, condition) {
+			assert.Equal(t, v1.PodReasonPreemptionByScheduler, condition.Reason)
+			assert.Equal(t, v1.ConditionTrue, condition.Status)
+		}
+		break
+	}
+}
+
+func TestCluster_PriorityAdmission(t *testing.T) {
+	never := v1.PreemptNever
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		priority int32
+		policy   *v1.PreemptionPolicy
+		wantErr  bool
+	}{
+		{name: "class", pod: newPod("p", "1", "1Gi", func(p *v1.Pod) { p.Spec.PriorityClassName = "batch" }), priority: 100, policy: &never},
+		{name: "matching priority", pod: newPod("p", "1", "1Gi", kube.WithPodPriorityClass("batch", 100)), priority: 100, policy: &never},
+		{name: "global default", pod: newPod("p", "1", "1Gi"), priority: 10},
+		{name: "unknown class with priority", pod: newPod("p", "1", "1Gi", kube.WithPodPriorityClass("missing", 7)), priority: 7},
+		{name: "unknown class", pod: newPod("p", "1", "1Gi", func(p *v1.Pod) { p.Spec.PriorityClassName = "missing" }), wantErr: true},
+		{name: "other priority", pod: newPod("p", "1", "1Gi", kube.WithPodPriorityClass("batch", 5)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")})
+			require.NoError(t, err)
+			clientset := cluster.Clientset()
+			for _, pc := range []*schedulingv1.PriorityClass{
+				{ObjectMeta: metav1.ObjectMeta{Name: "batch"}, Value: 100, PreemptionPolicy: &never},
+				{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Value: 10, GlobalDefault: true},
+			} {
+				_, err = clientset.SchedulingV1().PriorityClasses().Create(ctx, pc, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			pod, err := clientset.CoreV1().Pods("default").Create(ctx, tt.pod, metav1.CreateOptions{})
+			if tt.wantErr {
+				assert.True(t, apierrors.IsForbidden(err), "expected a forbidden error, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, pod.Spec.Priority)
+			assert.Equal(t, tt.priority, *pod.Spec.Priority)
+			assert.Equal(t, tt.policy, pod.Spec.PreemptionPolicy)
+		})
+	}
+}
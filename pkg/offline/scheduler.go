@@ -0,0 +1,485 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	kubescheduler "k8s.io/kube-scheduler/config/v1"
+)
+
+// Strategy is the scoring strategy of the NodeResourcesFit plugin.
+type Strategy string
+
+const (
+	// StrategyLeastAllocated favors the nodes with the most free resources, spreading the pods
+	StrategyLeastAllocated Strategy = "least-allocated"
+	// StrategyMostAllocated favors the nodes with the least free resources, packing the pods
+	StrategyMostAllocated Strategy = "most-allocated"
+)
+
+// ProfileName is the scheduler profile reported in the configuration of the built-in scheduler.
+const ProfileName = "default-scheduler"
+
+// Scheduler is a simple built-in scheduler for the offline cluster. Pending pods are filtered on the
+// node resources, taints, node selectors and required node affinity, scored with the weighted
+// NodeResourcesFit, NodeResourcesBalancedAllocation, TaintToleration and NodeAffinity plugins, and bound
+// to the best node, where they run immediately. Pods that fit no node preempt pods of lower priority, as
+// the DefaultPreemption plugin does. It implements kubernetes.SchedulerManager, so plugin weights can be
+// changed by scheduler events.
+type Scheduler struct {
+	clientset kubernetes.Interface
+	logger    *logger.Logger
+	strategy  Strategy
+
+	mu sync.Mutex
+	// profiles maps the scheduler name of each profile to its plugin weights
+	profiles map[string]map[string]int32
+	kick     chan struct{}
+}
+
+// SchedulerOpt configures a Scheduler.
+type SchedulerOpt func(*Scheduler)
+
+// WithStrategy sets the scoring strategy of the NodeResourcesFit plugin. Defaults to least-allocated.
+func WithStrategy(strategy Strategy) SchedulerOpt {
+	return func(s *Scheduler) {
+		s.strategy = strategy
+	}
+}
+
+// WithProfiles adds scheduler profiles besides default-scheduler, each with the default plugin weights.
+// Pods are scheduled with the profile named by their spec.schedulerName; pods naming no profile of the
+// scheduler stay pending, as they would in a cluster.
+func WithProfiles(names ...string) SchedulerOpt {
+	return func(s *Scheduler) {
+		for _, name := range names {
+			s.addProfile(name)
+		}
+	}
+}
+
+// addProfile adds a profile with the default plugin weights, unless it exists.
+func (s *Scheduler) addProfile(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[name]; !ok && name != "" {
+		s.profiles[name] = kube.DefaultPluginWeights()
+	}
+}
+
+// ParseStrategy parses a scoring strategy name.
+func ParseStrategy(name string) (Strategy, error) {
+	switch Strategy(name) {
+	case StrategyLeastAllocated, StrategyMostAllocated:
+		return Strategy(name), nil
+	}
+	return "", fmt.Errorf("unknown strategy %q, expected %s or %s", name, StrategyLeastAllocated, StrategyMostAllocated)
+}
+
+// NewScheduler creates a built-in scheduler binding the pods of clientset.
+func NewScheduler(clientset kubernetes.Interface, log *logger.Logger, opts ...SchedulerOpt) *Scheduler {
+	s := &Scheduler{
+		clientset: clientset,
+		logger:    log,
+		strategy:  StrategyLeastAllocated,
+		profiles:  map[string]map[string]int32{ProfileName: kube.DefaultPluginWeights()},
+		kick:      make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run schedules the pending pods whenever a pod is created, deleted or ends or a node changes, until the
+// context is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	pods, err := s.clientset.CoreV1().Pods("").Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %w", err)
+	}
+	defer pods.Stop()
+	nodes, err := s.clientset.CoreV1().Nodes().Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to watch nodes: %w", err)
+	}
+	defer nodes.Stop()
+
+	s.Trigger()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-pods.ResultChan():
+			if !ok {
+				return fmt.Errorf("pod watch closed")
+			}
+			// binding updates the pods, only creations, deletions and pods ending change what can be scheduled
+			if event.Type == watch.Added || event.Type == watch.Deleted || ended(event.Object) {
+				s.Trigger()
+			}
+		case _, ok := <-nodes.ResultChan():
+			if !ok {
+				return fmt.Errorf("node watch closed")
+			}
+			s.Trigger()
+		case <-s.kick:
+			if err := s.ScheduleOnce(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Errorf("offline scheduler: %v", err)
+			}
+		}
+	}
+}
+
+// Trigger requests a scheduling pass.
+func (s *Scheduler) Trigger() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// ScheduleOnce binds the pending pods that fit, by decreasing priority and then creation time.
+func (s *Scheduler) ScheduleOnce(ctx context.Context) error {
+	nodeList, err := s.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	podList, err := s.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	nodes := make([]*nodeInfo, 0, len(nodeList.Items))
+	byName := make(map[string]*nodeInfo, len(nodeList.Items))
+	for i := range nodeList.Items {
+		n := newNodeInfo(&nodeList.Items[i])
+		nodes = append(nodes, n)
+		byName[n.node.Name] = n
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].node.Name < nodes[j].node.Name })
+
+	pending := make([]*v1.Pod, 0)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if pod.Spec.NodeName == "" {
+			pending = append(pending, pod)
+		} else if n, ok := byName[pod.Spec.NodeName]; ok {
+			n.addPod(pod)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		pi, pj := priority(pending[i]), priority(pending[j])
+		if pi != pj {
+			return pi > pj
+		}
+		return pending[i].CreationTimestamp.Before(&pending[j].CreationTimestamp)
+	})
+
+	for _, pod := range pending {
+		weights, ok := s.profileWeights(profileOf(pod))
+		if !ok {
+			s.logger.Debugf("offline scheduler: pod %s/%s has no profile %s", pod.Namespace, pod.Name, profileOf(pod))
+			continue
+		}
+		node, err := s.selectNode(pod, nodes, weights)
+		if err != nil {
+			s.logger.Debugf("offline scheduler: pod %s/%s is unschedulable: %v", pod.Namespace, pod.Name, err)
+			nominated, preemptErr := s.preempt(ctx, pod, nodes)
+			if preemptErr != nil {
+				return fmt.Errorf("failed to preempt for pod %s/%s: %w", pod.Namespace, pod.Name, preemptErr)
+			}
+			if err := s.markUnschedulable(ctx, pod, err, nominated); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to update pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+			continue
+		}
+		if err := s.bind(ctx, pod, node.node.Name); err != nil {
+			return fmt.Errorf("failed to bind pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		node.addPod(pod)
+		s.logger.Debugf("offline scheduler: pod %s/%s bound to %s", pod.Namespace, pod.Name, node.node.Name)
+	}
+	return nil
+}
+
+// selectNode returns the feasible node with the highest score, weighted with the weights of the profile of
+// the pod. Ties go to the first node by name.
+func (s *Scheduler) selectNode(pod *v1.Pod, nodes []*nodeInfo, weights map[string]int32) (*nodeInfo, error) {
+	feasible := make([]*nodeInfo, 0, len(nodes))
+	reasons := make(map[string]int)
+	for _, n := range nodes {
+		if err := filter(pod, n); err != nil {
+			reasons[err.Error()]++
+			continue
+		}
+		feasible = append(feasible, n)
+	}
+	if len(feasible) == 0 {
+		return nil, fmt.Errorf("0/%d nodes are available: %v", len(nodes), reasons)
+	}
+
+	totals := make([]float64, len(feasible))
+	for _, plugin := range scorePlugins {
+		weight := weights[plugin.name]
+		if weight == 0 {
+			continue
+		}
+		scores := make([]float64, len(feasible))
+		highest := 0.0
+		for i, n := range feasible {
+			scores[i] = plugin.score(pod, n, s.strategy)
+			highest = max(highest, scores[i])
+		}
+		for i := range feasible {
+			value := scores[i]
+			if plugin.normalized {
+				value = normalize(plugin.name, scores[i], highest)
+			}
+			totals[i] += float64(weight) * value
+		}
+	}
+
+	best := 0
+	for i := range feasible {
+		if totals[i] > totals[best] {
+			best = i
+		}
+	}
+	return feasible[best], nil
+}
+
+// normalize scales the raw score of a plugin to [0, 100] against the highest raw score.
+func normalize(plugin string, score, highest float64) float64 {
+	if plugin == kube.TaintToleration {
+		// fewer untolerated taints is better
+		if highest == 0 {
+			return 100
+		}
+		return 100 * (1 - score/highest)
+	}
+	if highest == 0 {
+		return 0
+	}
+	return 100 * score / highest
+}
+
+// bind assigns the pod to the node and marks it running, as a kubelet would.
+func (s *Scheduler) bind(ctx context.Context, pod *v1.Pod, nodeName string) error {
+	bound := pod.DeepCopy()
+	now := metav1.NewTime(time.Now())
+	bound.Spec.NodeName = nodeName
+	bound.Status.NominatedNodeName = ""
+	bound.Status.Phase = v1.PodRunning
+	bound.Status.StartTime = &now
+	setCondition(bound, v1.PodCondition{Type: v1.PodScheduled, Status: v1.ConditionTrue, LastTransitionTime: now})
+	setCondition(bound, v1.PodCondition{Type: v1.PodReady, Status: v1.ConditionTrue, LastTransitionTime: now})
+	_, err := s.clientset.CoreV1().Pods(pod.Namespace).Update(ctx, bound, metav1.UpdateOptions{})
+	return err
+}
+
+// markUnschedulable sets the PodScheduled condition of a pod that fits no node and the node it is
+// nominated to by a preemption, if any, as kube-scheduler does.
+func (s *Scheduler) markUnschedulable(ctx context.Context, pod *v1.Pod, reason error, nominated string) error {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse && c.Message == reason.Error() &&
+			(nominated == "" || pod.Status.NominatedNodeName == nominated) {
+			return nil
+		}
+	}
+	marked := pod.DeepCopy()
+	setCondition(marked, v1.PodCondition{
+		Type:               v1.PodScheduled,
+		Status:             v1.ConditionFalse,
+		Reason:             v1.PodReasonUnschedulable,
+		Message:            reason.Error(),
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+	if nominated != "" {
+		marked.Status.NominatedNodeName = nominated
+	}
+	_, err := s.clientset.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, marked, metav1.UpdateOptions{})
+	return err
+}
+
+// setCondition sets a condition of the pod, replacing the condition of the same type if any.
+func setCondition(pod *v1.Pod, condition v1.PodCondition) {
+	for i, c := range pod.Status.Conditions {
+		if c.Type == condition.Type {
+			pod.Status.Conditions[i] = condition
+			return
+		}
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, condition)
+}
+
+// profileOf returns the scheduler name of a pod, default-scheduler if unset.
+func profileOf(pod *v1.Pod) string {
+	if pod.Spec.SchedulerName == "" {
+		return ProfileName
+	}
+	return pod.Spec.SchedulerName
+}
+
+// profileWeights returns a copy of the plugin weights of a profile.
+func (s *Scheduler) profileWeights(profile string) (map[string]int32, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.profiles[profile]
+	if !ok {
+		return nil, false
+	}
+	weights := make(map[string]int32, len(current))
+	for plugin, weight := range current {
+		weights[plugin] = weight
+	}
+	return weights, true
+}
+
+// ended reports whether the object is a pod that succeeded or failed.
+func ended(obj runtime.Object) bool {
+	pod, ok := obj.(*v1.Pod)
+	return ok && (pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed)
+}
+
+func priority(pod *v1.Pod) int32 {
+	if pod.Spec.Priority != nil {
+		return *pod.Spec.Priority
+	}
+	return 0
+}
+
+// GetPluginWeights returns the current plugin weights of the default-scheduler profile.
+func (s *Scheduler) GetPluginWeights(ctx context.Context) (map[string]int32, error) {
+	weights, _ := s.profileWeights(ProfileName)
+	return weights, nil
+}
+
+// UpdatePluginWeight updates the weight of a plugin of the default-scheduler profile.
+func (s *Scheduler) UpdatePluginWeight(ctx context.Context, pluginName string, weight int32) error {
+	return s.UpdatePluginWeights(ctx, map[string]int32{pluginName: weight})
+}
+
+// UpdatePluginWeights updates the weights of several plugins of the default-scheduler profile. Only the
+// scoring plugins of the built-in scheduler change its decisions.
+func (s *Scheduler) UpdatePluginWeights(ctx context.Context, weights map[string]int32) error {
+	return s.UpdateProfilePluginWeights(ctx, ProfileName, weights)
+}
+
+// UpdateProfilePluginWeights updates the weights of several plugins of a profile.
+func (s *Scheduler) UpdateProfilePluginWeights(ctx context.Context, profile string, weights map[string]int32) error {
+	for plugin := range weights {
+		if err := s.ValidatePluginName(plugin); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.profiles[profile]
+	if !ok {
+		return fmt.Errorf("profile %s not found in scheduler configuration", profile)
+	}
+	for plugin, weight := range weights {
+		if !isScorePlugin(plugin) {
+			s.logger.Warnf("plugin %s is not implemented by the offline scheduler, its weight has no effect", plugin)
+		}
+		current[plugin] = weight
+	}
+	return nil
+}
+
+// GetConfiguration returns the plugin weights as a scheduler configuration with a profile per scheduler
+// name, default-scheduler first.
+func (s *Scheduler) GetConfiguration(ctx context.Context) (*kubescheduler.KubeSchedulerConfiguration, error) {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.profiles))
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == ProfileName) != (names[j] == ProfileName) {
+			return names[i] == ProfileName
+		}
+		return names[i] < names[j]
+	})
+
+	config := &kubescheduler.KubeSchedulerConfiguration{}
+	for _, name := range names {
+		weights, _ := s.profileWeights(name)
+		plugins := make([]string, 0, len(weights))
+		for plugin := range weights {
+			plugins = append(plugins, plugin)
+		}
+		sort.Strings(plugins)
+
+		enabled := make([]kubescheduler.Plugin, 0, len(plugins))
+		for _, plugin := range plugins {
+			weight := weights[plugin]
+			enabled = append(enabled, kubescheduler.Plugin{Name: plugin, Weight: &weight})
+		}
+		schedulerName := name
+		config.Profiles = append(config.Profiles, kubescheduler.KubeSchedulerProfile{
+			SchedulerName: &schedulerName,
+			Plugins:       &kubescheduler.Plugins{MultiPoint: kubescheduler.PluginSet{Enabled: enabled}},
+		})
+	}
+	return config, nil
+}
+
+// UpdateConfiguration sets the plugin weights of each profile from its multi point plugins. Profiles
+// unknown to the scheduler are added.
+func (s *Scheduler) UpdateConfiguration(ctx context.Context, config *kubescheduler.KubeSchedulerConfiguration) error {
+	for _, profile := range config.Profiles {
+		if profile.Plugins == nil {
+			continue
+		}
+		weights := make(map[string]int32)
+		for _, plugin := range profile.Plugins.MultiPoint.Enabled {
+			weights[plugin.Name] = 1
+			if plugin.Weight != nil {
+				weights[plugin.Name] = *plugin.Weight
+			}
+		}
+		name := kube.ProfileSchedulerName(profile)
+		s.addProfile(name)
+		if err := s.UpdateProfilePluginWeights(ctx, name, weights); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidatePluginName checks that the plugin is a default scheduler plugin.
+func (s *Scheduler) ValidatePluginName(pluginName string) error {
+	if !kube.IsDefaultPlugin(pluginName) {
+		return fmt.Errorf("unknown plugin %q", pluginName)
+	}
+	return nil
+}
+
+// ResetToDefaults resets the plugin weights of all the profiles to the Kubernetes defaults.
+func (s *Scheduler) ResetToDefaults(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name := range s.profiles {
+		s.profiles[name] = kube.DefaultPluginWeights()
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubescheduler "k8s.io/kube-scheduler/config/v1"
+)
+
+func newNode(name string, opts ...kube.NodeOpt) *v1.Node {
+	opts = append([]kube.NodeOpt{kube.WithNodeAllocatable("2", "4Gi", "10")}, opts...)
+	return kube.ObjectFactory.NewNode(name, opts...)
+}
+
+func newPod(name, cpu, memory string, opts ...kube.PodOpt) *v1.Pod {
+	opts = append([]kube.PodOpt{kube.WithPodResources(cpu, memory)}, opts...)
+	pod := kube.ObjectFactory.NewPod(metav1.NamespaceDefault, opts...)
+	pod.Name = name
+	return pod
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		pod     *v1.Pod
+		node    *v1.Node
+		wantErr string
+	}{
+		{name: "fits", pod: newPod("p", "1", "1Gi"), node: newNode("n")},
+		{name: "insufficient cpu", pod: newPod("p", "3", "1Gi"), node: newNode("n"), wantErr: "insufficient cpu"},
+		{name: "insufficient memory", pod: newPod("p", "1", "8Gi"), node: newNode("n"), wantErr: "insufficient memory"},
+		{
+			name: "pods not declared",
+			pod:  newPod("p", "1", "1Gi"),
+			node: &v1.Node{Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("4Gi"),
+			}}},
+		},
+		{name: "unschedulable", pod: newPod("p", "1", "1Gi"), node: newNode("n", kube.WithNodeUnschedulable(true)), wantErr: "unschedulable"},
+		{
+			name:    "untolerated taint",
+			pod:     newPod("p", "1", "1Gi"),
+			node:    newNode("n", kube.WithNodeTaints([]v1.Taint{{Key: "gpu", Effect: v1.TaintEffectNoSchedule}})),
+			wantErr: "untolerated taint",
+		},
+		{
+			name: "tolerated taint",
+			pod:  newPod("p", "1", "1Gi", kube.WithPodTolerations([]v1.Toleration{{Key: "gpu", Operator: v1.TolerationOpExists}})),
+			node: newNode("n", kube.WithNodeTaints([]v1.Taint{{Key: "gpu", Effect: v1.TaintEffectNoSchedule}})),
+		},
+		{
+			name:    "node selector",
+			pod:     newPod("p", "1", "1Gi", kube.WithPodNodeSelector(map[string]string{"zone": "a"})),
+			node:    newNode("n", kube.WithNodeLabels(map[string]string{"zone": "b"})),
+			wantErr: "node selector",
+		},
+		{
+			name: "required node affinity",
+			pod: newPod("p", "1", "1Gi", kube.WithPodAffinity(&v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{{
+					MatchExpressions: []v1.NodeSelectorRequirement{{Key: "zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a", "b"}}},
+				}}},
+			}})),
+			node: newNode("n", kube.WithNodeLabels(map[string]string{"zone": "b"})),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := filter(tt.pod, newNodeInfo(tt.node))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduler_Strategy(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		want     string
+	}{
+		{strategy: StrategyLeastAllocated, want: "node-b"},
+		{strategy: StrategyMostAllocated, want: "node-a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			ctx := context.Background()
+			cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a"), newNode("node-b")}, WithStrategy(tt.strategy))
+			require.NoError(t, err)
+			clientset := cluster.Clientset()
+
+			// node-a is the first node by name, it takes the first pod with both strategies
+			_, err = clientset.CoreV1().Pods("default").Create(ctx, newPod("first", "1", "1Gi"), metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+			_, err = clientset.CoreV1().Pods("default").Create(ctx, newPod("second", "500m", "512Mi"), metav1.CreateOptions{})
+			require.NoError(t, err)
+			require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+			first, err := clientset.CoreV1().Pods("default").Get(ctx, "first", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "node-a", first.Spec.NodeName)
+			assert.Equal(t, v1.PodRunning, first.Status.Phase)
+
+			second, err := clientset.CoreV1().Pods("default").Get(ctx, "second", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, second.Spec.NodeName)
+		})
+	}
+}
+
+func TestScheduler_PendingUntilResourcesFree(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")})
+	require.NoError(t, err)
+	clientset := cluster.Clientset()
+
+	for _, name := range []string{"big-1", "big-2"} {
+		_, err = clientset.CoreV1().Pods("default").Create(ctx, newPod(name, "1500m", "1Gi"), metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+	pending, err := clientset.CoreV1().Pods("default").Get(ctx, "big-2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.PodPending, pending.Status.Phase)
+	assert.Empty(t, pending.Spec.NodeName)
+	require.NotEmpty(t, pending.Status.Conditions)
+	assert.Equal(t, v1.PodScheduled, pending.Status.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionFalse, pending.Status.Conditions[0].Status)
+	assert.Equal(t, v1.PodReasonUnschedulable, pending.Status.Conditions[0].Reason)
+
+	require.NoError(t, clientset.CoreV1().Pods("default").Delete(ctx, "big-1", metav1.DeleteOptions{}))
+	require.NoError(t, cluster.Scheduler().ScheduleOnce(ctx))
+
+	bound, err := clientset.CoreV1().Pods("default").Get(ctx, "big-2", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "node-a", bound.Spec.NodeName)
+	for _, c := range bound.Status.Conditions {
+		if c.Type == v1.PodScheduled {
+			assert.Equal(t, v1.ConditionTrue, c.Status)
+		}
+	}
+}
+
+func TestScheduler_PluginWeights(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(nil, logger.Default())
+
+	require.NoError(t, s.UpdatePluginWeights(ctx, map[string]int32{kube.NodeResourcesFit: 7}))
+	weights, err := s.GetPluginWeights(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), weights[kube.NodeResourcesFit])
+
+	config, err := s.GetConfiguration(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.ResetToDefaults(ctx))
+	require.NoError(t, s.UpdateConfiguration(ctx, config))
+	weights, _ = s.GetPluginWeights(ctx)
+	assert.Equal(t, int32(7), weights[kube.NodeResourcesFit])
+
+	assert.Error(t, s.UpdatePluginWeight(ctx, "NotAPlugin", 1))
+}
+
+func TestScheduler_Profiles(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")}, WithProfiles("binpack"))
+	require.NoError(t, err)
+	clientset := cluster.Clientset()
+	s := cluster.Scheduler()
+
+	pods := map[string]string{"default": "", "binpack": "binpack", "unknown": "spread"}
+	for name, schedulerName := range pods {
+		_, err = clientset.CoreV1().Pods("default").Create(ctx, newPod(name, "500m", "512Mi", kube.WithPodSchedulerName(schedulerName)), metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.ScheduleOnce(ctx))
+
+	for name, want := range map[string]string{"default": "node-a", "binpack": "node-a", "unknown": ""} {
+		pod, err := clientset.CoreV1().Pods("default").Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, want, pod.Spec.NodeName, name)
+	}
+	unknown, err := clientset.CoreV1().Pods("default").Get(ctx, "unknown", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, unknown.Status.Conditions, "pods of an unknown scheduler are left alone")
+
+	require.NoError(t, s.UpdateProfilePluginWeights(ctx, "binpack", map[string]int32{kube.NodeResourcesFit: 9}))
+	assert.Error(t, s.UpdateProfilePluginWeights(ctx, "spread", map[string]int32{kube.NodeResourcesFit: 9}))
+	weights, err := s.GetPluginWeights(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, kube.DefaultPluginWeights()[kube.NodeResourcesFit], weights[kube.NodeResourcesFit])
+
+	config, err := s.GetConfiguration(ctx)
+	require.NoError(t, err)
+	require.Len(t, config.Profiles, 2)
+	assert.Equal(t, ProfileName, kube.ProfileSchedulerName(config.Profiles[0]))
+	assert.Equal(t, "binpack", kube.ProfileSchedulerName(config.Profiles[1]))
+
+	restored := NewScheduler(nil, logger.Default())
+	require.NoError(t, restored.UpdateConfiguration(ctx, config))
+	binpack, ok := restored.profileWeights("binpack")
+	require.True(t, ok)
+	assert.Equal(t, int32(9), binpack[kube.NodeResourcesFit])
+}
+
+func TestScheduler_UpdateConfigurationWhileScheduling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := NewCluster(logger.Default(), []*v1.Node{newNode("node-a")})
+	require.NoError(t, err)
+	cluster.Start(ctx)
+	clientset := cluster.Clientset()
+	s := cluster.Scheduler()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			schedulerName := fmt.Sprintf("profile-%d", i)
+			config := &kubescheduler.KubeSchedulerConfiguration{Profiles: []kubescheduler.KubeSchedulerProfile{{
+				SchedulerName: &schedulerName,
+				Plugins:       &kubescheduler.Plugins{MultiPoint: kubescheduler.PluginSet{Enabled: []kubescheduler.Plugin{{Name: kube.NodeResourcesFit}}}},
+			}}}
+			assert.NoError(t, s.UpdateConfiguration(ctx, config))
+		}
+	}()
+	for i := 0; i < 8; i++ {
+		pod := newPod(fmt.Sprintf("pod-%d", i), "100m", "64Mi", kube.WithPodSchedulerName(fmt.Sprintf("profile-%d", i)))
+		_, err := clientset.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	// the profiles are read while they are added
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			_, err := s.GetConfiguration(ctx)
+			require.NoError(t, err)
+		}
+	}
+
+	config, err := s.GetConfiguration(ctx)
+	require.NoError(t, err)
+	assert.Len(t, config.Profiles, 201, "default-scheduler and the profiles added")
+}
@@ -0,0 +1,272 @@
+// Package recorder watches the pod activity of a live cluster and turns it into a scenario.
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	cc "k8s.io/client-go/tools/cache"
+)
+
+// Recorder records the pods created and deleted in a cluster. Pods existing when the recording starts
+// are ignored.
+type Recorder struct {
+	clientset     kubernetes.Interface
+	logger        *logger.Logger
+	namespaces    []string
+	labelSelector string
+	anonymize     bool
+	nodes         bool
+
+	mu      sync.Mutex
+	started time.Time
+	pods    map[types.UID]*recordedPod
+	order   []*recordedPod
+	cluster []*v1.Node
+}
+
+// recordedPod is a pod observed during the recording.
+type recordedPod struct {
+	spec *v1.Pod
+	// arrival is the time the pod was created since the start of the recording
+	arrival time.Duration
+	// lifetime is the time the pod ran, zero if it was still running when the recording stopped
+	lifetime time.Duration
+	ended    bool
+}
+
+// RecorderOpt configures a Recorder.
+type RecorderOpt func(*Recorder)
+
+// WithNamespaces restricts the recording to the given namespaces. All namespaces are recorded by default.
+func WithNamespaces(namespaces ...string) RecorderOpt {
+	return func(r *Recorder) {
+		r.namespaces = namespaces
+	}
+}
+
+// WithLabelSelector records only the pods matching the label selector.
+func WithLabelSelector(selector string) RecorderOpt {
+	return func(r *Recorder) {
+		r.labelSelector = selector
+	}
+}
+
+// WithAnonymize replaces the names of pods, namespaces, nodes and containers and the images of the
+// recorded pods, and drops their annotations, environment, commands and arguments.
+func WithAnonymize(anonymize bool) RecorderOpt {
+	return func(r *Recorder) {
+		r.anonymize = anonymize
+	}
+}
+
+// WithNodes records the nodes of the cluster, with their labels, taints and resources.
+func WithNodes(nodes bool) RecorderOpt {
+	return func(r *Recorder) {
+		r.nodes = nodes
+	}
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder(clientset kubernetes.Interface, log *logger.Logger, opts ...RecorderOpt) *Recorder {
+	r := &Recorder{
+		clientset: clientset,
+		logger:    log,
+		nodes:     true,
+		pods:      make(map[types.UID]*recordedPod),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Record records the cluster until the duration has elapsed or the context is done, and returns the
+// recorded scenario. A zero duration records until the context is done.
+func (r *Recorder) Record(ctx context.Context, duration time.Duration) (*simulation.Scenario, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := r.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	var timeout <-chan time.Time
+	if duration > 0 {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-timeout:
+	case <-ctx.Done():
+		r.logger.Warnf("recording interrupted after %s", time.Since(r.started).Round(time.Second))
+	}
+	return r.Scenario(), nil
+}
+
+// Start records the nodes of the cluster and watches its pods until the context is done. It returns once
+// the existing pods are listed.
+func (r *Recorder) Start(ctx context.Context) error {
+	if r.nodes {
+		nodes, err := r.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %w", err)
+		}
+		r.mu.Lock()
+		for i := range nodes.Items {
+			r.cluster = append(r.cluster, sanitizeNode(&nodes.Items[i]))
+		}
+		r.mu.Unlock()
+	}
+
+	namespaces := r.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	r.mu.Lock()
+	r.started = time.Now()
+	r.mu.Unlock()
+
+	for _, namespace := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(r.clientset, 0,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = r.labelSelector
+			}))
+		informer := factory.Core().V1().Pods().Informer()
+		if _, err := informer.AddEventHandler(cc.ResourceEventHandlerDetailedFuncs{
+			AddFunc:    r.onAdd,
+			UpdateFunc: r.onUpdate,
+			DeleteFunc: r.onDelete,
+		}); err != nil {
+			return err
+		}
+		factory.Start(ctx.Done())
+		for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				return fmt.Errorf("failed to sync the %s informer", informerType)
+			}
+		}
+	}
+	r.logger.Infof("recording pods in %s", describeNamespaces(r.namespaces))
+	return nil
+}
+
+func (r *Recorder) onAdd(obj interface{}, isInInitialList bool) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok || isInInitialList {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.pods[pod.UID]; exists {
+		return
+	}
+	recorded := &recordedPod{spec: sanitizePod(pod), arrival: time.Since(r.started)}
+	r.pods[pod.UID] = recorded
+	r.order = append(r.order, recorded)
+	r.logger.Debugf("[recorder] pod %s/%s created at %s", pod.Namespace, pod.Name, recorded.arrival)
+}
+
+// onUpdate ends the lifetime of pods that completed or failed.
+func (r *Recorder) onUpdate(_, newObj interface{}) {
+	pod, ok := newObj.(*v1.Pod)
+	if !ok || (pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed) {
+		return
+	}
+	r.end(pod)
+}
+
+func (r *Recorder) onDelete(obj interface{}) {
+	if tombstone, ok := obj.(cc.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*v1.Pod); ok {
+		r.end(pod)
+	}
+}
+
+// end records the lifetime of a pod created during the recording.
+func (r *Recorder) end(pod *v1.Pod) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorded, ok := r.pods[pod.UID]
+	if !ok || recorded.ended {
+		return
+	}
+	recorded.ended = true
+	recorded.lifetime = time.Since(r.started) - recorded.arrival
+	r.logger.Debugf("[recorder] pod %s/%s ended after %s", pod.Namespace, pod.Name, recorded.lifetime)
+}
+
+// Count returns the number of pods recorded so far.
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.order)
+}
+
+// Scenario returns the scenario recorded so far. Arrival times are relative to the start of the recording
+// and evict times are the observed lifetimes, measured from creation; pods still running have no evict time.
+func (r *Recorder) Scenario() *simulation.Scenario {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := time.Since(r.started).Round(time.Second)
+	scenario := &simulation.Scenario{
+		Metadata: simulation.Metadata{
+			Name: "recording",
+			Description: fmt.Sprintf("%d pod(s) recorded in %s for %s", len(r.order),
+				describeNamespaces(r.namespaces), elapsed),
+			CreatedAt: r.started.UTC().Format(time.RFC3339),
+		},
+		// the lifetimes are measured from the creation of the pods
+		Eviction: &simulation.EvictionPolicy{EvictFrom: simulation.EvictFromCreated},
+	}
+
+	a := newAnonymizer()
+	nodes := make([]*v1.Node, 0, len(r.cluster))
+	for _, node := range r.cluster {
+		node = node.DeepCopy()
+		if r.anonymize {
+			a.node(node)
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	scenario.Cluster.Nodes = nodes
+
+	for _, recorded := range r.order {
+		spec := recorded.spec.DeepCopy()
+		if r.anonymize {
+			a.pod(spec)
+		}
+		scenario.Events.Pods = append(scenario.Events.Pods, simulation.PodEvent{
+			Name:        spec.Name,
+			ArrivalTime: simulation.EventDuration(recorded.arrival.Round(time.Millisecond)),
+			EvictTime:   simulation.EventDuration(recorded.lifetime.Round(time.Millisecond)),
+			PodSpec:     spec,
+			EventType:   simulation.PodEventTypeCreate,
+		})
+	}
+	return scenario
+}
+
+func describeNamespaces(namespaces []string) string {
+	if len(namespaces) == 0 {
+		return "all namespaces"
+	}
+	return fmt.Sprintf("namespace(s) %v", namespaces)
+}
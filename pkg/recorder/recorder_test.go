@@ -0,0 +1,148 @@
+package recorder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPod(namespace, name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			UID:             types.UID(namespace + "/" + name),
+			ResourceVersion: "42",
+			Labels:          map[string]string{"app": "batch"},
+			Annotations:     map[string]string{lastAppliedAnnotation: "{}", "team": "a"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Job", Name: "batch"}},
+		},
+		Spec: v1.PodSpec{
+			NodeName: "node-1",
+			Containers: []v1.Container{{
+				Name:         "main",
+				Image:        "registry.example.com/batch:1.0",
+				Env:          []v1.EnvVar{{Name: "TOKEN", Value: "secret"}},
+				VolumeMounts: []v1.VolumeMount{{Name: "kube-api-access-abcde"}, {Name: "data"}},
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU: resource.MustParse("250m"),
+				}},
+			}},
+			Volumes: []v1.Volume{{Name: "kube-api-access-abcde"}, {Name: "data"}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{v1.LabelHostname: "node-1"}},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+			NodeInfo: v1.NodeSystemInfo{KubeletVersion: "v1.33.0"},
+		},
+	}
+	clientset := fake.NewClientset(node, newPod("team-a", "existing"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRecorder(clientset, logger.Default(), WithNamespaces("team-a"))
+	require.NoError(t, r.Start(ctx))
+
+	pods := clientset.CoreV1().Pods("team-a")
+	_, err := pods.Create(ctx, newPod("team-a", "short"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = pods.Create(ctx, newPod("team-a", "long"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = clientset.CoreV1().Pods("team-b").Create(ctx, newPod("team-b", "other"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return r.Count() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, pods.Delete(ctx, "short", metav1.DeleteOptions{}))
+	// deleting a pod existing before the recording is ignored
+	require.NoError(t, pods.Delete(ctx, "existing", metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool {
+		return r.Scenario().Events.Pods[0].EvictTime > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	scenario := r.Scenario()
+	require.Len(t, scenario.Cluster.Nodes, 1)
+	assert.Empty(t, scenario.Cluster.Nodes[0].Status.NodeInfo.KubeletVersion)
+	require.NotNil(t, scenario.Eviction)
+	assert.Equal(t, simulation.EvictFromCreated, scenario.Eviction.EvictFrom)
+	require.Len(t, scenario.Events.Pods, 2)
+
+	short, long := scenario.Events.Pods[0], scenario.Events.Pods[1]
+	assert.Equal(t, "short", short.Name)
+	assert.GreaterOrEqual(t, short.EvictTime.Duration(), 50*time.Millisecond)
+	assert.Equal(t, "long", long.Name)
+	assert.Zero(t, long.EvictTime)
+	assert.LessOrEqual(t, short.ArrivalTime, long.ArrivalTime)
+
+	spec := short.PodSpec
+	assert.Equal(t, "team-a", spec.Namespace)
+	assert.Empty(t, spec.UID)
+	assert.Empty(t, spec.ResourceVersion)
+	assert.Empty(t, spec.OwnerReferences)
+	assert.Empty(t, spec.Spec.NodeName)
+	assert.Empty(t, spec.Status)
+	assert.Equal(t, map[string]string{"team": "a"}, spec.Annotations)
+	assert.Equal(t, []v1.Volume{{Name: "data"}}, spec.Spec.Volumes)
+	assert.Equal(t, []v1.VolumeMount{{Name: "data"}}, spec.Spec.Containers[0].VolumeMounts)
+	assert.Equal(t, "registry.example.com/batch:1.0", spec.Spec.Containers[0].Image)
+}
+
+func TestRecorder_Anonymize(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "prod-node", Labels: map[string]string{v1.LabelHostname: "prod-node"}}}
+	clientset := fake.NewClientset(node)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRecorder(clientset, logger.Default(), WithAnonymize(true))
+	require.NoError(t, r.Start(ctx))
+
+	// one at a time, so the aliases follow the creation order
+	for i, name := range []string{"payments-1", "payments-2"} {
+		_, err := clientset.CoreV1().Pods("payments").Create(ctx, newPod("payments", name), metav1.CreateOptions{})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return r.Count() == i+1 }, 5*time.Second, 10*time.Millisecond)
+	}
+
+	scenario := r.Scenario()
+	assert.Equal(t, "node-0", scenario.Cluster.Nodes[0].Name)
+	assert.Equal(t, "node-0", scenario.Cluster.Nodes[0].Labels[v1.LabelHostname])
+	for i, event := range scenario.Events.Pods {
+		spec := event.PodSpec
+		assert.Equal(t, []string{"pod-0", "pod-1"}[i], spec.Name)
+		assert.Equal(t, spec.Name, event.Name)
+		assert.Equal(t, "namespace-0", spec.Namespace)
+		assert.Empty(t, spec.Annotations)
+		assert.Equal(t, map[string]string{"app": "batch"}, spec.Labels)
+		assert.Equal(t, "container-0", spec.Spec.Containers[0].Name)
+		assert.Equal(t, anonymousImage, spec.Spec.Containers[0].Image)
+		assert.Empty(t, spec.Spec.Containers[0].Env)
+	}
+}
+
+func TestRecorder_Record(t *testing.T) {
+	clientset := fake.NewClientset()
+	r := NewRecorder(clientset, logger.Default(), WithNodes(false))
+
+	start := time.Now()
+	scenario, err := r.Record(context.Background(), 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Empty(t, scenario.Cluster.Nodes)
+	assert.Empty(t, scenario.Events.Pods)
+}
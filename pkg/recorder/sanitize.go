@@ -0,0 +1,146 @@
+package recorder
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// anonymousImage replaces the container images of anonymized pods.
+const anonymousImage = "registry.k8s.io/pause:3.9"
+
+// lastAppliedAnnotation is the annotation kubectl apply stores the applied object in.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// serviceAccountVolumePrefix prefixes the projected service account token volumes added by the API server.
+const serviceAccountVolumePrefix = "kube-api-access-"
+
+// sanitizePod returns a copy of the pod that can be created again: the status, the node, the UID and
+// the fields set by the API server and by controllers are stripped.
+func sanitizePod(pod *v1.Pod) *v1.Pod {
+	annotations := make(map[string]string)
+	for k, v := range pod.Annotations {
+		if k != lastAppliedAnnotation {
+			annotations[k] = v
+		}
+	}
+
+	spec := *pod.Spec.DeepCopy()
+	spec.NodeName = ""
+	spec.EphemeralContainers = nil
+
+	// the service account token volume is projected again on creation
+	volumes := spec.Volumes[:0]
+	for _, volume := range spec.Volumes {
+		if !strings.HasPrefix(volume.Name, serviceAccountVolumePrefix) {
+			volumes = append(volumes, volume)
+		}
+	}
+	spec.Volumes = volumes
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			mounts := containers[i].VolumeMounts[:0]
+			for _, mount := range containers[i].VolumeMounts {
+				if !strings.HasPrefix(mount.Name, serviceAccountVolumePrefix) {
+					mounts = append(mounts, mount)
+				}
+			}
+			containers[i].VolumeMounts = mounts
+		}
+	}
+
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name,
+			Namespace:   pod.Namespace,
+			Labels:      pod.Labels,
+			Annotations: annotations,
+		},
+		Spec: spec,
+	}
+}
+
+// sanitizeNode returns a copy of the node with its labels, taints and resources only.
+func sanitizeNode(node *v1.Node) *v1.Node {
+	return &v1.Node{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   node.Name,
+			Labels: node.Labels,
+		},
+		Spec: v1.NodeSpec{
+			Taints:        node.Spec.Taints,
+			Unschedulable: node.Spec.Unschedulable,
+		},
+		Status: v1.NodeStatus{
+			Capacity:    node.Status.Capacity,
+			Allocatable: node.Status.Allocatable,
+		},
+	}
+}
+
+// anonymizer replaces names consistently: the same name is always replaced by the same alias.
+type anonymizer struct {
+	aliases map[string]map[string]string
+}
+
+func newAnonymizer() *anonymizer {
+	return &anonymizer{aliases: make(map[string]map[string]string)}
+}
+
+// alias returns the alias of a name of the given kind, e.g. pod-3.
+func (a *anonymizer) alias(kind, name string) string {
+	if name == "" {
+		return ""
+	}
+	aliases, ok := a.aliases[kind]
+	if !ok {
+		aliases = make(map[string]string)
+		a.aliases[kind] = aliases
+	}
+	if alias, ok := aliases[name]; ok {
+		return alias
+	}
+	alias := fmt.Sprintf("%s-%d", kind, len(aliases))
+	aliases[name] = alias
+	return alias
+}
+
+// pod anonymizes the pod names, namespace and containers. Labels are kept, as selectors may match them.
+func (a *anonymizer) pod(pod *v1.Pod) {
+	pod.Name = a.alias("pod", pod.Name)
+	if pod.Namespace != metav1.NamespaceDefault {
+		pod.Namespace = a.alias("namespace", pod.Namespace)
+	}
+	pod.Annotations = nil
+	pod.Spec.ServiceAccountName = ""
+	pod.Spec.ImagePullSecrets = nil
+	pod.Spec.Hostname = ""
+	pod.Spec.Subdomain = ""
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			containers[i].Name = a.alias("container", containers[i].Name)
+			containers[i].Image = anonymousImage
+			containers[i].Command = nil
+			containers[i].Args = nil
+			containers[i].Env = nil
+			containers[i].EnvFrom = nil
+		}
+	}
+}
+
+// node anonymizes the node name and its hostname label.
+func (a *anonymizer) node(node *v1.Node) {
+	node.Name = a.alias("node", node.Name)
+	if _, ok := node.Labels[v1.LabelHostname]; ok {
+		labels := make(map[string]string, len(node.Labels))
+		for k, v := range node.Labels {
+			labels[k] = v
+		}
+		labels[v1.LabelHostname] = node.Name
+		node.Labels = labels
+	}
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	chartWidth  = 760
+	chartHeight = 280
+	marginLeft  = 60
+	marginRight = 20
+	marginTop   = 16
+	// marginBottom leaves room for the X axis ticks and label
+	marginBottom = 44
+)
+
+// palette are the colors of the series, cycled.
+var palette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf"}
+
+type point struct {
+	x, y float64
+}
+
+type series struct {
+	name   string
+	points []point
+}
+
+// chart is a line chart drawn as inline SVG.
+type chart struct {
+	xLabel string
+	yLabel string
+	series []series
+	// step draws the series as step functions, holding each value until the next point
+	step bool
+	// yMin is the lowest upper bound of the Y axis
+	yMin float64
+}
+
+// html returns the chart as an SVG figure followed by its legend.
+func (c chart) html() template.HTML {
+	xEnd, yMax := 0.0, c.yMin
+	empty := true
+	for _, s := range c.series {
+		for _, p := range s.points {
+			xEnd = math.Max(xEnd, p.x)
+			yMax = math.Max(yMax, p.y)
+			empty = false
+		}
+	}
+	xMax := xEnd
+	if xMax == 0 {
+		xMax = 1
+	}
+	if yMax == 0 {
+		yMax = 1
+	}
+	xTicks := ticks(xMax, 8)
+	yTicks := ticks(yMax, 5)
+	xMax, yMax = xTicks[len(xTicks)-1], yTicks[len(yTicks)-1]
+
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	plotHeight := float64(chartHeight - marginTop - marginBottom)
+	x := func(v float64) float64 { return marginLeft + v/xMax*plotWidth }
+	y := func(v float64) float64 { return marginTop + plotHeight - v/yMax*plotHeight }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" role="img">`, chartWidth, chartHeight)
+	for _, t := range yTicks {
+		fmt.Fprintf(&b, `<line class="grid" x1="%d" x2="%d" y1="%.1f" y2="%.1f"/>`, marginLeft, chartWidth-marginRight, y(t), y(t))
+		fmt.Fprintf(&b, `<text class="tick" x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`, marginLeft-6, y(t), formatTick(t))
+	}
+	for _, t := range xTicks {
+		fmt.Fprintf(&b, `<text class="tick" x="%.1f" y="%d" text-anchor="middle">%s</text>`, x(t), chartHeight-marginBottom+16, formatTick(t))
+	}
+	fmt.Fprintf(&b, `<line class="axis" x1="%d" x2="%d" y1="%.1f" y2="%.1f"/>`, marginLeft, chartWidth-marginRight, y(0), y(0))
+	fmt.Fprintf(&b, `<line class="axis" x1="%d" x2="%d" y1="%d" y2="%.1f"/>`, marginLeft, marginLeft, marginTop, y(0))
+	fmt.Fprintf(&b, `<text class="label" x="%.1f" y="%d" text-anchor="middle">%s</text>`, marginLeft+plotWidth/2, chartHeight-6, html.EscapeString(c.xLabel))
+	fmt.Fprintf(&b, `<text class="label" transform="translate(14 %.1f) rotate(-90)" text-anchor="middle">%s</text>`, marginTop+plotHeight/2, html.EscapeString(c.yLabel))
+	if empty {
+		fmt.Fprintf(&b, `<text class="label" x="%.1f" y="%.1f" text-anchor="middle">no data</text>`, marginLeft+plotWidth/2, marginTop+plotHeight/2)
+	}
+
+	for i, s := range c.series {
+		if len(s.points) == 0 {
+			continue
+		}
+		var path strings.Builder
+		for j, p := range s.points {
+			switch {
+			case j == 0:
+				fmt.Fprintf(&path, "M%.1f %.1f", x(p.x), y(p.y))
+			case c.step:
+				fmt.Fprintf(&path, "H%.1f V%.1f", x(p.x), y(p.y))
+			default:
+				fmt.Fprintf(&path, "L%.1f %.1f", x(p.x), y(p.y))
+			}
+		}
+		if c.step {
+			// the last value holds until the end of the data
+			fmt.Fprintf(&path, "H%.1f", x(xEnd))
+		}
+		fmt.Fprintf(&b, `<path class="series" stroke="%s" d="%s"><title>%s</title></path>`, color(i), path.String(), html.EscapeString(s.name))
+	}
+	b.WriteString(`</svg>`)
+
+	if len(c.series) > 1 {
+		b.WriteString(`<div class="legend">`)
+		for i, s := range c.series {
+			fmt.Fprintf(&b, `<span><i style="background:%s"></i>%s</span>`, color(i), html.EscapeString(s.name))
+		}
+		b.WriteString(`</div>`)
+	}
+	return template.HTML(b.String())
+}
+
+func color(i int) string {
+	return palette[i%len(palette)]
+}
+
+// ticks returns about n round ticks from 0 to at least max.
+func ticks(max float64, n int) []float64 {
+	step := niceStep(max / float64(n))
+	count := int(math.Ceil(max/step - 1e-9))
+	values := make([]float64, 0, count+1)
+	for i := 0; i <= count; i++ {
+		values = append(values, float64(i)*step)
+	}
+	return values
+}
+
+// niceStep rounds a step up to 1, 2 or 5 times a power of ten.
+func niceStep(raw float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatTick(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
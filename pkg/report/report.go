@@ -0,0 +1,175 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"time"
+)
+
+// maxTimelineRows bounds the rows of the event timeline, the full history is in event_history.csv.
+const maxTimelineRows = 5000
+
+//go:embed report.html
+var reportTemplate string
+
+var tmpl = template.Must(template.New("report").Parse(reportTemplate))
+
+// figure is a chart of the report.
+type figure struct {
+	Title       string
+	Description string
+	Chart       template.HTML
+}
+
+// stat is a key figure of the report.
+type stat struct {
+	Name  string
+	Value string
+}
+
+// timelineRow is an event of the timeline, at an offset from the start of the results.
+type timelineRow struct {
+	Offset string
+	Event
+}
+
+type reportData struct {
+	Title       string
+	Results     *Results
+	GeneratedAt time.Time
+	Stats       []stat
+	Sections    []section
+	Timeline    []timelineRow
+	// Hidden is the number of events left out of the timeline
+	Hidden int
+}
+
+type section struct {
+	Title   string
+	Figures []figure
+}
+
+// Write renders the results as a self-contained HTML report, with no external resource.
+func Write(w io.Writer, r *Results) error {
+	start := r.Start()
+	offset := func(at time.Time) float64 { return at.Sub(start).Seconds() }
+
+	data := reportData{
+		Title:       r.Dir,
+		Results:     r,
+		GeneratedAt: time.Now(),
+		Stats:       summaryStats(r),
+	}
+	if r.Metadata != nil {
+		data.Title = r.Metadata.SimulationID
+	}
+
+	data.Sections = []section{
+		{Title: "Node allocation", Figures: []figure{
+			{Title: "CPU allocation ratio", Description: "Requested CPU over allocatable CPU, per node.",
+				Chart: chart{xLabel: "time (s)", yLabel: "ratio", step: true, yMin: 1, series: nodeSeries(r.Nodes, offset, true, "cpu", 1)}.html()},
+			{Title: "Memory allocation ratio", Description: "Requested memory over allocatable memory, per node.",
+				Chart: chart{xLabel: "time (s)", yLabel: "ratio", step: true, yMin: 1, series: nodeSeries(r.Nodes, offset, true, "memory", 1)}.html()},
+			{Title: "CPU allocated", Description: "CPU requested by the pods of each node.",
+				Chart: chart{xLabel: "time (s)", yLabel: "cores", step: true, series: nodeSeries(r.Nodes, offset, false, "cpu", 1e-3)}.html()},
+			{Title: "Memory allocated", Description: "Memory requested by the pods of each node.",
+				Chart: chart{xLabel: "time (s)", yLabel: "GiB", step: true, series: nodeSeries(r.Nodes, offset, false, "memory", 1e-3/(1<<30))}.html()},
+		}},
+		{Title: "Scheduling", Figures: []figure{
+			{Title: "Pending queue length", Description: "Pods waiting to be scheduled.",
+				Chart: chart{xLabel: "time (s)", yLabel: "pods", step: true, series: []series{queueSeries(r.QueueLength, offset)}}.html()},
+			{Title: "Pending duration CDF", Description: "Fraction of the pods scheduled within a time after their creation.",
+				Chart: chart{xLabel: "pending time (s)", yLabel: "fraction of pods", step: true, yMin: 1, series: []series{cdf("pending", r.PendingDurations)}}.html()},
+			{Title: "Running duration CDF", Description: "Fraction of the pods that ran up to a time.",
+				Chart: chart{xLabel: "running time (s)", yLabel: "fraction of pods", step: true, yMin: 1, series: []series{cdf("running", r.RunningDurations)}}.html()},
+		}},
+	}
+
+	for i, e := range r.Events {
+		if i == maxTimelineRows {
+			data.Hidden = len(r.Events) - i
+			break
+		}
+		data.Timeline = append(data.Timeline, timelineRow{Offset: fmt.Sprintf("%.3f", offset(e.At)), Event: e})
+	}
+	return tmpl.Execute(w, data)
+}
+
+// nodeSeries returns a series per node of a resource of its ratio or allocated history, scaled.
+func nodeSeries(nodes []NodeHistory, offset func(time.Time) float64, ratio bool, resource string, scale float64) []series {
+	all := make([]series, 0, len(nodes))
+	for _, node := range nodes {
+		samples := node.Allocated
+		if ratio {
+			samples = node.Ratio
+		}
+		s := series{name: node.Name}
+		for _, sample := range samples {
+			s.points = append(s.points, point{x: offset(sample.At), y: sample.Values[resource] * scale})
+		}
+		all = append(all, s)
+	}
+	return all
+}
+
+func queueSeries(samples []Sample, offset func(time.Time) float64) series {
+	s := series{name: "pending pods"}
+	for _, sample := range samples {
+		s.points = append(s.points, point{x: offset(sample.At), y: sample.Value})
+	}
+	return s
+}
+
+// cdf returns the empirical distribution of sorted durations, in seconds.
+func cdf(name string, durations []time.Duration) series {
+	s := series{name: name}
+	if len(durations) == 0 {
+		return s
+	}
+	s.points = append(s.points, point{x: 0, y: 0})
+	for i, d := range durations {
+		s.points = append(s.points, point{x: d.Seconds(), y: float64(i+1) / float64(len(durations))})
+	}
+	return s
+}
+
+// summaryStats are the key figures of the results.
+func summaryStats(r *Results) []stat {
+	peak := 0.0
+	for _, sample := range r.QueueLength {
+		peak = math.Max(peak, sample.Value)
+	}
+	span := time.Duration(0)
+	if len(r.Events) > 0 {
+		span = r.Events[len(r.Events)-1].At.Sub(r.Events[0].At)
+	}
+	return []stat{
+		{Name: "nodes", Value: fmt.Sprint(len(r.Nodes))},
+		{Name: "pod events", Value: fmt.Sprint(len(r.Events))},
+		{Name: "pods scheduled", Value: fmt.Sprint(len(r.PendingDurations))},
+		{Name: "pending p50", Value: formatDuration(quantile(r.PendingDurations, 0.5))},
+		{Name: "pending p95", Value: formatDuration(quantile(r.PendingDurations, 0.95))},
+		{Name: "running p50", Value: formatDuration(quantile(r.RunningDurations, 0.5))},
+		{Name: "max pending queue", Value: fmt.Sprint(peak)},
+		{Name: "event span", Value: formatDuration(span)},
+	}
+}
+
+// quantile returns the q quantile of sorted durations, by the nearest rank.
+func quantile(durations []time.Duration, q float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q*float64(len(durations)))) - 1
+	return durations[max(rank, 0)]
+}
+
+func formatDuration(d time.Duration) string {
+	if d >= time.Second {
+		return d.Round(10 * time.Millisecond).String()
+	}
+	return d.String()
+}
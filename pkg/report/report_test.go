@@ -0,0 +1,170 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeResults writes the files of a small simulation into a temporary directory.
+func writeResults(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"event_history.csv": `timestamp,pod_name,node_name,phase,event_type,cpu_req,mem_req,workload
+2025-01-01T10:00:02.000,web-1,node-1,Running,UPDATE,500m,256Mi,web
+2025-01-01T10:00:01.000,web-1,,Pending,ADD,500m,256Mi,web
+2025-01-01T10:00:03.500,<b>batch</b>,node-2,Running,UPDATE,1,1Gi,
+`,
+		"pod_queue_length.csv": `timestamp,length
+2025-01-01T10:00:00.000,0
+2025-01-01T10:00:01.000,2
+2025-01-01T10:00:03.000,0
+`,
+		"pod_pending_durations.csv": `pod_uid,pod_name,pending_time_milliseconds
+2,batch,2500
+1,web-1,1000
+`,
+		"pod_running_durations.csv": `pod_uid,pod_name,running_time_milliseconds
+1,web-1,4000
+`,
+		"node-1_allocation_history.csv": `timestamp,cpu,memory
+2025-01-01T10:00:00.000,0,0
+2025-01-01T10:00:02.000,500,268435456000
+`,
+		"node-1_allocation_ratio_history.csv": `timestamp,cpu,memory
+2025-01-01T10:00:00.000,0.00,0.00
+2025-01-01T10:00:02.000,0.25,0.06
+`,
+		"node-2_allocation_ratio_history.csv": `timestamp,memory,cpu
+2025-01-01T10:00:03.500,0.25,0.50
+`,
+		"metadata.json": `{"simulationId":"sim-42","scenario":"web","params":{"replicas":3},"endReason":"AllEventsDone","exportedAt":"2025-01-01T10:00:05Z"}`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func at(s string) time.Time {
+	return time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local).Add(mustDuration(s))
+}
+
+func mustDuration(s string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestLoad(t *testing.T) {
+	r, err := Load(writeResults(t))
+	require.NoError(t, err)
+
+	require.NotNil(t, r.Metadata)
+	assert.Equal(t, "sim-42", r.Metadata.SimulationID)
+
+	require.Len(t, r.Events, 3)
+	assert.Equal(t, Event{At: at("1s"), Pod: "web-1", Phase: "Pending", Type: "ADD", CPU: "500m", Memory: "256Mi", Workload: "web"}, r.Events[0])
+	assert.Equal(t, "node-1", r.Events[1].Node)
+
+	assert.Equal(t, []Sample{{At: at("0s"), Value: 0}, {At: at("1s"), Value: 2}, {At: at("3s"), Value: 0}}, r.QueueLength)
+	assert.Equal(t, []time.Duration{time.Second, 2500 * time.Millisecond}, r.PendingDurations)
+	assert.Equal(t, []time.Duration{4 * time.Second}, r.RunningDurations)
+
+	require.Len(t, r.Nodes, 2)
+	assert.Equal(t, "node-1", r.Nodes[0].Name)
+	require.Len(t, r.Nodes[0].Allocated, 2)
+	assert.Equal(t, map[string]float64{"cpu": 500, "memory": 268435456000}, r.Nodes[0].Allocated[1].Values)
+	assert.Equal(t, map[string]float64{"cpu": 0.25, "memory": 0.06}, r.Nodes[0].Ratio[1].Values)
+	assert.Equal(t, "node-2", r.Nodes[1].Name)
+	assert.Empty(t, r.Nodes[1].Allocated)
+	assert.Equal(t, map[string]float64{"cpu": 0.5, "memory": 0.25}, r.Nodes[1].Ratio[0].Values, "columns are read by name")
+
+	assert.Equal(t, at("0s"), r.Start())
+}
+
+func TestLoad_Errors(t *testing.T) {
+	empty := t.TempDir()
+	_, err := Load(empty)
+	assert.ErrorContains(t, err, "event_history.csv not found")
+
+	sweep := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(sweep, "run-001"), 0755))
+	_, err = Load(sweep)
+	assert.ErrorContains(t, err, "several runs")
+	assert.ErrorContains(t, err, "run-001")
+
+	invalid := writeResults(t)
+	require.NoError(t, os.WriteFile(filepath.Join(invalid, "pod_queue_length.csv"), []byte("timestamp,length\nyesterday,1\n"), 0644))
+	_, err = Load(invalid)
+	assert.ErrorContains(t, err, `pod_queue_length.csv: line 2: invalid timestamp "yesterday"`)
+}
+
+func TestWrite(t *testing.T) {
+	r, err := Load(writeResults(t))
+	require.NoError(t, err)
+
+	var b strings.Builder
+	require.NoError(t, Write(&b, r))
+	out := b.String()
+
+	assert.Contains(t, out, "<h1>sim-42</h1>")
+	assert.Contains(t, out, "<dt>param replicas</dt><dd>3</dd>")
+	assert.Contains(t, out, "<b>1s</b><span>pending p50</span>")
+	assert.Contains(t, out, "<b>2.5s</b><span>pending p95</span>")
+	assert.Contains(t, out, "<b>2</b><span>max pending queue</span>")
+	assert.Contains(t, out, "<b>2.5s</b><span>event span</span>")
+	for _, title := range []string{"CPU allocation ratio", "Memory allocation ratio", "CPU allocated", "Memory allocated", "Pending queue length", "Pending duration CDF", "Running duration CDF"} {
+		assert.Contains(t, out, "<h3>"+title+"</h3>")
+	}
+	assert.Equal(t, 7, strings.Count(out, "<svg "))
+	assert.Contains(t, out, "<title>node-2</title>")
+	assert.Contains(t, out, `<td class="num">3.500</td><td>&lt;b&gt;batch&lt;/b&gt;</td>`, "values are escaped")
+	assert.NotContains(t, out, "<script")
+	assert.NotContains(t, out, "http://", "the report loads no external resource")
+}
+
+func TestWrite_LongTimeline(t *testing.T) {
+	r := &Results{Dir: "results"}
+	for i := 0; i < maxTimelineRows+10; i++ {
+		r.Events = append(r.Events, Event{At: at("0s"), Pod: "pod"})
+	}
+
+	var b strings.Builder
+	require.NoError(t, Write(&b, r))
+	assert.Equal(t, maxTimelineRows, strings.Count(b.String(), "<td>pod</td>"))
+	assert.Contains(t, b.String(), "The last 10 events are left out")
+	assert.Contains(t, b.String(), "no data")
+}
+
+func TestTicks(t *testing.T) {
+	tests := []struct {
+		max  float64
+		n    int
+		want []float64
+	}{
+		{max: 1, n: 5, want: []float64{0, 0.2, 0.4, 0.6000000000000001, 0.8, 1}},
+		{max: 7, n: 5, want: []float64{0, 2, 4, 6, 8}},
+		{max: 95, n: 8, want: []float64{0, 20, 40, 60, 80, 100}},
+		{max: 100, n: 5, want: []float64{0, 20, 40, 60, 80, 100}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ticks(tt.max, tt.n), tt.max)
+	}
+	assert.Equal(t, "0.6", formatTick(0.6000000000000001))
+}
+
+func TestQuantile(t *testing.T) {
+	durations := []time.Duration{1, 2, 3, 4}
+	assert.Equal(t, time.Duration(2), quantile(durations, 0.5))
+	assert.Equal(t, time.Duration(4), quantile(durations, 0.95))
+	assert.Equal(t, time.Duration(1), quantile(durations, 0))
+	assert.Equal(t, time.Duration(0), quantile(nil, 0.5))
+}
@@ -0,0 +1,320 @@
+// Package report renders the results directory of a simulation as a self-contained HTML report.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+)
+
+// eventHistoryFile is the file of the pod events exported by the stats.
+const eventHistoryFile = "event_history.csv"
+
+// Sample is a value at a point in time.
+type Sample struct {
+	At    time.Time
+	Value float64
+}
+
+// ResourceSample are the values of the resources of a node at a point in time.
+type ResourceSample struct {
+	At     time.Time
+	Values map[string]float64
+}
+
+// NodeHistory is the allocation history of a node.
+type NodeHistory struct {
+	Name string
+	// Allocated are the resources requested by the pods of the node, in milli-units
+	Allocated []ResourceSample
+	// Ratio are the allocated resources over the allocatable ones
+	Ratio []ResourceSample
+}
+
+// Event is a pod event of the event history.
+type Event struct {
+	At       time.Time
+	Pod      string
+	Node     string
+	Phase    string
+	Type     string
+	CPU      string
+	Memory   string
+	Workload string
+}
+
+// Results are the stats of a simulation read back from its results directory.
+type Results struct {
+	Dir string
+	// Metadata describes the run, nil if the directory has no metadata
+	Metadata *simulation.ResultsMetadata
+	// Nodes are sorted by name
+	Nodes       []NodeHistory
+	QueueLength []Sample
+	// PendingDurations and RunningDurations are sorted
+	PendingDurations []time.Duration
+	RunningDurations []time.Duration
+	// Events are sorted by time
+	Events []Event
+}
+
+// Load reads the results of a simulation exported into dir. Missing files are left empty, but dir must
+// hold the event history of a simulation.
+func Load(dir string) (*Results, error) {
+	if _, err := os.Stat(filepath.Join(dir, eventHistoryFile)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if runs, _ := filepath.Glob(filepath.Join(dir, "run-*")); len(runs) > 0 {
+				return nil, fmt.Errorf("%s holds the results of several runs, pass the directory of one of them, e.g. %s", dir, runs[0])
+			}
+			return nil, fmt.Errorf("%s holds no simulation results: %s not found", dir, eventHistoryFile)
+		}
+		return nil, err
+	}
+
+	r := &Results{Dir: dir}
+	var err error
+	if r.Metadata, err = readMetadata(dir); err != nil {
+		return nil, err
+	}
+	if r.Events, err = readEvents(filepath.Join(dir, eventHistoryFile)); err != nil {
+		return nil, err
+	}
+	if r.QueueLength, err = readQueueLength(filepath.Join(dir, cache.PodQueueLengthKey+".csv")); err != nil {
+		return nil, err
+	}
+	if r.PendingDurations, err = readDurations(filepath.Join(dir, cache.PodPendingDurationKey+".csv")); err != nil {
+		return nil, err
+	}
+	if r.RunningDurations, err = readDurations(filepath.Join(dir, cache.PodRunningDurationKey+".csv")); err != nil {
+		return nil, err
+	}
+	if r.Nodes, err = readNodes(dir); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Start returns the time of the first record of the results.
+func (r *Results) Start() time.Time {
+	var start time.Time
+	first := func(at time.Time) {
+		if start.IsZero() || at.Before(start) {
+			start = at
+		}
+	}
+	if len(r.Events) > 0 {
+		first(r.Events[0].At)
+	}
+	if len(r.QueueLength) > 0 {
+		first(r.QueueLength[0].At)
+	}
+	for _, node := range r.Nodes {
+		if len(node.Ratio) > 0 {
+			first(node.Ratio[0].At)
+		}
+		if len(node.Allocated) > 0 {
+			first(node.Allocated[0].At)
+		}
+	}
+	return start
+}
+
+func readMetadata(dir string) (*simulation.ResultsMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(dir, simulation.ResultsMetadataFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m simulation.ResultsMetadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", simulation.ResultsMetadataFile, err)
+	}
+	return &m, nil
+}
+
+// readCSV calls fn with the columns of the header and each record of the file. A missing file has no
+// record.
+func readCSV(path string, fn func(header map[string]int, record []string) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	header := make(map[string]int)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if line == 1 {
+			for i, column := range record {
+				header[column] = i
+			}
+			continue
+		}
+		if err := fn(header, record); err != nil {
+			return fmt.Errorf("%s: line %d: %w", filepath.Base(path), line, err)
+		}
+	}
+}
+
+// column returns the value of a column of the record, empty if missing.
+func column(header map[string]int, record []string, name string) string {
+	i, ok := header[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return record[i]
+}
+
+func parseTime(value string) (time.Time, error) {
+	at, err := time.ParseInLocation(cache.TimeFormat, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+	}
+	return at, nil
+}
+
+func readEvents(path string) ([]Event, error) {
+	var events []Event
+	err := readCSV(path, func(header map[string]int, record []string) error {
+		at, err := parseTime(column(header, record, "timestamp"))
+		if err != nil {
+			return err
+		}
+		events = append(events, Event{
+			At:       at,
+			Pod:      column(header, record, "pod_name"),
+			Node:     column(header, record, "node_name"),
+			Phase:    column(header, record, "phase"),
+			Type:     column(header, record, "event_type"),
+			CPU:      column(header, record, "cpu_req"),
+			Memory:   column(header, record, "mem_req"),
+			Workload: column(header, record, "workload"),
+		})
+		return nil
+	})
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	return events, err
+}
+
+func readQueueLength(path string) ([]Sample, error) {
+	var samples []Sample
+	err := readCSV(path, func(header map[string]int, record []string) error {
+		at, err := parseTime(column(header, record, "timestamp"))
+		if err != nil {
+			return err
+		}
+		length, err := strconv.ParseFloat(column(header, record, "length"), 64)
+		if err != nil {
+			return fmt.Errorf("invalid length: %w", err)
+		}
+		samples = append(samples, Sample{At: at, Value: length})
+		return nil
+	})
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].At.Before(samples[j].At) })
+	return samples, err
+}
+
+// readDurations reads the durations, in milliseconds, of a pod durations file.
+func readDurations(path string) ([]time.Duration, error) {
+	var durations []time.Duration
+	err := readCSV(path, func(header map[string]int, record []string) error {
+		// the last column is the duration, pending_time_milliseconds or running_time_milliseconds
+		ms, err := strconv.ParseInt(record[len(record)-1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %w", err)
+		}
+		durations = append(durations, time.Duration(ms)*time.Millisecond)
+		return nil
+	})
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations, err
+}
+
+// readNodes reads the allocation histories of the nodes, named <node>_allocation_history.csv and
+// <node>_allocation_ratio_history.csv.
+func readNodes(dir string) ([]NodeHistory, error) {
+	nodes := make(map[string]*NodeHistory)
+	node := func(name string) *NodeHistory {
+		if nodes[name] == nil {
+			nodes[name] = &NodeHistory{Name: name}
+		}
+		return nodes[name]
+	}
+
+	for _, key := range []string{cache.AllocationHistoryKey, cache.AllocationRatioHistoryKey} {
+		suffix := "_" + key + ".csv"
+		paths, err := filepath.Glob(filepath.Join(dir, "*"+suffix))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			samples, err := readResources(path)
+			if err != nil {
+				return nil, err
+			}
+			n := node(strings.TrimSuffix(filepath.Base(path), suffix))
+			if key == cache.AllocationHistoryKey {
+				n.Allocated = samples
+			} else {
+				n.Ratio = samples
+			}
+		}
+	}
+
+	histories := make([]NodeHistory, 0, len(nodes))
+	for _, n := range nodes {
+		histories = append(histories, *n)
+	}
+	sort.Slice(histories, func(i, j int) bool { return histories[i].Name < histories[j].Name })
+	return histories, nil
+}
+
+// readResources reads a node history file, a timestamp column followed by one column per resource.
+func readResources(path string) ([]ResourceSample, error) {
+	var samples []ResourceSample
+	err := readCSV(path, func(header map[string]int, record []string) error {
+		at, err := parseTime(column(header, record, "timestamp"))
+		if err != nil {
+			return err
+		}
+		sample := ResourceSample{At: at, Values: make(map[string]float64, len(header)-1)}
+		for name, i := range header {
+			if name == "timestamp" || i >= len(record) {
+				continue
+			}
+			value, err := strconv.ParseFloat(record[i], 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			sample.Values[name] = value
+		}
+		samples = append(samples, sample)
+		return nil
+	})
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].At.Before(samples[j].At) })
+	return samples, err
+}
@@ -10,6 +10,8 @@ var (
 	ErrSchedulerNotStarted     = errors.New("scheduler not started")
 	ErrSchedulerAlreadyStarted = errors.New("scheduler already started")
 	ErrSchedulerStopped        = errors.New("scheduler stopped")
+	ErrSchedulerPaused         = errors.New("scheduler already paused")
+	ErrSchedulerNotPaused      = errors.New("scheduler not paused")
 	ErrInvalidEvent            = errors.New("invalid event")
 	ErrEventNotFound           = errors.New("event not found")
 	ErrQueueEmpty              = errors.New("queue is empty")
@@ -24,23 +24,37 @@ type Scheduler interface {
 	Schedule(event SchedulableEvent) error
 	// GetEvents returns all events currently in the queue
 	GetEvents() []SchedulableEvent
-	// StartedAt returns the time when the scheduler was started
+	// StartedAt returns the time when the scheduler was started, shifted by the time it spent paused
 	StartedAt() time.Time
 	ResetStartTime() error
+	// Pause stops the event clock: no event is executed until Resume
+	Pause() error
+	// Resume restarts the event clock where Pause stopped it
+	Resume() error
+	// Paused reports whether the event clock is stopped
+	Paused() bool
+	// Go runs fn in the background with a context done when the scheduler stops. Stop waits for it.
+	Go(fn func(ctx context.Context))
+	// Metrics returns the event counters of the scheduler
+	Metrics() *Metrics
 }
 
 // scheduler is the main implementation of the Scheduler interface
 type scheduler struct {
 	logger    *logger.Logger
 	queue     *Queue[SchedulableEvent]
+	metrics   *Metrics
 	startTime time.Time
-	running   bool
-	mu        sync.RWMutex
+	// pausedAt is when the scheduler was paused, zero while it runs
+	pausedAt time.Time
+	running  bool
+	mu       sync.RWMutex
 
 	// Lifecycle management
-	ctx    context.Context
-	cancel context.CancelFunc
-	done   chan struct{}
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+	background sync.WaitGroup
 }
 
 // New creates a new scheduler
@@ -50,9 +64,10 @@ func New(log *logger.Logger) Scheduler {
 	}
 
 	return &scheduler{
-		logger: log,
-		queue:  NewQueue[SchedulableEvent](),
-		done:   make(chan struct{}),
+		logger:  log,
+		queue:   NewQueue[SchedulableEvent](),
+		metrics: NewMetrics(),
+		done:    make(chan struct{}),
 	}
 }
 
@@ -67,6 +82,7 @@ func (s *scheduler) Start(ctx context.Context) error {
 
 	s.logger.Info("starting scheduler")
 	s.startTime = time.Now()
+	s.pausedAt = time.Time{}
 	s.running = true
 
 	// Create cancellable context
@@ -89,6 +105,9 @@ func (s *scheduler) ResetStartTime() error {
 	}
 
 	s.startTime = time.Now()
+	if !s.pausedAt.IsZero() {
+		s.pausedAt = s.startTime
+	}
 	s.logger.Infof("scheduler start time reset to %v", s.startTime)
 	return nil
 }
@@ -96,9 +115,8 @@ func (s *scheduler) ResetStartTime() error {
 // Stop gracefully stops the scheduler
 func (s *scheduler) Stop() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.running {
+		s.mu.Unlock()
 		return ErrSchedulerNotStarted
 	}
 
@@ -109,9 +127,11 @@ func (s *scheduler) Stop() error {
 	if s.cancel != nil {
 		s.cancel()
 	}
+	s.mu.Unlock()
 
-	// Wait for scheduler loop to finish
+	// Wait for scheduler loop and background functions to finish, without the lock: they may call StartedAt
 	<-s.done
+	s.background.Wait()
 
 	s.logger.Info("scheduler stopped")
 	return nil
@@ -127,6 +147,8 @@ func (s *scheduler) Schedule(event SchedulableEvent) error {
 		s.logger.Errorf("failed to schedule event %s: %v", event.GetID(), err)
 		return err
 	}
+	s.metrics.EventsScheduled.Inc()
+	s.updateQueueSize()
 
 	s.logger.Debugf("event scheduled: %s (arrival: %v)",
 		event.GetID(), event.Arrival())
@@ -139,13 +161,90 @@ func (s *scheduler) GetEvents() []SchedulableEvent {
 	return s.queue.GetEvents()
 }
 
-// StartedAt returns the time when the scheduler was started
+// Metrics returns the event counters of the scheduler.
+func (s *scheduler) Metrics() *Metrics {
+	return s.metrics
+}
+
+// updateQueueSize records the size of the queue and its maximum.
+func (s *scheduler) updateQueueSize() {
+	size := int64(s.queue.Size())
+	s.metrics.QueueSize.Set(size)
+	if size > s.metrics.MaxQueueSize.Value() {
+		s.metrics.MaxQueueSize.Set(size)
+	}
+}
+
+// StartedAt returns the time when the scheduler was started, shifted by the time it spent paused: the
+// time elapsed since is the event clock.
 func (s *scheduler) StartedAt() time.Time {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	if !s.pausedAt.IsZero() {
+		return s.startTime.Add(time.Since(s.pausedAt))
+	}
 	return s.startTime
 }
 
+// Pause stops the event clock: no event is executed until Resume. The events being executed complete.
+func (s *scheduler) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return ErrSchedulerNotStarted
+	}
+	if !s.pausedAt.IsZero() {
+		return ErrSchedulerPaused
+	}
+	s.pausedAt = time.Now()
+	s.logger.Info("scheduler paused")
+	return nil
+}
+
+// Resume restarts the event clock where Pause stopped it, delaying the pending events by the pause.
+func (s *scheduler) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return ErrSchedulerNotStarted
+	}
+	if s.pausedAt.IsZero() {
+		return ErrSchedulerNotPaused
+	}
+	paused := time.Since(s.pausedAt)
+	s.startTime = s.startTime.Add(paused)
+	s.pausedAt = time.Time{}
+	s.logger.Infof("scheduler resumed after %v", paused)
+	return nil
+}
+
+// Paused reports whether the event clock is stopped.
+func (s *scheduler) Paused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.pausedAt.IsZero()
+}
+
+// Go runs fn in the background until the scheduler stops. The context of fn carries the scheduler, like
+// the one of executed events. fn is not run if the scheduler is not running.
+func (s *scheduler) Go(fn func(ctx context.Context)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.running {
+		s.logger.Warn("scheduler not running, background function dropped")
+		return
+	}
+
+	ctx := context.WithValue(s.ctx, SchedulerContextKey, s)
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		fn(ctx)
+	}()
+}
+
 // schedulerLoop is the main event processing loop
 func (s *scheduler) schedulerLoop() {
 	defer close(s.done)
@@ -165,7 +264,10 @@ func (s *scheduler) schedulerLoop() {
 
 // processReadyEvents checks for and processes events that are ready to execute
 func (s *scheduler) processReadyEvents() {
-	now := time.Since(s.startTime)
+	if s.Paused() {
+		return
+	}
+	now := time.Since(s.StartedAt())
 
 	for {
 		event, err := s.queue.Peek()
@@ -184,6 +286,7 @@ func (s *scheduler) processReadyEvents() {
 		if err != nil {
 			break
 		}
+		s.updateQueueSize()
 		s.executeEvent(event)
 	}
 }
@@ -198,11 +301,18 @@ func (s *scheduler) executeEvent(event SchedulableEvent) {
 	defer cancel()
 
 	// Execute the event
-	if err := event.Execute(ctx); err != nil {
+	start := time.Now()
+	err := event.Execute(ctx)
+	s.metrics.ExecutionDuration.Observe(time.Since(start).Seconds())
+	s.metrics.EventsExecuted.Inc()
+	s.metrics.UpdateLastEventTime()
+	if err != nil {
 		event.SetStatus(EventStatusFailed)
+		s.metrics.EventsFailed.Inc()
 		s.logger.Errorf("event execution failed: %s - %v", event.GetID(), err)
 	} else {
 		event.SetStatus(EventStatusCompleted)
+		s.metrics.EventsCompleted.Inc()
 		s.logger.Debugf("event executed successfully: %s", event.GetID())
 	}
 }
@@ -146,3 +146,60 @@ func TestEventTimeout(t *testing.T) {
 	event.SetExecuteTimeout(customTimeout)
 	assert.Equal(t, customTimeout, event.GetExecuteTimeout())
 }
+
+func TestSchedulerPauseResume(t *testing.T) {
+	scheduler := New(logger.Default())
+	assert.ErrorIs(t, scheduler.Pause(), ErrSchedulerNotStarted)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, scheduler.Start(ctx))
+	defer func() { _ = scheduler.Stop() }()
+
+	event := NewBaseEvent(200*time.Millisecond, 0)
+	require.NoError(t, scheduler.Schedule(event))
+
+	require.NoError(t, scheduler.Pause())
+	assert.True(t, scheduler.Paused())
+	assert.ErrorIs(t, scheduler.Pause(), ErrSchedulerPaused)
+
+	// the event clock is stopped while paused
+	time.Sleep(400 * time.Millisecond)
+	assert.Equal(t, EventStatusPending, event.GetStatus())
+	assert.Less(t, time.Since(scheduler.StartedAt()), 100*time.Millisecond)
+
+	require.NoError(t, scheduler.Resume())
+	assert.False(t, scheduler.Paused())
+	assert.ErrorIs(t, scheduler.Resume(), ErrSchedulerNotPaused)
+
+	assert.Eventually(t, func() bool { return event.GetStatus() == EventStatusCompleted }, time.Second, 20*time.Millisecond)
+}
+
+// failingEvent is an event whose execution fails.
+type failingEvent struct {
+	*BaseEvent
+}
+
+func (e *failingEvent) Execute(ctx context.Context) error {
+	return assert.AnError
+}
+
+func TestSchedulerMetrics(t *testing.T) {
+	scheduler := New(logger.Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, scheduler.Start(ctx))
+	defer func() { _ = scheduler.Stop() }()
+
+	require.NoError(t, scheduler.Schedule(NewBaseEvent(0, 0)))
+	require.NoError(t, scheduler.Schedule(&failingEvent{BaseEvent: NewBaseEvent(0, 0)}))
+	require.NoError(t, scheduler.Schedule(NewBaseEvent(time.Hour, 0)))
+
+	metrics := scheduler.Metrics()
+	assert.Equal(t, int64(3), metrics.EventsScheduled.Value())
+	assert.Equal(t, int64(3), metrics.MaxQueueSize.Value())
+	assert.Eventually(t, func() bool { return metrics.EventsExecuted.Value() == 2 }, time.Second, 20*time.Millisecond)
+	assert.Equal(t, int64(1), metrics.EventsCompleted.Value())
+	assert.Equal(t, int64(1), metrics.EventsFailed.Value())
+	assert.Equal(t, int64(1), metrics.QueueSize.Value())
+}
@@ -0,0 +1,320 @@
+package server
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+)
+
+// maxScenarioSize bounds the size of a submitted scenario.
+const maxScenarioSize = 10 << 20
+
+// apiPrefix is the path prefix of the API routes.
+const apiPrefix = "/api/v1/simulations"
+
+// StatsResponse is the live stats of a run.
+type StatsResponse struct {
+	ID    string `json:"id"`
+	State State  `json:"state"`
+	// Summary has the columns of the summary.csv of sweeps, durations in seconds
+	Summary map[string]float64 `json:"summary"`
+	// PendingPods are the names of the pods in the pending queue
+	PendingPods []string `json:"pendingPods"`
+	// Schedulers break the pods down by scheduler
+	Schedulers []SchedulerStats `json:"schedulers"`
+}
+
+// SchedulerStats is the live placement of the pods of a scheduler, durations in seconds.
+type SchedulerStats struct {
+	Scheduler     string  `json:"scheduler"`
+	Pods          int     `json:"pods"`
+	Scheduled     int     `json:"scheduled"`
+	Pending       int     `json:"pending"`
+	Unschedulable int     `json:"unschedulable"`
+	Nodes         int     `json:"nodes"`
+	PendingMean   float64 `json:"pendingMean"`
+	PendingP95    float64 `json:"pendingP95"`
+}
+
+// errorResponse is the body of the error responses.
+type errorResponse struct {
+	Error       string                 `json:"error"`
+	Diagnostics simulation.Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// Handler returns the HTTP handler of the server API:
+//
+//	POST /api/v1/simulations                      submit a YAML scenario (?set=name=value&seed=N)
+//	GET  /api/v1/simulations                      list the runs
+//	GET  /api/v1/simulations/{id}                 status of a run
+//	GET  /api/v1/simulations/{id}/stats           live stats of a running run
+//	POST /api/v1/simulations/{id}/pause           pause a running run
+//	POST /api/v1/simulations/{id}/resume          resume a paused run
+//	POST /api/v1/simulations/{id}/stop            stop a queued or running run
+//	GET  /api/v1/simulations/{id}/results         zip of the results of an ended run
+//	GET  /api/v1/simulations/{id}/results/{file}  a single results file, e.g. schedulers.csv
+//	GET  /healthz                                 liveness
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+apiPrefix, s.handleSubmit)
+	mux.HandleFunc("GET "+apiPrefix, s.handleList)
+	mux.HandleFunc("GET "+apiPrefix+"/{id}", s.handleGet)
+	mux.HandleFunc("GET "+apiPrefix+"/{id}/stats", s.handleStats)
+	mux.HandleFunc("POST "+apiPrefix+"/{id}/pause", s.handlePause)
+	mux.HandleFunc("POST "+apiPrefix+"/{id}/resume", s.handleResume)
+	mux.HandleFunc("POST "+apiPrefix+"/{id}/stop", s.handleStop)
+	mux.HandleFunc("GET "+apiPrefix+"/{id}/results", s.handleResults)
+	mux.HandleFunc("GET "+apiPrefix+"/{id}/results/{file}", s.handleResultsFile)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return mux
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxScenarioSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(data) > maxScenarioSize {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("scenario exceeds %d bytes", maxScenarioSize))
+		return
+	}
+
+	query := r.URL.Query()
+	params, err := simulation.ParseParamOverrides(query["set"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sub := Submission{Scenario: data, Params: params}
+	if value := query.Get("seed"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid seed %q: %w", value, err))
+			return
+		}
+		sub.Seed = &seed
+	}
+
+	status, err := s.Submit(sub)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	w.Header().Set("Location", apiPrefix+"/"+status.ID)
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.List())
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	run, err := s.run(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	sim, ok := run.simulation()
+	if !ok {
+		status := run.status()
+		writeError(w, http.StatusConflict, fmt.Errorf("%w: run %s is %s, the stats of ended runs are in its results", ErrRunState, status.ID, status.State))
+		return
+	}
+
+	response := StatsResponse{ID: run.id, State: run.status().State}
+	sim.ReadStats(func(stats *cache.Stats) {
+		response.Summary = summaryValues(stats.Summarize())
+		response.PendingPods = make([]string, 0, len(stats.PendingQ))
+		for key := range stats.PendingQ {
+			response.PendingPods = append(response.PendingPods, key.GetName())
+		}
+		sort.Strings(response.PendingPods)
+		for _, summary := range stats.SummarizeBySchedulers() {
+			response.Schedulers = append(response.Schedulers, SchedulerStats{
+				Scheduler:     summary.Scheduler,
+				Pods:          summary.Pods,
+				Scheduled:     summary.Scheduled,
+				Pending:       summary.Pending,
+				Unschedulable: summary.Unschedulable,
+				Nodes:         summary.Nodes,
+				PendingMean:   summary.Mean.Seconds(),
+				PendingP95:    summary.P95.Seconds(),
+			})
+		}
+	})
+	writeJSON(w, http.StatusOK, response)
+}
+
+// summaryValues returns the summary as the columns of summary.csv.
+func summaryValues(summary cache.Summary) map[string]float64 {
+	values := make(map[string]float64)
+	row := summary.Row()
+	for i, column := range cache.SummaryHeader() {
+		if value, err := strconv.ParseFloat(row[i], 64); err == nil {
+			values[column] = value
+		}
+	}
+	return values
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Pause(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Resume(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Stop(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// endedResults returns the results directory of an ended run.
+func (s *Server) endedResults(id string) (string, error) {
+	run, err := s.run(id)
+	if err != nil {
+		return "", err
+	}
+	status := run.status()
+	if !status.State.Ended() {
+		return "", fmt.Errorf("%w: run %s is %s, its results are written when it ends", ErrRunState, id, status.State)
+	}
+	if _, err := os.Stat(run.dir); err != nil {
+		return "", fmt.Errorf("%w: run %s has no results", ErrRunNotFound, id)
+	}
+	return run.dir, nil
+}
+
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	dir, err := s.endedResults(id)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".zip"))
+	archive := zip.NewWriter(w)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := addFile(archive, filepath.Join(dir, entry.Name())); err != nil {
+			s.logger.Errorf("run %s: failed to archive %s: %v", id, entry.Name(), err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		s.logger.Errorf("run %s: failed to archive the results: %v", id, err)
+	}
+}
+
+func addFile(archive *zip.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: filepath.Base(path), Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, f)
+	return err
+}
+
+func (s *Server) handleResultsFile(w http.ResponseWriter, r *http.Request) {
+	dir, err := s.endedResults(r.PathValue("id"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	name := r.PathValue("file")
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid file name %q", name))
+		return
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: no results file %s", ErrRunNotFound, name))
+		return
+	}
+	http.ServeFile(w, r, path)
+}
+
+// statusCode maps the errors of the server to HTTP status codes.
+func statusCode(err error) int {
+	var invalid *InvalidScenarioError
+	switch {
+	case errors.As(err, &invalid), errors.Is(err, ErrSchedulerEvents):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrRunNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrRunState):
+		return http.StatusConflict
+	case errors.Is(err, ErrShutdown):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	response := errorResponse{Error: err.Error()}
+	var invalid *InvalidScenarioError
+	if errors.As(err, &invalid) {
+		response.Diagnostics = invalid.Diagnostics
+	}
+	writeJSON(w, code, response)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+)
+
+// State is the state of a run.
+type State string
+
+const (
+	// StateQueued runs wait for a free slot
+	StateQueued State = "queued"
+	// StateRunning runs are dispatching their events
+	StateRunning State = "running"
+	// StatePaused runs are running with their event clock stopped
+	StatePaused State = "paused"
+	// StateSucceeded runs ended on an end condition and their results are written
+	StateSucceeded State = "succeeded"
+	// StateFailed runs ended on an error
+	StateFailed State = "failed"
+	// StateStopped runs were stopped before they ended on their own
+	StateStopped State = "stopped"
+)
+
+// Ended reports whether the run is over.
+func (s State) Ended() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateStopped
+}
+
+// RunStatus describes a run.
+type RunStatus struct {
+	ID       string `json:"id"`
+	Scenario string `json:"scenario"`
+	State    State  `json:"state"`
+	// SimulationID is the ID of the simulation of the run, once started
+	SimulationID string `json:"simulationId,omitempty"`
+	// Namespace is the namespace of the pods of the simulation, once started
+	Namespace   string            `json:"namespace,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	Seed        int64             `json:"seed"`
+	SubmittedAt time.Time         `json:"submittedAt"`
+	StartedAt   *time.Time        `json:"startedAt,omitempty"`
+	EndedAt     *time.Time        `json:"endedAt,omitempty"`
+	// EndReason tells why the simulation ended
+	EndReason simulation.EndReason `json:"endReason,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// run is a submitted scenario and its simulation.
+type run struct {
+	id       string
+	scenario *simulation.Scenario
+	params   map[string]string
+	seed     int64
+	dir      string
+	// ctx is done when the run is stopped or ends
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu          sync.Mutex
+	state       State
+	sim         simulation.Simulation
+	stopReq     bool
+	submittedAt time.Time
+	startedAt   time.Time
+	endedAt     time.Time
+	endReason   simulation.EndReason
+	err         error
+}
+
+func newRun(id string, scenario *simulation.Scenario, params map[string]string, dir string) *run {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &run{
+		id:          id,
+		scenario:    scenario,
+		params:      params,
+		seed:        scenario.EffectiveSeed(),
+		dir:         dir,
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+		state:       StateQueued,
+		submittedAt: time.Now(),
+	}
+}
+
+// setRunning records the simulation of the run. It returns false if the run was stopped meanwhile.
+func (r *run) setRunning(sim simulation.Simulation) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopReq {
+		return false
+	}
+	r.state = StateRunning
+	r.sim = sim
+	r.startedAt = time.Now()
+	return true
+}
+
+func (r *run) setEnded(state State, reason simulation.EndReason, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = state
+	r.endReason = reason
+	r.err = err
+	r.endedAt = time.Now()
+}
+
+// simulation returns the simulation of a running run.
+func (r *run) simulation() (simulation.Simulation, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state != StateRunning {
+		return nil, false
+	}
+	return r.sim, true
+}
+
+// stop asks the run to stop: a queued run never starts, the simulation of a running one ends.
+func (r *run) stop() {
+	r.mu.Lock()
+	if !r.state.Ended() {
+		r.stopReq = true
+	}
+	r.mu.Unlock()
+	r.cancel()
+}
+
+func (r *run) stopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopReq
+}
+
+func (r *run) status() RunStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := RunStatus{
+		ID:          r.id,
+		Scenario:    r.scenario.Metadata.Name,
+		State:       r.state,
+		Params:      r.params,
+		Seed:        r.seed,
+		SubmittedAt: r.submittedAt,
+		EndReason:   r.endReason,
+	}
+	if r.sim != nil {
+		status.SimulationID = r.sim.GetID()
+		status.Namespace = r.sim.Namespace()
+		if r.state == StateRunning && r.sim.Paused() {
+			status.State = StatePaused
+		}
+	}
+	if !r.startedAt.IsZero() {
+		startedAt := r.startedAt
+		status.StartedAt = &startedAt
+	}
+	if !r.endedAt.IsZero() {
+		endedAt := r.endedAt
+		status.EndedAt = &endedAt
+	}
+	if r.err != nil {
+		status.Error = r.err.Error()
+	}
+	return status
+}
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// ErrRunNotFound is returned for an unknown run ID
+	ErrRunNotFound = errors.New("run not found")
+	// ErrRunState is returned when a run is not in a state allowing the operation
+	ErrRunState = errors.New("invalid run state")
+	// ErrShutdown is returned for submissions after Shutdown
+	ErrShutdown = errors.New("server is shutting down")
+	// ErrSchedulerEvents is returned for scenarios with scheduler events when concurrent runs share a scheduler
+	ErrSchedulerEvents = errors.New("scheduler events are not allowed in concurrent runs sharing a scheduler")
+)
+
+// cleanupTimeout bounds the cleanup of a run once it ends.
+const cleanupTimeout = time.Minute
+
+// Cluster is the cluster a run executes against.
+type Cluster struct {
+	Clientset kubernetes.Interface
+	Manager   kube.SchedulerManager
+}
+
+// ClusterFunc returns the cluster to run a scenario against. ctx is done once the run ends, releasing
+// what the cluster started, e.g. an offline cluster.
+type ClusterFunc func(ctx context.Context, scenario *simulation.Scenario) (Cluster, error)
+
+// Server runs the scenarios submitted to it and keeps their status and results.
+type Server struct {
+	logger      *logger.Logger
+	cluster     ClusterFunc
+	resultsDir  string
+	concurrency int
+	cleanup     bool
+	simOpts     []simulation.SimulationOpt
+	// sharedScheduler tells that the runs share the scheduler of a cluster
+	sharedScheduler bool
+
+	mu     sync.Mutex
+	runs   map[string]*run
+	closed bool
+	slots  chan struct{}
+	wg     sync.WaitGroup
+}
+
+// ServerOpt configures a Server.
+type ServerOpt func(*Server)
+
+// WithResultsDir sets the directory where the results of each run are written, in a subdirectory named
+// after the run ID. Defaults to results/serve.
+func WithResultsDir(dir string) ServerOpt {
+	return func(s *Server) {
+		s.resultsDir = dir
+	}
+}
+
+// WithConcurrency sets how many runs execute at once, 1 by default. The other runs wait in submission
+// order. Concurrent runs against the same cluster should run in isolated namespaces.
+func WithConcurrency(n int) ServerOpt {
+	return func(s *Server) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
+}
+
+// WithCleanup deletes the objects created by each run once it ends.
+func WithCleanup() ServerOpt {
+	return func(s *Server) {
+		s.cleanup = true
+	}
+}
+
+// WithSharedScheduler tells that the runs share the scheduler of a cluster. With a concurrency above 1,
+// scenarios with scheduler events are refused: the weights they set would apply to the other runs, and
+// restoring the scheduler configuration of a stopped run would undo the weights set by the others.
+func WithSharedScheduler() ServerOpt {
+	return func(s *Server) {
+		s.sharedScheduler = true
+	}
+}
+
+// WithSimulationOpts sets options applied to the simulation of every run.
+func WithSimulationOpts(opts ...simulation.SimulationOpt) ServerOpt {
+	return func(s *Server) {
+		s.simOpts = append(s.simOpts, opts...)
+	}
+}
+
+// NewServer creates a server running scenarios against the clusters returned by cluster.
+func NewServer(log *logger.Logger, cluster ClusterFunc, opts ...ServerOpt) *Server {
+	s := &Server{
+		logger:      log,
+		cluster:     cluster,
+		resultsDir:  "results/serve",
+		concurrency: 1,
+		runs:        make(map[string]*run),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.slots = make(chan struct{}, s.concurrency)
+	return s
+}
+
+// Submission is a scenario submitted to the server.
+type Submission struct {
+	// Scenario is the YAML scenario. Imports are refused, as they would read files of the server.
+	Scenario []byte
+	// Params override the scenario parameters
+	Params map[string]string
+	// Seed overrides the seed of the scenario distributions
+	Seed *int64
+}
+
+// InvalidScenarioError is returned for a submitted scenario with validation errors.
+type InvalidScenarioError struct {
+	Diagnostics simulation.Diagnostics
+}
+
+func (e *InvalidScenarioError) Error() string {
+	return fmt.Sprintf("scenario is invalid: %d error(s)", e.Diagnostics.Count(simulation.SeverityError))
+}
+
+// Submit validates a scenario and queues a run of it.
+func (s *Server) Submit(sub Submission) (RunStatus, error) {
+	loadOpts := []simulation.LoadOpt{simulation.WithParams(sub.Params), simulation.WithoutImports()}
+	diags := simulation.Validate(sub.Scenario, simulation.WithLoadOpts(loadOpts...))
+	if diags.HasErrors() {
+		return RunStatus{}, &InvalidScenarioError{Diagnostics: diags}
+	}
+	scenario, err := simulation.Load(sub.Scenario, loadOpts...)
+	if err != nil {
+		return RunStatus{}, err
+	}
+	if sub.Seed != nil {
+		scenario.Seed = sub.Seed
+	}
+	if s.sharedScheduler && s.concurrency > 1 && len(scenario.Events.Scheduler) > 0 {
+		return RunStatus{}, ErrSchedulerEvents
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return RunStatus{}, ErrShutdown
+	}
+	id := uuid.NewString()[:8]
+	for s.runs[id] != nil {
+		id = uuid.NewString()[:8]
+	}
+	r := newRun(id, scenario, sub.Params, filepath.Join(s.resultsDir, id))
+	s.runs[id] = r
+	s.wg.Add(1)
+	go s.execute(r)
+
+	s.logger.Infof("run %s of scenario %s queued", id, scenario.Metadata.Name)
+	return r.status(), nil
+}
+
+// Get returns the status of a run.
+func (s *Server) Get(id string) (RunStatus, error) {
+	r, err := s.run(id)
+	if err != nil {
+		return RunStatus{}, err
+	}
+	return r.status(), nil
+}
+
+// List returns the status of all the runs, in submission order.
+func (s *Server) List() []RunStatus {
+	s.mu.Lock()
+	statuses := make([]RunStatus, 0, len(s.runs))
+	for _, r := range s.runs {
+		statuses = append(statuses, r.status())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if !statuses[i].SubmittedAt.Equal(statuses[j].SubmittedAt) {
+			return statuses[i].SubmittedAt.Before(statuses[j].SubmittedAt)
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+// Pause pauses a running run.
+func (s *Server) Pause(id string) (RunStatus, error) {
+	r, err := s.run(id)
+	if err != nil {
+		return RunStatus{}, err
+	}
+	sim, ok := r.simulation()
+	if !ok {
+		return r.status(), fmt.Errorf("%w: run %s is %s", ErrRunState, id, r.status().State)
+	}
+	if err := sim.Pause(); err != nil {
+		return r.status(), fmt.Errorf("%w: %v", ErrRunState, err)
+	}
+	s.logger.Infof("run %s paused", id)
+	return r.status(), nil
+}
+
+// Resume resumes a paused run.
+func (s *Server) Resume(id string) (RunStatus, error) {
+	r, err := s.run(id)
+	if err != nil {
+		return RunStatus{}, err
+	}
+	sim, ok := r.simulation()
+	if !ok {
+		return r.status(), fmt.Errorf("%w: run %s is %s", ErrRunState, id, r.status().State)
+	}
+	if err := sim.Resume(); err != nil {
+		return r.status(), fmt.Errorf("%w: %v", ErrRunState, err)
+	}
+	s.logger.Infof("run %s resumed", id)
+	return r.status(), nil
+}
+
+// Stop stops a queued or running run, and waits for it to end or for the context to be done. Stopping
+// an ended run does nothing.
+func (s *Server) Stop(ctx context.Context, id string) (RunStatus, error) {
+	r, err := s.run(id)
+	if err != nil {
+		return RunStatus{}, err
+	}
+	r.stop()
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return r.status(), ctx.Err()
+	}
+	return r.status(), nil
+}
+
+// Shutdown refuses new submissions, stops all the runs and waits for them to end, or for the context
+// to be done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for _, r := range s.runs {
+		r.stop()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) run(id string) (*run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRunNotFound, id)
+	}
+	return r, nil
+}
+
+// execute waits for a free slot, then runs the simulation of r and writes its results.
+func (s *Server) execute(r *run) {
+	defer s.wg.Done()
+	defer close(r.done)
+	// releases the cluster of the run
+	defer r.cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-r.ctx.Done():
+		r.setEnded(StateStopped, "", nil)
+		return
+	}
+
+	cluster, err := s.cluster(r.ctx, r.scenario)
+	if err != nil {
+		s.logger.Errorf("run %s: failed to get a cluster: %v", r.id, err)
+		r.setEnded(StateFailed, "", err)
+		return
+	}
+	sim := simulation.NewSimulation(r.scenario, cluster.Clientset, cluster.Manager, s.logger, s.simOpts...)
+	if !r.setRunning(sim) {
+		r.setEnded(StateStopped, "", nil)
+		return
+	}
+	s.logger.Infof("run %s started simulation %s", r.id, sim.GetID())
+
+	runErr := sim.Start(r.ctx)
+	stopped := r.stopped()
+
+	// the cleanup runs even if the run was stopped
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	var errs []error
+	if s.cleanup {
+		errs = append(errs, sim.DeleteCreated(cleanupCtx))
+	}
+	if stopped {
+		errs = append(errs, sim.RestoreScheduler(cleanupCtx))
+	}
+	if runErr == nil {
+		errs = append(errs, s.saveResults(r, sim))
+	}
+	err = errors.Join(append([]error{runErr}, errs...)...)
+
+	switch {
+	case stopped:
+		// the simulation of a stopped run ends on its context
+		r.setEnded(StateStopped, simulation.EndStopped, err)
+	case err != nil:
+		r.setEnded(StateFailed, sim.EndReason(), err)
+	default:
+		r.setEnded(StateSucceeded, sim.EndReason(), nil)
+	}
+	if err != nil {
+		s.logger.Errorf("run %s ended: %v", r.id, err)
+	} else {
+		s.logger.Infof("run %s ended: %s", r.id, sim.EndReason())
+	}
+}
+
+// saveResults exports the stats and the results metadata of the simulation of a run.
+func (s *Server) saveResults(r *run, sim simulation.Simulation) error {
+	stats := sim.GetStats()
+	if stats == nil {
+		return fmt.Errorf("simulation %s has no stats", sim.GetID())
+	}
+	if err := stats.ExportCSV(r.dir); err != nil {
+		return err
+	}
+	return simulation.WriteResultsMetadata(r.dir, simulation.NewResultsMetadata(sim, r.scenario, ""))
+}
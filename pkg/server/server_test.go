@@ -0,0 +1,297 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scenarioYaml = `
+metadata:
+  name: serve
+params:
+  evict:
+    type: duration
+    default: 200ms
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "4", memory: 8Gi, pods: "10" }
+templates:
+  web:
+    metadata:
+      name: web
+    spec:
+      containers:
+        - name: main
+          image: nginx
+          resources:
+            requests: { cpu: 500m, memory: 256Mi }
+events:
+  pods:
+    - name: web
+      template: web
+      evictTime: ${evict}
+      replicas: 2
+      spacing: 50ms
+`
+
+// offlineCluster runs each scenario against its own offline cluster.
+func offlineCluster(ctx context.Context, scenario *simulation.Scenario) (Cluster, error) {
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	if err != nil {
+		return Cluster{}, err
+	}
+	cluster.Start(ctx)
+	return Cluster{Clientset: cluster.Clientset(), Manager: cluster.Scheduler()}, nil
+}
+
+func newTestServer(t *testing.T, opts ...ServerOpt) *httptest.Server {
+	srv := NewServer(logger.Default(), offlineCluster, append([]ServerOpt{WithResultsDir(t.TempDir())}, opts...)...)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		assert.NoError(t, srv.Shutdown(ctx))
+		ts.Close()
+	})
+	return ts
+}
+
+func submit(t *testing.T, ts *httptest.Server, query string, scenario string) RunStatus {
+	resp, err := http.Post(ts.URL+apiPrefix+query, "application/yaml", strings.NewReader(scenario))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var status RunStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, apiPrefix+"/"+status.ID, resp.Header.Get("Location"))
+	return status
+}
+
+func post(t *testing.T, ts *httptest.Server, path string) (int, RunStatus) {
+	resp, err := http.Post(ts.URL+path, "", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var status RunStatus
+	_ = json.NewDecoder(resp.Body).Decode(&status)
+	return resp.StatusCode, status
+}
+
+func get(t *testing.T, ts *httptest.Server, path string) (int, []byte) {
+	resp, err := http.Get(ts.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, body
+}
+
+// waitState polls the status of a run until it is in one of the states.
+func waitState(t *testing.T, ts *httptest.Server, id string, states ...State) RunStatus {
+	var status RunStatus
+	require.Eventually(t, func() bool {
+		code, body := get(t, ts, apiPrefix+"/"+id)
+		require.Equal(t, http.StatusOK, code)
+		require.NoError(t, json.Unmarshal(body, &status))
+		for _, state := range states {
+			if status.State == state {
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 20*time.Millisecond, "run %s never reached %v", id, states)
+	return status
+}
+
+func TestServer_Run(t *testing.T) {
+	ts := newTestServer(t)
+
+	status := submit(t, ts, "?set=evict=100ms&seed=7", scenarioYaml)
+	assert.Equal(t, "serve", status.Scenario)
+	assert.Equal(t, map[string]string{"evict": "100ms"}, status.Params)
+	assert.Equal(t, int64(7), status.Seed)
+
+	status = waitState(t, ts, status.ID, StateSucceeded, StateFailed)
+	require.Equal(t, StateSucceeded, status.State, status.Error)
+	assert.Equal(t, simulation.EndAllEvicted, status.EndReason)
+	assert.NotEmpty(t, status.SimulationID)
+	assert.NotNil(t, status.StartedAt)
+	assert.NotNil(t, status.EndedAt)
+
+	code, body := get(t, ts, apiPrefix)
+	require.Equal(t, http.StatusOK, code)
+	var list []RunStatus
+	require.NoError(t, json.Unmarshal(body, &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, status.ID, list[0].ID)
+
+	code, body = get(t, ts, apiPrefix+"/"+status.ID+"/results")
+	require.Equal(t, http.StatusOK, code)
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "schedulers.csv")
+	assert.Contains(t, names, simulation.ResultsMetadataFile)
+
+	code, body = get(t, ts, apiPrefix+"/"+status.ID+"/results/schedulers.csv")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, strings.HasPrefix(string(body), "scheduler,"), string(body))
+
+	code, _ = get(t, ts, apiPrefix+"/"+status.ID+"/results/missing.csv")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get(t, ts, apiPrefix+"/"+status.ID+"/stats")
+	assert.Equal(t, http.StatusConflict, code, "the stats of ended runs are in its results")
+}
+
+func TestServer_PauseResumeStop(t *testing.T) {
+	ts := newTestServer(t)
+
+	status := submit(t, ts, "?set=evict=1h", scenarioYaml)
+	waitState(t, ts, status.ID, StateRunning)
+
+	code, status := post(t, ts, apiPrefix+"/"+status.ID+"/pause")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatePaused, status.State)
+	code, _ = post(t, ts, apiPrefix+"/"+status.ID+"/pause")
+	assert.Equal(t, http.StatusConflict, code, "the run is already paused")
+
+	code, status = post(t, ts, apiPrefix+"/"+status.ID+"/resume")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StateRunning, status.State)
+
+	var stats StatsResponse
+	require.Eventually(t, func() bool {
+		code, body := get(t, ts, apiPrefix+"/"+status.ID+"/stats")
+		require.Equal(t, http.StatusOK, code)
+		require.NoError(t, json.Unmarshal(body, &stats))
+		return len(stats.Schedulers) == 1 && stats.Schedulers[0].Scheduled == 2
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.Empty(t, stats.PendingPods)
+	assert.Equal(t, 2.0, stats.Summary["scheduled_pods"])
+
+	code, status = post(t, ts, apiPrefix+"/"+status.ID+"/stop")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StateStopped, status.State)
+	assert.Equal(t, simulation.EndStopped, status.EndReason)
+
+	code, _ = post(t, ts, apiPrefix+"/"+status.ID+"/resume")
+	assert.Equal(t, http.StatusConflict, code)
+}
+
+func TestServer_StopQueued(t *testing.T) {
+	ts := newTestServer(t)
+
+	first := submit(t, ts, "?set=evict=1h", scenarioYaml)
+	second := submit(t, ts, "", scenarioYaml)
+	waitState(t, ts, first.ID, StateRunning)
+	assert.Equal(t, StateQueued, waitState(t, ts, second.ID, StateQueued).State, "a single run executes at once")
+
+	code, status := post(t, ts, apiPrefix+"/"+second.ID+"/stop")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StateStopped, status.State)
+	assert.Nil(t, status.StartedAt)
+
+	code, _ = get(t, ts, apiPrefix+"/"+second.ID+"/results")
+	assert.Equal(t, http.StatusNotFound, code, "a run stopped before it started has no results")
+}
+
+func TestServer_Errors(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{name: "invalid scenario", method: http.MethodPost, path: apiPrefix, body: "metadata:\n  name: x\nevents:\n  pods:\n    - name: p\n      template: missing\n", code: http.StatusUnprocessableEntity},
+		{name: "imports", method: http.MethodPost, path: apiPrefix, body: "imports: [/etc/hostname]\n" + scenarioYaml, code: http.StatusUnprocessableEntity},
+		{name: "invalid override", method: http.MethodPost, path: apiPrefix + "?set=evict", body: scenarioYaml, code: http.StatusBadRequest},
+		{name: "invalid seed", method: http.MethodPost, path: apiPrefix + "?seed=x", body: scenarioYaml, code: http.StatusBadRequest},
+		{name: "unknown run", method: http.MethodGet, path: apiPrefix + "/unknown", code: http.StatusNotFound},
+		{name: "unknown run stats", method: http.MethodGet, path: apiPrefix + "/unknown/stats", code: http.StatusNotFound},
+		{name: "unknown run pause", method: http.MethodPost, path: apiPrefix + "/unknown/pause", code: http.StatusNotFound},
+		{name: "unknown run results", method: http.MethodGet, path: apiPrefix + "/unknown/results", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.code, resp.StatusCode)
+
+			var body errorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.NotEmpty(t, body.Error)
+			if tt.code == http.StatusUnprocessableEntity {
+				assert.True(t, body.Diagnostics.HasErrors(), fmt.Sprint(body.Diagnostics))
+			}
+		})
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	srv := NewServer(logger.Default(), offlineCluster, WithResultsDir(t.TempDir()))
+	status, err := srv.Submit(Submission{Scenario: []byte(scenarioYaml), Params: map[string]string{"evict": "1h"}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, srv.Shutdown(ctx))
+
+	status, err = srv.Get(status.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StateStopped, status.State)
+
+	_, err = srv.Submit(Submission{Scenario: []byte(scenarioYaml)})
+	assert.ErrorIs(t, err, ErrShutdown)
+}
+
+func TestServer_SharedScheduler(t *testing.T) {
+	withSchedulerEvents := []byte(scenarioYaml + `
+  scheduler:
+    - name: binpack
+      arrivalTime: 1s
+      weights: { NodeResourcesFit: 5 }
+`)
+
+	concurrent := NewServer(logger.Default(), offlineCluster, WithResultsDir(t.TempDir()), WithSharedScheduler(), WithConcurrency(2))
+	_, err := concurrent.Submit(Submission{Scenario: withSchedulerEvents})
+	assert.ErrorIs(t, err, ErrSchedulerEvents)
+	assert.Equal(t, http.StatusUnprocessableEntity, statusCode(err))
+	_, err = concurrent.Submit(Submission{Scenario: []byte(scenarioYaml)})
+	assert.NoError(t, err, "scenarios without scheduler events run concurrently")
+
+	sequential := NewServer(logger.Default(), offlineCluster, WithResultsDir(t.TempDir()), WithSharedScheduler())
+	_, err = sequential.Submit(Submission{Scenario: withSchedulerEvents})
+	assert.NoError(t, err, "runs one at a time do not share the scheduler")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, concurrent.Shutdown(ctx))
+	assert.NoError(t, sequential.Shutdown(ctx))
+}
@@ -0,0 +1,51 @@
+package simulation
+
+import "sync"
+
+// StartBarrier synchronizes simulations run in parallel, e.g. against several clusters: the event clock
+// of each one starts once all of them are set up, so the events arrive at the same time everywhere.
+type StartBarrier struct {
+	mu      sync.Mutex
+	pending int
+	ready   chan struct{}
+}
+
+// NewStartBarrier returns a barrier shared by n simulations.
+func NewStartBarrier(n int) *StartBarrier {
+	b := &StartBarrier{pending: n, ready: make(chan struct{})}
+	if n <= 0 {
+		close(b.ready)
+	}
+	return b
+}
+
+// WithStartBarrier starts the event clock of the simulation once all the simulations sharing the barrier
+// are set up. A simulation that fails or ends before does not hold back the others.
+func WithStartBarrier(b *StartBarrier) SimulationOpt {
+	return func(s *simulation) {
+		s.barrier = b
+	}
+}
+
+// arrive counts a simulation as set up and returns a channel closed once all of them are.
+func (b *StartBarrier) arrive() <-chan struct{} {
+	b.done()
+	return b.ready
+}
+
+// leave counts a simulation that will not start, so the others do not wait for it.
+func (b *StartBarrier) leave() {
+	b.done()
+}
+
+func (b *StartBarrier) done() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending == 0 {
+		return
+	}
+	b.pending--
+	if b.pending == 0 {
+		close(b.ready)
+	}
+}
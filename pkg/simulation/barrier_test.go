@@ -0,0 +1,32 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestStartBarrier(t *testing.T) {
+	b := NewStartBarrier(3)
+	first := b.arrive()
+	assert.False(t, closed(first))
+
+	b.leave()
+	assert.False(t, closed(first), "the barrier waits for the last simulation")
+
+	assert.True(t, closed(b.arrive()))
+	assert.True(t, closed(first), "all the simulations are released together")
+
+	b.leave()
+	assert.True(t, closed(b.arrive()), "extra simulations are not held back")
+	assert.True(t, closed(NewStartBarrier(0).arrive()))
+}
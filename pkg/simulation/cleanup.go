@@ -0,0 +1,132 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podKind is the kind of the pods recorded by the object tracker.
+const podKind = "Pod"
+
+// createdObjectsKey is a type-safe context key for the objects created by a simulation.
+type createdObjectsKey struct{}
+
+// createdObject is an object created by an event of a simulation.
+type createdObject struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (o createdObject) String() string {
+	return fmt.Sprintf("%s %s/%s", o.kind, o.namespace, o.name)
+}
+
+// createdObjects records the objects created by the events of a simulation, in creation order.
+type createdObjects struct {
+	mu      sync.Mutex
+	objects []createdObject
+	seen    map[createdObject]bool
+}
+
+func newCreatedObjects() *createdObjects {
+	return &createdObjects{seen: make(map[createdObject]bool)}
+}
+
+func (c *createdObjects) add(o createdObject) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.seen[o] {
+		c.seen[o] = true
+		c.objects = append(c.objects, o)
+	}
+}
+
+// list returns the recorded objects, the latest first.
+func (c *createdObjects) list() []createdObject {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	objects := make([]createdObject, 0, len(c.objects))
+	for i := len(c.objects) - 1; i >= 0; i-- {
+		objects = append(objects, c.objects[i])
+	}
+	return objects
+}
+
+// withCreatedObjects returns a context the events record the objects they create in.
+func withCreatedObjects(ctx context.Context, c *createdObjects) context.Context {
+	return context.WithValue(ctx, createdObjectsKey{}, c)
+}
+
+// trackCreated records an object created by an event, if the context has an object tracker.
+func trackCreated(ctx context.Context, kind, namespace, name string) {
+	if c, ok := ctx.Value(createdObjectsKey{}).(*createdObjects); ok {
+		c.add(createdObject{kind: kind, namespace: namespace, name: name})
+	}
+}
+
+// evictionsKey is a type-safe context key for the function told of the objects not waiting for eviction anymore.
+type evictionsKey struct{}
+
+// withEvictions returns a context the events report the objects that are evicted, or never will be, in.
+func withEvictions(ctx context.Context, fn func(kind, namespace, name string)) context.Context {
+	return context.WithValue(ctx, evictionsKey{}, fn)
+}
+
+// trackEvictionEnded reports an object that is evicted, or never will be, if the context has an
+// eviction tracker.
+func trackEvictionEnded(ctx context.Context, kind, namespace, name string) {
+	if fn, ok := ctx.Value(evictionsKey{}).(func(string, string, string)); ok {
+		fn(kind, namespace, name)
+	}
+}
+
+// DeleteCreated deletes the objects created by the simulation that still exist. Workloads are deleted
+// with their pods.
+func (s *simulation) DeleteCreated(ctx context.Context) error {
+	var errs []error
+	deleted := 0
+	for _, o := range s.created.list() {
+		var err error
+		if o.kind == podKind {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: o.name, Namespace: o.namespace}}
+			event := NewDeletePodEvent(0, pod)
+			event.SetClientset(s.clientset)
+			err = event.Evict(ctx)
+		} else {
+			event := NewDeleteWorkloadEvent(0, WorkloadKind(o.kind), o.namespace, o.name)
+			event.SetClientset(s.clientset)
+			err = event.delete(ctx)
+		}
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", o, err))
+		default:
+			deleted++
+		}
+	}
+	s.logger.Infof("deleted %d object(s) created by simulation %s", deleted, s.ID)
+	return errors.Join(errs...)
+}
+
+// RestoreScheduler restores the scheduler configuration found when the simulation started.
+func (s *simulation) RestoreScheduler(ctx context.Context) error {
+	s.mu.Lock()
+	config := s.schedulerConfig
+	s.mu.Unlock()
+	if config == nil {
+		return nil
+	}
+	if err := s.schedulerManager.UpdateConfiguration(ctx, config); err != nil {
+		return fmt.Errorf("failed to restore the scheduler configuration: %w", err)
+	}
+	s.logger.Infoln("scheduler configuration restored")
+	return nil
+}
@@ -0,0 +1,477 @@
+package simulation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/ghodss/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Overlay patches the imported events with the given name.
+type Overlay struct {
+	// Name is the name of the imported events to patch
+	Name string `yaml:"name" json:"name"`
+	// Patch is a JSON merge patch (RFC 7386) applied to the matching events
+	Patch map[string]interface{} `yaml:"patch" json:"patch"`
+}
+
+// PodDefaults are merged into every pod spec of the scenario.
+type PodDefaults struct {
+	// Namespace is set on pods and workloads without a namespace
+	Namespace string `yaml:"namespace" json:"namespace,omitempty"`
+	// SchedulerName is set on pods without a scheduler name
+	SchedulerName string `yaml:"schedulerName" json:"schedulerName,omitempty"`
+	// Labels are added to pods, existing labels are kept
+	Labels map[string]string `yaml:"labels" json:"labels,omitempty"`
+	// Tolerations are appended to the pod tolerations
+	Tolerations []v1.Toleration `yaml:"tolerations" json:"tolerations,omitempty"`
+}
+
+// ComposeError is returned when the imports, overlays or parameters of a scenario cannot be resolved.
+type ComposeError struct {
+	// Path is the YAML path of the import, overlay or parameter reference that failed, e.g. imports[1]
+	Path string
+	Err  error
+	path fieldPath
+}
+
+func (e *ComposeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ComposeError) Unwrap() error {
+	return e.Err
+}
+
+func newComposeError(path fieldPath, err error) *ComposeError {
+	return &ComposeError{Path: path.String(), Err: err, path: path}
+}
+
+// eventKinds are the keys of the event lists under events.
+var eventKinds = []string{"pods", "workloads", "scheduler"}
+
+// composer resolves the imports and overlays of scenario documents.
+type composer struct {
+	// stack holds the absolute paths of the files being imported, to detect cycles
+	stack []string
+	// noImports rejects imports
+	noImports bool
+}
+
+// quotedRef is a quoted string made of a single parameter reference. Unlike plain references it is
+// always substituted as a string.
+type quotedRef string
+
+// decodeDocument decodes a YAML scenario into a generic document.
+func decodeDocument(data []byte) (map[string]interface{}, error) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return make(map[string]interface{}), nil
+	}
+
+	value, err := nodeValue(root.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return make(map[string]interface{}), nil
+	}
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("scenario must be a mapping")
+	}
+	return doc, nil
+}
+
+// nodeValue converts a YAML node into generic maps, slices and scalars.
+func nodeValue(node *yamlv3.Node) (interface{}, error) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		m := make(map[string]interface{})
+		merged := make(map[string]interface{})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			v, err := nodeValue(value)
+			if err != nil {
+				return nil, err
+			}
+			if key.Value == "<<" {
+				mergeKeyValues(merged, v)
+				continue
+			}
+			m[key.Value] = v
+		}
+		for k, v := range merged {
+			if _, ok := m[k]; !ok {
+				m[k] = v
+			}
+		}
+		return m, nil
+	case yamlv3.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			v, err := nodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case yamlv3.ScalarNode:
+		quoted := node.Style&(yamlv3.DoubleQuotedStyle|yamlv3.SingleQuotedStyle) != 0
+		if quoted && isParamRef(node.Value) {
+			return quotedRef(node.Value), nil
+		}
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, nil
+}
+
+// mergeKeyValues adds the entries of a YAML merge key value (a mapping or a list of mappings) to merged.
+// Earlier mappings win.
+func mergeKeyValues(merged map[string]interface{}, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if _, ok := merged[k]; !ok {
+				merged[k] = item
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			mergeKeyValues(merged, item)
+		}
+	}
+}
+
+// loadOwn loads the scenario without resolving its imports. Parameter references are replaced by values.
+func loadOwn(data []byte, values map[string]interface{}) (*Scenario, error) {
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	delete(doc, "imports")
+	delete(doc, "overlays")
+	for key, value := range doc {
+		if key == "params" {
+			continue
+		}
+		if doc[key], err = substituteParams(value, values, fieldPath{key}); err != nil {
+			return nil, err
+		}
+	}
+	return unmarshalDocument(doc)
+}
+
+// unmarshalDocument converts a generic document into a scenario.
+func unmarshalDocument(doc map[string]interface{}) (*Scenario, error) {
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// compose resolves the imports of doc relative to baseDir, then applies its overlays to the imported events.
+// Imported events come before the events of doc; metadata, templates and defaults of doc win over imported ones.
+func (c *composer) compose(doc map[string]interface{}, baseDir string) (map[string]interface{}, error) {
+	imports, err := stringList(doc["imports"])
+	if err != nil {
+		return nil, newComposeError(fieldPath{"imports"}, err)
+	}
+	overlays, err := decodeOverlays(doc["overlays"])
+	if err != nil {
+		return nil, newComposeError(fieldPath{"overlays"}, err)
+	}
+	delete(doc, "imports")
+	delete(doc, "overlays")
+
+	if len(imports) > 0 && c.noImports {
+		return nil, newComposeError(fieldPath{"imports"}, fmt.Errorf("imports are not allowed"))
+	}
+	if len(imports) == 0 {
+		if len(overlays) > 0 {
+			return nil, newComposeError(fieldPath{"overlays"}, fmt.Errorf("overlays require imports"))
+		}
+		return doc, nil
+	}
+
+	merged := make(map[string]interface{})
+	for i, name := range imports {
+		path := fieldPath{"imports"}.Index(i)
+
+		imported, err := c.importFile(name, baseDir)
+		if err != nil {
+			return nil, newComposeError(path, err)
+		}
+		mergeDocuments(merged, imported)
+	}
+
+	for i, overlay := range overlays {
+		if err := applyOverlay(merged, overlay); err != nil {
+			return nil, newComposeError(fieldPath{"overlays"}.Index(i).Key("name"), err)
+		}
+	}
+
+	mergeDocuments(merged, doc)
+	return merged, nil
+}
+
+// importFile loads and composes the scenario file name, relative to baseDir.
+func (c *composer) importFile(name, baseDir string) (map[string]interface{}, error) {
+	filename := name
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(baseDir, filename)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range c.stack {
+		if f == abs {
+			return nil, fmt.Errorf("import cycle on %s", name)
+		}
+	}
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	c.stack = append(c.stack, abs)
+	defer func() { c.stack = c.stack[:len(c.stack)-1] }()
+
+	composed, err := c.compose(doc, filepath.Dir(abs))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return composed, nil
+}
+
+// mergeDocuments merges src into dst. Event and node lists are concatenated, the other
+// mappings are merged recursively with src winning on conflicts.
+func mergeDocuments(dst, src map[string]interface{}) {
+	for key, value := range src {
+		switch key {
+		case "events":
+			dstEvents, _ := dst["events"].(map[string]interface{})
+			if dstEvents == nil {
+				dstEvents = make(map[string]interface{})
+				dst["events"] = dstEvents
+			}
+			srcEvents, _ := value.(map[string]interface{})
+			for _, kind := range eventKinds {
+				if list, ok := srcEvents[kind].([]interface{}); ok {
+					existing, _ := dstEvents[kind].([]interface{})
+					dstEvents[kind] = append(existing, list...)
+				}
+			}
+		case "cluster":
+			dstCluster, _ := dst["cluster"].(map[string]interface{})
+			if dstCluster == nil {
+				dstCluster = make(map[string]interface{})
+				dst["cluster"] = dstCluster
+			}
+			srcCluster, _ := value.(map[string]interface{})
+			for k, v := range srcCluster {
+				existing, okDst := dstCluster[k].([]interface{})
+				list, okSrc := v.([]interface{})
+				if okDst && okSrc {
+					dstCluster[k] = append(existing, list...)
+				} else {
+					dstCluster[k] = v
+				}
+			}
+		default:
+			dst[key] = mergeValues(dst[key], value)
+		}
+	}
+}
+
+// mergeValues merges mappings recursively. Any other value of src replaces dst.
+func mergeValues(dst, src interface{}) interface{} {
+	dstMap, okDst := dst.(map[string]interface{})
+	srcMap, okSrc := src.(map[string]interface{})
+	if !okDst || !okSrc {
+		return src
+	}
+	for k, v := range srcMap {
+		dstMap[k] = mergeValues(dstMap[k], v)
+	}
+	return dstMap
+}
+
+// applyOverlay applies the overlay patch to every event named overlay.Name.
+func applyOverlay(doc map[string]interface{}, overlay Overlay) error {
+	if overlay.Name == "" {
+		return fmt.Errorf("overlay name is required")
+	}
+
+	events, _ := doc["events"].(map[string]interface{})
+	matched := 0
+	for _, kind := range eventKinds {
+		list, _ := events[kind].([]interface{})
+		for i, item := range list {
+			event, ok := item.(map[string]interface{})
+			if !ok || event["name"] != overlay.Name {
+				continue
+			}
+			list[i] = mergePatch(event, overlay.Patch)
+			matched++
+		}
+	}
+
+	if matched == 0 {
+		return fmt.Errorf("no imported event named %q", overlay.Name)
+	}
+	return nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to target.
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = make(map[string]interface{})
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+			continue
+		}
+		targetMap[k] = mergePatch(targetMap[k], v)
+	}
+	return targetMap
+}
+
+// stringList converts a decoded YAML list of strings.
+func stringList(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list of file names")
+	}
+	names := make([]string, 0, len(list))
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected a file name, got %v", item)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// decodeOverlays converts decoded YAML overlays.
+func decodeOverlays(value interface{}) ([]Overlay, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var overlays []Overlay
+	if err := yaml.Unmarshal(data, &overlays); err != nil {
+		return nil, err
+	}
+	return overlays, nil
+}
+
+// applyDefaults merges the scenario defaults into every pod spec and workload.
+func (s *Scenario) applyDefaults() {
+	d := s.Defaults
+	if d == nil {
+		return
+	}
+
+	for _, pod := range s.Templates {
+		if pod != nil {
+			d.applyMeta(&pod.ObjectMeta, true)
+			d.applySpec(&pod.Spec)
+		}
+	}
+
+	for i := range s.Events.Pods {
+		if pod := s.Events.Pods[i].PodSpec; pod != nil {
+			d.applyMeta(&pod.ObjectMeta, true)
+			d.applySpec(&pod.Spec)
+		}
+	}
+
+	for i := range s.Events.Workloads {
+		e := &s.Events.Workloads[i]
+		if e.Namespace == "" {
+			if obj := e.object(); obj == nil || obj.GetNamespace() == "" {
+				e.Namespace = d.Namespace
+			}
+		}
+		if template := e.podTemplate(); template != nil {
+			d.applyMeta(&template.ObjectMeta, false)
+			d.applySpec(&template.Spec)
+		}
+	}
+}
+
+// applyMeta merges the default labels, and the namespace if withNamespace is set.
+func (d *PodDefaults) applyMeta(meta *metav1.ObjectMeta, withNamespace bool) {
+	if withNamespace && meta.Namespace == "" {
+		meta.Namespace = d.Namespace
+	}
+	if len(d.Labels) == 0 {
+		return
+	}
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string)
+	}
+	for k, v := range d.Labels {
+		if _, ok := meta.Labels[k]; !ok {
+			meta.Labels[k] = v
+		}
+	}
+}
+
+// applySpec sets the default scheduler name and appends the default tolerations.
+func (d *PodDefaults) applySpec(spec *v1.PodSpec) {
+	if spec.SchedulerName == "" {
+		spec.SchedulerName = d.SchedulerName
+	}
+	for _, toleration := range d.Tolerations {
+		found := false
+		for _, t := range spec.Tolerations {
+			if reflect.DeepEqual(t, toleration) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			spec.Tolerations = append(spec.Tolerations, toleration)
+		}
+	}
+}
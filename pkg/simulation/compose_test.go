@@ -0,0 +1,230 @@
+package simulation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+var clusterYaml = `
+metadata:
+  name: shared-cluster
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+`
+
+var workloadsYaml = `
+imports:
+  - cluster.yaml
+templates:
+  small:
+    metadata:
+      name: small
+    spec:
+      containers:
+        - name: main
+          image: nginx
+events:
+  pods:
+    - name: burst
+      template: small
+      arrivalTime: 10s
+      replicas: 2
+    - name: single
+      arrivalTime: 1s
+      podSpec:
+        metadata:
+          name: single
+          namespace: team-b
+        spec:
+          containers:
+            - name: main
+              image: nginx
+`
+
+var composedYaml = `
+imports:
+  - base/workloads.yaml
+overlays:
+  - name: burst
+    patch:
+      replicas: 5
+      arrivalTime: 20s
+defaults:
+  namespace: team-a
+  schedulerName: my-scheduler
+  labels:
+    team: a
+  tolerations:
+    - key: dedicated
+      operator: Exists
+metadata:
+  name: composed
+cluster:
+  nodes:
+    - metadata:
+        name: node-2
+events:
+  pods:
+    - name: own
+      arrivalTime: 30s
+      template: small
+`
+
+func writeScenarioFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoad_ImportsOverlaysDefaults(t *testing.T) {
+	dir := writeScenarioFiles(t, map[string]string{
+		"base/cluster.yaml":   clusterYaml,
+		"base/workloads.yaml": workloadsYaml,
+		"scenario.yaml":       composedYaml,
+	})
+
+	s, err := LoadFromYaml(filepath.Join(dir, "scenario.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "composed", s.Metadata.Name)
+	require.Len(t, s.Cluster.Nodes, 2)
+	assert.Equal(t, "node-1", s.Cluster.Nodes[0].Name)
+	assert.Equal(t, "node-2", s.Cluster.Nodes[1].Name)
+
+	require.Len(t, s.Events.Pods, 3)
+	assert.Equal(t, []string{"burst", "single", "own"},
+		[]string{s.Events.Pods[0].Name, s.Events.Pods[1].Name, s.Events.Pods[2].Name})
+
+	// overlay
+	assert.Equal(t, 5, s.Events.Pods[0].Replicas)
+	assert.Equal(t, 20*time.Second, s.Events.Pods[0].ArrivalTime.Duration())
+	assert.Equal(t, "small", s.Events.Pods[0].Template)
+
+	// defaults
+	small := s.Templates["small"]
+	require.NotNil(t, small)
+	assert.Equal(t, "team-a", small.Namespace)
+	assert.Equal(t, "my-scheduler", small.Spec.SchedulerName)
+	assert.Equal(t, "a", small.Labels["team"])
+	assert.Equal(t, []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpExists}}, small.Spec.Tolerations)
+
+	single := s.Events.Pods[1].PodSpec
+	assert.Equal(t, "team-b", single.Namespace, "explicit namespace wins over defaults")
+	assert.Equal(t, "my-scheduler", single.Spec.SchedulerName)
+
+	events, err := s.ExpandPodEvents()
+	require.NoError(t, err)
+	assert.Len(t, events, 5+1+1)
+}
+
+func TestLoad_ComposeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		path  string
+	}{
+		{
+			name:  "missing import",
+			files: map[string]string{"scenario.yaml": "imports: [missing.yaml]\n"},
+			path:  "imports[0]",
+		},
+		{
+			name: "import cycle",
+			files: map[string]string{
+				"scenario.yaml": "imports: [a.yaml]\n",
+				"a.yaml":        "imports: [b.yaml]\n",
+				"b.yaml":        "imports: [a.yaml]\n",
+			},
+			path: "imports[0]",
+		},
+		{
+			name: "unknown overlay target",
+			files: map[string]string{
+				"scenario.yaml": "imports: [base.yaml]\noverlays:\n  - name: nope\n    patch: {replicas: 2}\n",
+				"base.yaml":     "events:\n  pods:\n    - name: web\n",
+			},
+			path: "overlays[0].name",
+		},
+		{
+			name:  "overlays without imports",
+			files: map[string]string{"scenario.yaml": "overlays:\n  - name: web\n"},
+			path:  "overlays",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeScenarioFiles(t, tt.files)
+
+			_, err := LoadFromYaml(filepath.Join(dir, "scenario.yaml"))
+			require.Error(t, err)
+			var composeErr *ComposeError
+			require.ErrorAs(t, err, &composeErr)
+			assert.Equal(t, tt.path, composeErr.Path)
+		})
+	}
+}
+
+func TestLoad_WithoutImports(t *testing.T) {
+	dir := writeScenarioFiles(t, map[string]string{"base.yaml": "events:\n  pods:\n    - name: web\n"})
+	data := []byte("imports: [base.yaml]\n")
+
+	_, err := Load(data, WithBaseDir(dir), WithoutImports())
+	var composeErr *ComposeError
+	require.ErrorAs(t, err, &composeErr)
+	assert.Equal(t, "imports", composeErr.Path)
+	assert.ErrorContains(t, err, "imports are not allowed")
+
+	_, err = Load(data, WithBaseDir(dir))
+	assert.NoError(t, err)
+}
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{
+		"name":     "web",
+		"replicas": float64(1),
+		"podSpec":  map[string]interface{}{"metadata": map[string]interface{}{"name": "web", "namespace": "a"}},
+	}
+	patch := map[string]interface{}{
+		"replicas": float64(3),
+		"podSpec":  map[string]interface{}{"metadata": map[string]interface{}{"namespace": nil}},
+	}
+
+	got := mergePatch(target, patch)
+	assert.Equal(t, map[string]interface{}{
+		"name":     "web",
+		"replicas": float64(3),
+		"podSpec":  map[string]interface{}{"metadata": map[string]interface{}{"name": "web"}},
+	}, got)
+}
+
+func TestValidateFile_Imports(t *testing.T) {
+	dir := writeScenarioFiles(t, map[string]string{
+		"base/cluster.yaml":   clusterYaml,
+		"base/workloads.yaml": workloadsYaml,
+		"scenario.yaml":       composedYaml,
+		"broken.yaml":         "metadata:\n  name: broken\nimports:\n  - base/cluster.yaml\n  - missing.yaml\n",
+	})
+
+	diags, err := ValidateFile(filepath.Join(dir, "scenario.yaml"))
+	require.NoError(t, err)
+	assert.False(t, diags.HasErrors(), "%v", diags)
+
+	diags, err = ValidateFile(filepath.Join(dir, "broken.yaml"))
+	require.NoError(t, err)
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "imports[1]", diags[0].Path)
+	assert.Equal(t, 5, diags[0].Line)
+}
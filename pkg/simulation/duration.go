@@ -23,8 +23,8 @@ func (d *EventDuration) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func (d *EventDuration) MarshalJSON() ([]byte, error) {
-	durationStr := time.Duration(*d).String()
+func (d EventDuration) MarshalJSON() ([]byte, error) {
+	durationStr := time.Duration(d).String()
 	return json.Marshal(durationStr)
 }
 
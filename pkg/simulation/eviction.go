@@ -0,0 +1,87 @@
+package simulation
+
+import (
+	"time"
+)
+
+// DefaultStartTimeout is the time a pod has to start when its eviction waits for it.
+const DefaultStartTimeout = 5 * time.Minute
+
+// EvictFrom is the reference point the evict time of a pod is measured from.
+type EvictFrom string
+
+const (
+	// EvictFromArrival measures the evict time from the arrival time of the event
+	EvictFromArrival EvictFrom = "arrival"
+	// EvictFromCreated measures the evict time from the creation of the pod
+	EvictFromCreated EvictFrom = "created"
+	// EvictFromScheduled measures the evict time from the binding of the pod to a node
+	EvictFromScheduled EvictFrom = "scheduled"
+	// EvictFromRunning measures the evict time from the pod running, the default
+	EvictFromRunning EvictFrom = "running"
+)
+
+// StartTimeoutPolicy is what happens to a pod that does not start within its start timeout.
+type StartTimeoutPolicy string
+
+const (
+	// StartTimeoutDelete deletes the pod, the default
+	StartTimeoutDelete StartTimeoutPolicy = "delete"
+	// StartTimeoutKeep leaves the pod in place. It is never evicted.
+	StartTimeoutKeep StartTimeoutPolicy = "keep"
+	// StartTimeoutFail marks the pod failed, which removes it from the scheduling queue
+	StartTimeoutFail StartTimeoutPolicy = "fail"
+)
+
+// StartTimeoutReason is the status reason of the pods marked failed by StartTimeoutFail.
+const StartTimeoutReason = "StartTimeout"
+
+// EvictionPolicy tells when a pod is evicted, and what happens to a pod that does not start. A pod starts
+// when it is scheduled if its evict time is measured from scheduling, when it runs otherwise.
+type EvictionPolicy struct {
+	// EvictFrom is the reference point of the evict time: arrival, created, scheduled or running (default)
+	EvictFrom EvictFrom `yaml:"evictFrom" json:"evictFrom,omitempty"`
+	// StartTimeout is the time the pod has to start. It defaults to 5m when the eviction waits for the
+	// pod to be scheduled or running, and is not enforced otherwise unless set.
+	StartTimeout EventDuration `yaml:"startTimeout" json:"startTimeout,omitempty"`
+	// OnStartTimeout is what happens to a pod that does not start in time: delete (default), keep or fail
+	OnStartTimeout StartTimeoutPolicy `yaml:"onStartTimeout" json:"onStartTimeout,omitempty"`
+}
+
+// withDefaults returns e with its unset fields taken from defaults, then from the built-in defaults.
+func (e EvictionPolicy) withDefaults(defaults *EvictionPolicy) EvictionPolicy {
+	if defaults != nil {
+		if e.EvictFrom == "" {
+			e.EvictFrom = defaults.EvictFrom
+		}
+		if e.StartTimeout == 0 {
+			e.StartTimeout = defaults.StartTimeout
+		}
+		if e.OnStartTimeout == "" {
+			e.OnStartTimeout = defaults.OnStartTimeout
+		}
+	}
+	if e.EvictFrom == "" {
+		e.EvictFrom = EvictFromRunning
+	}
+	if e.OnStartTimeout == "" {
+		e.OnStartTimeout = StartTimeoutDelete
+	}
+	return e
+}
+
+// waitsForStart reports whether the eviction of a pod with the evict time is measured from its start.
+func (e EvictionPolicy) waitsForStart(evictTime time.Duration) bool {
+	return evictTime > 0 && (e.EvictFrom == EvictFromScheduled || e.EvictFrom == EvictFromRunning)
+}
+
+// startTimeout returns the start timeout of a pod with the evict time, zero if its start is not watched.
+func (e EvictionPolicy) startTimeout(evictTime time.Duration) time.Duration {
+	if e.StartTimeout > 0 {
+		return e.StartTimeout.Duration()
+	}
+	if e.waitsForStart(evictTime) {
+		return DefaultStartTimeout
+	}
+	return 0
+}
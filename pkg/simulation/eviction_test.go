@@ -0,0 +1,83 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvictionPolicy_WithDefaults(t *testing.T) {
+	scenario := &EvictionPolicy{EvictFrom: EvictFromCreated, StartTimeout: EventDuration(time.Minute)}
+
+	tests := []struct {
+		name     string
+		event    EvictionPolicy
+		defaults *EvictionPolicy
+		expected EvictionPolicy
+	}{
+		{"built-in defaults", EvictionPolicy{}, nil, EvictionPolicy{EvictFrom: EvictFromRunning, OnStartTimeout: StartTimeoutDelete}},
+		{"scenario defaults", EvictionPolicy{}, scenario,
+			EvictionPolicy{EvictFrom: EvictFromCreated, StartTimeout: EventDuration(time.Minute), OnStartTimeout: StartTimeoutDelete}},
+		{"event overrides", EvictionPolicy{EvictFrom: EvictFromScheduled, OnStartTimeout: StartTimeoutKeep}, scenario,
+			EvictionPolicy{EvictFrom: EvictFromScheduled, StartTimeout: EventDuration(time.Minute), OnStartTimeout: StartTimeoutKeep}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.event.withDefaults(tt.defaults))
+		})
+	}
+}
+
+func TestEvictionPolicy_StartTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    EvictionPolicy
+		evictTime time.Duration
+		expected  time.Duration
+	}{
+		{"waits for running", EvictionPolicy{EvictFrom: EvictFromRunning}, time.Second, DefaultStartTimeout},
+		{"waits for scheduling", EvictionPolicy{EvictFrom: EvictFromScheduled}, time.Second, DefaultStartTimeout},
+		{"measured from creation", EvictionPolicy{EvictFrom: EvictFromCreated}, time.Second, 0},
+		{"no eviction", EvictionPolicy{EvictFrom: EvictFromRunning}, 0, 0},
+		{"explicit timeout", EvictionPolicy{EvictFrom: EvictFromArrival, StartTimeout: EventDuration(time.Minute)}, time.Second, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.startTimeout(tt.evictTime))
+		})
+	}
+}
+
+func TestExpandPodEvents_EvictionPolicy(t *testing.T) {
+	scenario, err := Load([]byte(`metadata:
+  name: eviction
+eviction:
+  evictFrom: created
+  startTimeout: 30s
+events:
+  pods:
+    - name: default
+      evictTime: 1m
+      podSpec:
+        spec:
+          containers: [{ name: main, image: nginx }]
+    - name: override
+      evictTime: 1m
+      evictFrom: scheduled
+      onStartTimeout: fail
+      podSpec:
+        spec:
+          containers: [{ name: main, image: nginx }]
+`))
+	require.NoError(t, err)
+	events, err := scenario.ExpandPodEvents()
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, EvictionPolicy{EvictFrom: EvictFromCreated, StartTimeout: EventDuration(30 * time.Second),
+		OnStartTimeout: StartTimeoutDelete}, events[0].EvictionPolicy)
+	assert.Equal(t, EvictionPolicy{EvictFrom: EvictFromScheduled, StartTimeout: EventDuration(30 * time.Second),
+		OnStartTimeout: StartTimeoutFail}, events[1].EvictionPolicy)
+}
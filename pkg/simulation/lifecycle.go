@@ -0,0 +1,48 @@
+package simulation
+
+import (
+	"strconv"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodOutcome is how a running pod ends on its own.
+type PodOutcome string
+
+const (
+	// OutcomeSucceed makes the pod succeed, as a finished batch task
+	OutcomeSucceed PodOutcome = kube.OutcomeSucceed
+	// OutcomeFail makes the pod fail
+	OutcomeFail PodOutcome = kube.OutcomeFail
+	// OutcomeCrashLoop makes the containers of the pod crash and restart in a loop
+	OutcomeCrashLoop PodOutcome = kube.OutcomeCrashLoop
+)
+
+// Lifecycle makes the pods of an event end on their own. It is set as pod annotations, which the KWOK
+// stages of docker/kwok.yaml and the offline cluster act on. The pods still run until their eviction
+// if they crash-loop.
+type Lifecycle struct {
+	// Outcome is how the pod ends: succeed, fail or crash-loop
+	Outcome PodOutcome `yaml:"outcome" json:"outcome"`
+	// After is the time the pod runs before its outcome. Crash-looping pods also wait this long before
+	// each restart.
+	After EventDuration `yaml:"after" json:"after"`
+	// ExitCode is the exit code of the containers of a failed or crash-looping pod, 1 if unset
+	ExitCode int32 `yaml:"exitCode" json:"exitCode,omitempty"`
+}
+
+// annotate sets the lifecycle annotations of a pod or pod template.
+func (l *Lifecycle) annotate(meta *metav1.ObjectMeta) {
+	if l == nil {
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string)
+	}
+	meta.Annotations[kube.OutcomeAnnotation] = string(l.Outcome)
+	meta.Annotations[kube.OutcomeAfterAnnotation] = l.After.Duration().String()
+	if l.ExitCode != 0 {
+		meta.Annotations[kube.ExitCodeAnnotation] = strconv.Itoa(int(l.ExitCode))
+	}
+}
@@ -0,0 +1,72 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// namespaceDeleteTimeout bounds the deletion of the namespace of an isolated simulation.
+const namespaceDeleteTimeout = 30 * time.Second
+
+// invalidLabelChars matches the characters not allowed in a DNS-1123 label.
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// WithIsolatedNamespace runs the simulation in its own namespace, keg-<simulation id>-<suffix>. The
+// namespace is created when the simulation starts and deleted, with everything in it, when it ends. Pods
+// and workloads without a namespace run in it, and only its pods are accounted in the stats.
+func WithIsolatedNamespace() SimulationOpt {
+	return func(s *simulation) {
+		s.namespace = isolatedNamespace(s.ID, utilrand.String(5))
+		s.isolated = true
+	}
+}
+
+// isolatedNamespace returns the namespace of an isolated simulation, a valid DNS-1123 label. The random
+// suffix keeps apart simulations started in the same second, as the namespace of the previous one may
+// still be terminating.
+func isolatedNamespace(id, suffix string) string {
+	name := "keg-" + invalidLabelChars.ReplaceAllString(strings.ToLower(id), "-")
+	if limit := validation.DNS1123LabelMaxLength - len(suffix) - 1; len(name) > limit {
+		name = name[:limit]
+	}
+	return strings.TrimRight(name, "-") + "-" + suffix
+}
+
+// Namespace returns the namespace of the pods and workloads that do not set one.
+func (s *simulation) Namespace() string {
+	return s.namespace
+}
+
+// createNamespace creates the namespace of an isolated simulation.
+func (s *simulation) createNamespace(ctx context.Context) error {
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: s.namespace}}
+	kube.Ownership{SimulationID: s.ID, Scenario: s.scenario.Metadata.Name}.Stamp(ns)
+	if _, err := s.clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create namespace %s: %w", s.namespace, err)
+	}
+	s.logger.Infof("simulation %s runs in namespace %s", s.ID, s.namespace)
+	return nil
+}
+
+// deleteNamespace deletes the namespace of an isolated simulation. The context of the simulation may be
+// done already, so the deletion has its own.
+func (s *simulation) deleteNamespace() {
+	ctx, cancel := context.WithTimeout(context.Background(), namespaceDeleteTimeout)
+	defer cancel()
+	err := s.clientset.CoreV1().Namespaces().Delete(ctx, s.namespace, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		s.logger.Errorf("failed to delete namespace %s: %v", s.namespace, err)
+		return
+	}
+	s.logger.Infof("namespace %s deleted", s.namespace)
+}
@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestIsolatedNamespace(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"sim-web-15_04_05_020106", "keg-sim-web-15-04-05-020106-abcde"},
+		{"sim-My Scenario-1", "keg-sim-my-scenario-1-abcde"},
+		{"sim-" + strings.Repeat("a", 80), "keg-sim-" + strings.Repeat("a", 49) + "-abcde"},
+		{"sim-" + strings.Repeat("a", 48) + "_b", "keg-sim-" + strings.Repeat("a", 48) + "-abcde"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got := isolatedNamespace(tt.id, "abcde")
+			assert.Equal(t, tt.want, got)
+			assert.Empty(t, validation.IsDNS1123Label(got))
+		})
+	}
+}
+
+func TestSimulation_IsolatedNamespace(t *testing.T) {
+	scenarioYaml := strings.Replace(offlineScenarioYaml, "          namespace: default\n", "", 1)
+	scenario, err := Load([]byte(strings.Replace(scenarioYaml, "evictTime: 300ms", "evictTime: 1h", 1) +
+		"termination: { duration: 500ms }"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	// a pod of the cluster, outside the simulation
+	other := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: metav1.NamespaceDefault},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "main", Image: "nginx"}}},
+	}
+	_, err = cluster.Clientset().CoreV1().Pods(metav1.NamespaceDefault).Create(ctx, other, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default(), WithIsolatedNamespace())
+	namespace := sim.Namespace()
+	require.True(t, strings.HasPrefix(namespace, "keg-sim-offline-"), namespace)
+	require.NoError(t, sim.Start(ctx))
+
+	// the fake clientset does not delete the pods of a deleted namespace
+	pods, err := cluster.Clientset().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, pods.Items, 3, "the pods without a namespace run in the simulation namespace")
+	assert.Equal(t, 3, sim.GetStats().Summarize().ScheduledPods, "the pods of other namespaces are not accounted")
+
+	_, err = cluster.Clientset().CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the namespace is deleted when the simulation ends")
+}
@@ -0,0 +1,25 @@
+package simulation
+
+import (
+	"context"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ownershipKey is a type-safe context key for the simulation that executes the events.
+type ownershipKey struct{}
+
+// withOwnership returns a context the events stamp the objects they create with the simulation in.
+func withOwnership(ctx context.Context, simulationID, scenario string) context.Context {
+	return context.WithValue(ctx, ownershipKey{}, kube.Ownership{SimulationID: simulationID, Scenario: scenario})
+}
+
+// stampOwnership sets the ownership labels and annotations of an object created by an event. Without a
+// simulation in the context, only the event is recorded.
+func stampOwnership(ctx context.Context, obj metav1.Object, eventName, eventID string) {
+	o, _ := ctx.Value(ownershipKey{}).(kube.Ownership)
+	o.EventName = eventName
+	o.EventID = eventID
+	o.Stamp(obj)
+}
@@ -0,0 +1,307 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ParamType is the type of a scenario parameter.
+type ParamType string
+
+const (
+	ParamTypeInt      ParamType = "int"
+	ParamTypeFloat    ParamType = "float"
+	ParamTypeBool     ParamType = "bool"
+	ParamTypeString   ParamType = "string"
+	ParamTypeQuantity ParamType = "quantity"
+	ParamTypeDuration ParamType = "duration"
+)
+
+// paramRefPattern matches ${name} references to parameters, and $${name} escapes.
+var paramRefPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// Param is a typed scenario parameter. It is declared either in full form ({type: int, default: 3})
+// or as a shorthand default value whose type (int, float, bool or string) is inferred.
+type Param struct {
+	// Type is the type of the parameter
+	Type ParamType `yaml:"type" json:"type"`
+	// Default is the value used when the parameter is not overridden
+	Default interface{} `yaml:"default" json:"default"`
+	// Description documents the parameter
+	Description string `yaml:"description" json:"description,omitempty"`
+}
+
+// UnmarshalJSON decodes both the full and the shorthand form of a parameter.
+func (p *Param) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		type Alias Param
+		var alias Alias
+		if err := json.Unmarshal(data, &alias); err != nil {
+			return err
+		}
+		*p = Param(alias)
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	p.Default = value
+	p.Type = inferParamType(value)
+	return nil
+}
+
+// inferParamType returns the type of a shorthand parameter value.
+func inferParamType(value interface{}) ParamType {
+	switch v := value.(type) {
+	case bool:
+		return ParamTypeBool
+	case float64:
+		if v == math.Trunc(v) {
+			return ParamTypeInt
+		}
+		return ParamTypeFloat
+	}
+	return ParamTypeString
+}
+
+// convert checks value, as decoded from JSON, against the parameter type and returns its canonical form:
+// int64 for int, float64 for float, bool for bool and string for the other types.
+func (p *Param) convert(value interface{}) (interface{}, error) {
+	if s, ok := value.(string); ok && p.Type != ParamTypeString {
+		return p.parse(s)
+	}
+
+	switch p.Type {
+	case ParamTypeInt:
+		if v, ok := value.(float64); ok && v == math.Trunc(v) {
+			return int64(v), nil
+		}
+	case ParamTypeFloat:
+		if v, ok := value.(float64); ok {
+			return v, nil
+		}
+	case ParamTypeBool:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case ParamTypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case ParamTypeQuantity:
+		if v, ok := value.(float64); ok {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	case ParamTypeDuration:
+	default:
+		return nil, fmt.Errorf("unknown parameter type %q", p.Type)
+	}
+	return nil, fmt.Errorf("expected %s, got %v", p.Type, value)
+}
+
+// parse parses a command line value according to the parameter type.
+func (p *Param) parse(s string) (interface{}, error) {
+	switch p.Type {
+	case ParamTypeInt:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected int, got %q", s)
+		}
+		return v, nil
+	case ParamTypeFloat:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected float, got %q", s)
+		}
+		return v, nil
+	case ParamTypeBool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("expected bool, got %q", s)
+		}
+		return v, nil
+	case ParamTypeString:
+		return s, nil
+	case ParamTypeQuantity:
+		if _, err := resource.ParseQuantity(s); err != nil {
+			return nil, fmt.Errorf("invalid quantity %q", s)
+		}
+		return s, nil
+	case ParamTypeDuration:
+		if _, err := time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("invalid duration %q", s)
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown parameter type %q", p.Type)
+}
+
+// ParseParamOverrides parses name=value pairs, as given to --set.
+func ParseParamOverrides(pairs []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid parameter %q, expected name=value", pair)
+		}
+		overrides[name] = value
+	}
+	return overrides, nil
+}
+
+// resolveParams computes the parameter values of doc, applying overrides, and substitutes
+// the references to parameters in every string of doc.
+func resolveParams(doc map[string]interface{}, overrides map[string]string) (map[string]interface{}, error) {
+	params, err := decodeParams(doc["params"])
+	if err != nil {
+		return nil, newComposeError(fieldPath{"params"}, err)
+	}
+
+	values := make(map[string]interface{}, len(params))
+	for name, param := range params {
+		if param.Default == nil {
+			if _, ok := overrides[name]; !ok {
+				return nil, newComposeError(fieldPath{"params", name}, fmt.Errorf("parameter %q has no default, set it with --set", name))
+			}
+			continue
+		}
+		value, err := param.convert(param.Default)
+		if err != nil {
+			return nil, newComposeError(fieldPath{"params", name}, err)
+		}
+		values[name] = value
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		param, ok := params[name]
+		if !ok {
+			return nil, newComposeError(fieldPath{"params"}, fmt.Errorf("unknown parameter %q", name))
+		}
+		value, err := param.parse(overrides[name])
+		if err != nil {
+			return nil, newComposeError(fieldPath{"params", name}, fmt.Errorf("--set %s: %w", name, err))
+		}
+		values[name] = value
+	}
+
+	for key, value := range doc {
+		if key == "params" {
+			continue
+		}
+		resolved, err := substituteParams(value, values, fieldPath{key})
+		if err != nil {
+			return nil, err
+		}
+		doc[key] = resolved
+	}
+	return values, nil
+}
+
+// decodeParams converts decoded YAML parameters.
+func decodeParams(value interface{}) (map[string]Param, error) {
+	params := make(map[string]Param)
+	if value == nil {
+		return params, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// substituteParams replaces the parameter references in the strings of value. An unquoted string made of
+// a single reference is replaced by the typed value, otherwise values are formatted into the string.
+func substituteParams(value interface{}, values map[string]interface{}, path fieldPath) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			resolved, err := substituteParams(item, values, path.Key(k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = resolved
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			resolved, err := substituteParams(item, values, path.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	case quotedRef:
+		return interpolateString(string(v), values, path)
+	case string:
+		return substituteString(v, values, path)
+	}
+	return value, nil
+}
+
+// isParamRef reports whether s is made of a single parameter reference.
+func isParamRef(s string) bool {
+	m := paramRefPattern.FindString(s)
+	return m == s && m != "" && !strings.HasPrefix(s, "$$")
+}
+
+func substituteString(s string, values map[string]interface{}, path fieldPath) (interface{}, error) {
+	if isParamRef(s) {
+		name := paramRefPattern.FindStringSubmatch(s)[1]
+		value, ok := values[name]
+		if !ok {
+			return nil, newComposeError(path, fmt.Errorf("unknown parameter %q", name))
+		}
+		return value, nil
+	}
+	return interpolateString(s, values, path)
+}
+
+// interpolateString formats the referenced parameter values into s.
+func interpolateString(s string, values map[string]interface{}, path fieldPath) (string, error) {
+
+	var err error
+	resolved := paramRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := paramRefPattern.FindStringSubmatch(ref)[1]
+		value, ok := values[name]
+		if !ok {
+			if err == nil {
+				err = newComposeError(path, fmt.Errorf("unknown parameter %q", name))
+			}
+			return ref
+		}
+		return formatParam(value)
+	})
+	return resolved, err
+}
+
+// formatParam formats a parameter value for interpolation into a string.
+func formatParam(value interface{}) string {
+	if v, ok := value.(float64); ok {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
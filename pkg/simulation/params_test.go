@@ -0,0 +1,171 @@
+package simulation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var paramsScenarioYaml = `
+params:
+  replicas: 3
+  cpu: 200m
+  spacing:
+    type: duration
+    default: 2s
+  rate:
+    type: float
+    default: 0.5
+    description: pods per second
+metadata:
+  name: load-${replicas}
+events:
+  pods:
+    - name: burst
+      arrivalTime: 10s
+      spacing: ${spacing}
+      replicas: ${replicas}
+      podSpec:
+        metadata:
+          name: burst
+          annotations:
+            rate: "${rate}"
+            literal: "$${rate}"
+        spec:
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests:
+                  cpu: ${cpu}
+`
+
+func TestLoad_Params(t *testing.T) {
+	s, err := Load([]byte(paramsScenarioYaml))
+	require.NoError(t, err)
+
+	assert.Equal(t, "load-3", s.Metadata.Name)
+	event := s.Events.Pods[0]
+	assert.Equal(t, 3, event.Replicas)
+	assert.Equal(t, 2*time.Second, event.Spacing.Duration())
+	assert.Equal(t, resource.MustParse("200m"), event.PodSpec.Spec.Containers[0].Resources.Requests["cpu"])
+	assert.Equal(t, "0.5", event.PodSpec.Annotations["rate"])
+	assert.Equal(t, "${rate}", event.PodSpec.Annotations["literal"])
+
+	assert.Equal(t, map[string]interface{}{
+		"replicas": int64(3), "cpu": "200m", "spacing": "2s", "rate": 0.5,
+	}, s.ParamValues())
+}
+
+func TestLoad_ParamOverrides(t *testing.T) {
+	s, err := Load([]byte(paramsScenarioYaml), WithParams(map[string]string{"replicas": "10", "rate": "1.5", "cpu": "1"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "load-10", s.Metadata.Name)
+	assert.Equal(t, 10, s.Events.Pods[0].Replicas)
+	assert.Equal(t, "1.5", s.Events.Pods[0].PodSpec.Annotations["rate"])
+	assert.Equal(t, resource.MustParse("1"), s.Events.Pods[0].PodSpec.Spec.Containers[0].Resources.Requests["cpu"])
+}
+
+func TestLoad_ParamErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		yaml      string
+		overrides map[string]string
+		path      string
+	}{
+		{
+			name:      "override type mismatch",
+			yaml:      paramsScenarioYaml,
+			overrides: map[string]string{"replicas": "many"},
+			path:      "params.replicas",
+		},
+		{
+			name:      "unknown override",
+			yaml:      paramsScenarioYaml,
+			overrides: map[string]string{"nope": "1"},
+			path:      "params",
+		},
+		{
+			name: "default type mismatch",
+			yaml: "params:\n  count:\n    type: int\n    default: abc\n",
+			path: "params.count",
+		},
+		{
+			name: "missing required value",
+			yaml: "params:\n  count:\n    type: int\n",
+			path: "params.count",
+		},
+		{
+			name: "unknown reference",
+			yaml: "metadata:\n  name: ${missing}\n",
+			path: "metadata.name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load([]byte(tt.yaml), WithParams(tt.overrides))
+			require.Error(t, err)
+			var composeErr *ComposeError
+			require.ErrorAs(t, err, &composeErr)
+			assert.Equal(t, tt.path, composeErr.Path)
+		})
+	}
+}
+
+func TestLoad_ParamsAcrossImports(t *testing.T) {
+	dir := writeScenarioFiles(t, map[string]string{
+		"base.yaml": `
+params:
+  replicas: 1
+events:
+  pods:
+    - name: web
+      replicas: ${replicas}
+      podSpec:
+        spec:
+          containers:
+            - name: main
+              image: nginx
+`,
+		"scenario.yaml": "imports: [base.yaml]\nparams:\n  replicas: 4\n",
+	})
+
+	s, err := LoadFromYaml(filepath.Join(dir, "scenario.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, s.Events.Pods[0].Replicas)
+}
+
+func TestValidate_Params(t *testing.T) {
+	diags := Validate([]byte(paramsScenarioYaml))
+	assert.False(t, diags.HasErrors(), "%v", diags)
+
+	diags = Validate([]byte(paramsScenarioYaml), WithLoadOpts(WithParams(map[string]string{"replicas": "-"})))
+	require.True(t, diags.HasErrors())
+	assert.Equal(t, "params.replicas", diags[0].Path)
+}
+
+func TestWriteResultsMetadata(t *testing.T) {
+	dir := t.TempDir()
+	err := WriteResultsMetadata(dir, ResultsMetadata{SimulationID: "sim-1", Params: map[string]interface{}{"rate": 0.5}})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, ResultsMetadataFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"rate": 0.5`)
+}
+
+func TestParseParamOverrides(t *testing.T) {
+	overrides, err := ParseParamOverrides([]string{"rate=1.0", "label=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"rate": "1.0", "label": "a=b"}, overrides)
+
+	_, err = ParseParamOverrides([]string{"rate"})
+	assert.Error(t, err)
+}
@@ -8,10 +8,14 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"time"
 
+	"github.com/maczg/kube-event-generator/pkg/distribution"
 	"github.com/maczg/kube-event-generator/pkg/logger"
 	eventscheduler "github.com/maczg/kube-event-generator/pkg/scheduler"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/util/retry"
 )
 
 // PodEventType represents the type of pod event
@@ -31,14 +35,30 @@ type PodEvent struct {
 	Name string `yaml:"name" json:"name"`
 	// ArrivalTime is the time when the event arrives in the scheduler
 	ArrivalTime EventDuration `yaml:"arrivalTime" json:"arrivalTime"`
-	// EvictTime is the time when the pod should be evicted after creation
+	// EvictTime is the time the pod runs before it is evicted, measured from the EvictFrom reference point
 	EvictTime EventDuration `yaml:"evictTime" json:"evictTime"`
+	// EvictionPolicy sets the reference point of EvictTime and the start timeout of the pod
+	EvictionPolicy
 	// PodSpec is the specification of the pod to be created or deleted
 	PodSpec *v1.Pod `yaml:"podSpec" json:"podSpec"`
+	// Template is the name of a scenario template used instead of PodSpec
+	Template string `yaml:"template" json:"template,omitempty"`
+	// Replicas is the number of pods created by the event. Zero means a single pod.
+	Replicas int `yaml:"replicas" json:"replicas,omitempty"`
+	// Spacing is the time between the arrival of two consecutive replicas
+	Spacing EventDuration `yaml:"spacing" json:"spacing,omitempty"`
+	// Distribution draws the time in seconds between the arrival of two consecutive replicas, instead of Spacing
+	Distribution *distribution.Spec `yaml:"distribution" json:"distribution,omitempty"`
+	// Lifetime draws the time in seconds each replica runs before it is evicted, instead of EvictTime
+	Lifetime *distribution.Spec `yaml:"lifetime" json:"lifetime,omitempty"`
+	// Lifecycle makes the pods succeed, fail or crash-loop on their own once running
+	Lifecycle *Lifecycle `yaml:"lifecycle" json:"lifecycle,omitempty"`
+	// Overrides are applied to selected replicas on top of the pod spec
+	Overrides []ReplicaOverride `yaml:"overrides" json:"overrides,omitempty"`
 	// EventType indicates the type of pod event (create or delete)
 	EventType PodEventType `json:"eventType"`
 	// Clientset is the Kubernetes clientset used to interact with the cluster
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 }
 
 // NewCreatePodEvent creates a new pod creation event
@@ -85,7 +105,12 @@ func (e *PodEvent) Execute(ctx context.Context) error {
 			return err
 		}
 	case PodEventTypeDelete:
-		if err := e.Evict(ctx); err != nil {
+		err := e.Evict(ctx)
+		switch {
+		case apierrors.IsNotFound(err):
+			// e.g. preempted by the scheduler before its eviction
+			logger.Default().Infof("pod %s is already deleted", e.PodSpec.Name)
+		case err != nil:
 			logger.Default().Errorf("failed to evict pod %s: %v", e.PodSpec.Name, err)
 			return err
 		}
@@ -96,74 +121,158 @@ func (e *PodEvent) Execute(ctx context.Context) error {
 	return nil
 }
 
-// createAndWatch creates a pod and watches for its running state to schedule eviction if needed
+// createAndWatch creates a pod and schedules its eviction. When the eviction is measured from the start
+// of the pod, or a start timeout is set, the pod is watched in the background: the events queued after
+// it are not delayed by a pod that stays pending.
 func (e *PodEvent) createAndWatch(ctx context.Context) error {
 	clientset := e.clientset
 
-	_, err := clientset.CoreV1().Pods(e.PodSpec.Namespace).Create(ctx, e.PodSpec, metav1.CreateOptions{})
+	pod := e.PodSpec.DeepCopy()
+	stampOwnership(ctx, pod, e.Name, e.GetID())
+	_, err := clientset.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
 	if err != nil {
 		return err
 	}
 
 	logger.Default().Infof("event %s with pod %s created successfully", e.GetID(), e.PodSpec.Name)
+	trackCreated(ctx, podKind, e.PodSpec.Namespace, e.PodSpec.Name)
 
-	// If no eviction time is set, we're done
-	if e.EvictTime <= 0 {
-		return nil
+	policy := e.EvictionPolicy.withDefaults(nil)
+	evictTime := e.EvictTime.Duration()
+	if evictTime > 0 && !policy.waitsForStart(evictTime) {
+		if err := e.scheduleEviction(ctx, policy.EvictFrom); err != nil {
+			return err
+		}
 	}
 
-	// Watch for pod to become running, then schedule eviction
-	return e.watchAndScheduleEviction(ctx, clientset)
+	timeout := policy.startTimeout(evictTime)
+	if timeout == 0 {
+		return nil
+	}
+	scheduler, ok := ctx.Value(eventscheduler.SchedulerContextKey).(eventscheduler.Scheduler)
+	if !ok {
+		return errors.New("scheduler not found in context")
+	}
+	scheduler.Go(func(ctx context.Context) {
+		if err := e.watchStart(ctx, policy, timeout); err != nil {
+			logger.Default().Errorf("failed to watch the start of pod %s: %v", e.PodSpec.Name, err)
+		}
+	})
+	return nil
 }
 
-func (e *PodEvent) SetClientset(clientset *kubernetes.Clientset) {
+func (e *PodEvent) SetClientset(clientset kubernetes.Interface) {
 	e.clientset = clientset
 }
 
-// watchAndScheduleEviction watches for the pod to become running and schedules its eviction
-func (e *PodEvent) watchAndScheduleEviction(ctx context.Context, clientset *kubernetes.Clientset) error {
-	watcher, err := clientset.CoreV1().Pods(e.PodSpec.Namespace).Watch(ctx, metav1.ListOptions{
+// watchStart waits for the pod to start, then schedules its eviction if it is measured from the start.
+// A pod that does not start within the timeout is deleted, kept or marked failed according to the policy.
+func (e *PodEvent) watchStart(ctx context.Context, policy EvictionPolicy, timeout time.Duration) error {
+	pods := e.clientset.CoreV1().Pods(e.PodSpec.Namespace)
+	watcher, err := pods.Watch(ctx, metav1.ListOptions{
 		FieldSelector: "metadata.name=" + e.PodSpec.Name,
 	})
 	if err != nil {
-		logger.Default().Warnf("failed to create watcher for pod %s", e.PodSpec.Name)
 		return err
 	}
 	defer watcher.Stop()
 
-	// Set a timeout for waiting for the pod to become running
-	timeout := time.NewTimer(5 * time.Minute)
-	defer timeout.Stop()
+	started := func(pod *v1.Pod) bool {
+		if policy.EvictFrom == EvictFromScheduled {
+			return pod.Spec.NodeName != ""
+		}
+		return pod.Status.Phase == v1.PodRunning
+	}
+
+	// the pod may start before the watch starts, e.g. with the offline binder
+	if pod, err := pods.Get(ctx, e.PodSpec.Name, metav1.GetOptions{}); err == nil && started(pod) {
+		return e.onStart(ctx, policy, pod)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("context canceled while waiting for pod %s to start: %w", e.PodSpec.Name, ctx.Err())
-		case <-timeout.C:
-			return fmt.Errorf("timeout waiting for pod %s to become running", e.PodSpec.Name)
+			// the simulation is over
+			return nil
+		case <-timer.C:
+			return e.onStartTimeout(ctx, policy, timeout)
 		case event, ok := <-watcher.ResultChan():
 			if !ok {
-				err = fmt.Errorf("watch channel closed for pod %s", e.PodSpec.Name)
-				return err
+				return fmt.Errorf("watch channel closed for pod %s", e.PodSpec.Name)
+			}
+			// some clients, e.g. the fake clientset, ignore the field selector
+			pod, ok := event.Object.(*v1.Pod)
+			if !ok || pod.Name != e.PodSpec.Name {
+				continue
 			}
-			if pod, ok := event.Object.(*v1.Pod); ok {
-				if pod.Status.Phase == v1.PodRunning {
-					logger.Default().Infof("pod %s is running, scheduling eviction", pod.Name)
-					return e.scheduleEviction(ctx, pod)
-				}
+			if event.Type == watch.Deleted || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+				logger.Default().Debugf("pod %s ended before it started", pod.Name)
+				return nil
+			}
+			if started(pod) {
+				return e.onStart(ctx, policy, pod)
 			}
 		}
 	}
 }
 
-// scheduleEviction schedules the eviction of a running pod
-func (e *PodEvent) scheduleEviction(ctx context.Context, pod *v1.Pod) error {
+// onStart schedules the eviction of a started pod, if it is measured from the start.
+func (e *PodEvent) onStart(ctx context.Context, policy EvictionPolicy, pod *v1.Pod) error {
+	if !policy.waitsForStart(e.EvictTime.Duration()) {
+		return nil
+	}
+	logger.Default().Infof("pod %s is %s, scheduling eviction", pod.Name, policy.EvictFrom)
+	return e.scheduleEviction(ctx, policy.EvictFrom)
+}
+
+// onStartTimeout applies the start timeout policy to a pod that did not start in time.
+func (e *PodEvent) onStartTimeout(ctx context.Context, policy EvictionPolicy, timeout time.Duration) error {
+	switch policy.OnStartTimeout {
+	case StartTimeoutKeep:
+		logger.Default().Warnf("pod %s did not start within %s, leaving it in place", e.PodSpec.Name, timeout)
+		// the pod is never evicted, the simulation does not wait for it
+		trackEvictionEnded(ctx, podKind, e.PodSpec.Namespace, e.PodSpec.Name)
+		return nil
+	case StartTimeoutFail:
+		logger.Default().Warnf("pod %s did not start within %s, marking it failed", e.PodSpec.Name, timeout)
+		return e.markFailed(ctx, fmt.Sprintf("the pod did not start within %s", timeout))
+	default:
+		logger.Default().Warnf("pod %s did not start within %s, deleting it", e.PodSpec.Name, timeout)
+		return e.Evict(ctx)
+	}
+}
+
+// markFailed sets the phase of the pod to failed, so that schedulers ignore it.
+func (e *PodEvent) markFailed(ctx context.Context, message string) error {
+	pods := e.clientset.CoreV1().Pods(e.PodSpec.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pod, err := pods.Get(ctx, e.PodSpec.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		pod.Status.Phase = v1.PodFailed
+		pod.Status.Reason = StartTimeoutReason
+		pod.Status.Message = message
+		_, err = pods.UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// scheduleEviction schedules the eviction of the pod EvictTime after the reference point. Only the arrival
+// reference point is known in advance, the others are now.
+func (e *PodEvent) scheduleEviction(ctx context.Context, from EvictFrom) error {
 	scheduler, ok := ctx.Value(eventscheduler.SchedulerContextKey).(eventscheduler.Scheduler)
 	if !ok {
 		return errors.New("scheduler not found in context")
 	}
 
 	evictionTime := time.Since(scheduler.StartedAt()) + e.EvictTime.Duration()
+	if from == EvictFromArrival {
+		evictionTime = e.ArrivalTime.Duration() + e.EvictTime.Duration()
+	}
 	evictEvent := NewDeletePodEvent(evictionTime, e.PodSpec)
 	evictEvent.SetClientset(e.clientset)
 
@@ -171,7 +280,7 @@ func (e *PodEvent) scheduleEviction(ctx context.Context, pod *v1.Pod) error {
 		return err
 	}
 
-	logger.Default().Debugf("scheduled eviction for pod %s at %s", pod.Name, evictionTime)
+	logger.Default().Debugf("scheduled eviction for pod %s at %s", e.PodSpec.Name, evictionTime)
 	return nil
 }
 
@@ -204,7 +313,15 @@ func (e *PodEvent) UnmarshalJSON(data []byte) error {
 	e.Name = temp.Name
 	e.ArrivalTime = temp.ArrivalTime
 	e.EvictTime = temp.EvictTime
+	e.EvictionPolicy = temp.EvictionPolicy
 	e.PodSpec = temp.PodSpec
+	e.Template = temp.Template
+	e.Replicas = temp.Replicas
+	e.Spacing = temp.Spacing
+	e.Distribution = temp.Distribution
+	e.Lifetime = temp.Lifetime
+	e.Lifecycle = temp.Lifecycle
+	e.Overrides = temp.Overrides
 	e.EventType = temp.EventType
 	e.BaseEvent = eventscheduler.NewBaseEvent(temp.ArrivalTime.Duration(), temp.EvictTime.Duration())
 
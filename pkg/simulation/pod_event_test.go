@@ -1,9 +1,20 @@
 package simulation
 
 import (
+	"context"
 	"fmt"
-	"github.com/ghodss/yaml"
 	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	eventscheduler "github.com/maczg/kube-event-generator/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 // test file unmarshal pod event
@@ -29,3 +40,113 @@ func TestPodEvent_UnmarshalJSON(t *testing.T) {
 	}
 	fmt.Println(event)
 }
+
+func TestPodEvent_UnmarshalEvictionPolicy(t *testing.T) {
+	var event PodEvent
+	require.NoError(t, yaml.Unmarshal([]byte(podEventYaml+`evictFrom: scheduled
+startTimeout: 1m
+onStartTimeout: fail
+`), &event))
+	assert.Equal(t, EvictionPolicy{
+		EvictFrom:      EvictFromScheduled,
+		StartTimeout:   EventDuration(time.Minute),
+		OnStartTimeout: StartTimeoutFail,
+	}, event.EvictionPolicy)
+}
+
+// startEventScheduler starts an event scheduler and returns the context events are executed with.
+func startEventScheduler(t *testing.T) (context.Context, eventscheduler.Scheduler) {
+	scheduler := eventscheduler.New(logger.Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, scheduler.Start(ctx))
+	t.Cleanup(func() { _ = scheduler.Stop() })
+	return context.WithValue(ctx, eventscheduler.SchedulerContextKey, scheduler), scheduler
+}
+
+func newTestPodEvent(clientset *fake.Clientset, arrival, evict time.Duration, policy EvictionPolicy) *PodEvent {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+	event := NewCreatePodEvent(arrival, evict, pod)
+	event.ArrivalTime = EventDuration(arrival)
+	event.EvictTime = EventDuration(evict)
+	event.EvictionPolicy = policy
+	event.SetClientset(clientset)
+	return event
+}
+
+func TestPodEvent_EvictFrom(t *testing.T) {
+	tests := []struct {
+		from EvictFrom
+		// start updates the pod to the given state before the eviction is expected
+		start func(pod *v1.Pod)
+	}{
+		{EvictFromArrival, nil},
+		{EvictFromCreated, nil},
+		{EvictFromScheduled, func(pod *v1.Pod) { pod.Spec.NodeName = "node-1" }},
+		{EvictFromRunning, func(pod *v1.Pod) { pod.Status.Phase = v1.PodRunning }},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from), func(t *testing.T) {
+			ctx, scheduler := startEventScheduler(t)
+			clientset := fake.NewSimpleClientset()
+			event := newTestPodEvent(clientset, time.Hour, time.Hour, EvictionPolicy{EvictFrom: tt.from})
+			require.NoError(t, event.Execute(ctx))
+
+			if tt.start != nil {
+				time.Sleep(100 * time.Millisecond)
+				assert.Empty(t, scheduler.GetEvents(), "the eviction waits for the pod to start")
+
+				pod, err := clientset.CoreV1().Pods("default").Get(ctx, "pod", metav1.GetOptions{})
+				require.NoError(t, err)
+				tt.start(pod)
+				_, err = clientset.CoreV1().Pods("default").Update(ctx, pod, metav1.UpdateOptions{})
+				require.NoError(t, err)
+			}
+
+			require.Eventually(t, func() bool { return len(scheduler.GetEvents()) == 1 }, 5*time.Second, 10*time.Millisecond)
+			eviction := scheduler.GetEvents()[0].Arrival()
+			if tt.from == EvictFromArrival {
+				assert.Equal(t, 2*time.Hour, eviction, "measured from the arrival time")
+			} else {
+				assert.Less(t, eviction, 2*time.Hour, "measured from now")
+				assert.GreaterOrEqual(t, eviction, time.Hour)
+			}
+		})
+	}
+}
+
+func TestPodEvent_StartTimeout(t *testing.T) {
+	tests := []struct {
+		policy StartTimeoutPolicy
+		check  func(t *testing.T, pod *v1.Pod, err error)
+	}{
+		{StartTimeoutDelete, func(t *testing.T, pod *v1.Pod, err error) {
+			assert.True(t, apierrors.IsNotFound(err), "the pod is deleted")
+		}},
+		{StartTimeoutKeep, func(t *testing.T, pod *v1.Pod, err error) {
+			require.NoError(t, err)
+			assert.NotEqual(t, v1.PodFailed, pod.Status.Phase, "the pod is left in place")
+		}},
+		{StartTimeoutFail, func(t *testing.T, pod *v1.Pod, err error) {
+			require.NoError(t, err)
+			assert.Equal(t, v1.PodFailed, pod.Status.Phase)
+			assert.Equal(t, StartTimeoutReason, pod.Status.Reason)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			ctx, scheduler := startEventScheduler(t)
+			clientset := fake.NewSimpleClientset()
+			event := newTestPodEvent(clientset, 0, time.Hour, EvictionPolicy{
+				StartTimeout:   EventDuration(100 * time.Millisecond),
+				OnStartTimeout: tt.policy,
+			}.withDefaults(nil))
+			require.NoError(t, event.Execute(ctx), "the event does not wait for the pod to start")
+
+			time.Sleep(500 * time.Millisecond)
+			pod, err := clientset.CoreV1().Pods("default").Get(ctx, "pod", metav1.GetOptions{})
+			tt.check(t, pod, err)
+			assert.Empty(t, scheduler.GetEvents(), "a pod that did not start is never evicted")
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// highestUserDefinablePriority is the highest value of a priority class not created by Kubernetes itself.
+const highestUserDefinablePriority = 1000000000
+
+// createPriorityClasses creates the priority classes of the scenario. A class that already exists with
+// the same value is kept as it is: the value of a priority class cannot be changed.
+func (s *simulation) createPriorityClasses(ctx context.Context) error {
+	classes := s.clientset.SchedulingV1().PriorityClasses()
+	for _, pc := range s.scenario.Cluster.PriorityClasses {
+		if pc == nil {
+			continue
+		}
+		pc = pc.DeepCopy()
+		kube.Ownership{SimulationID: s.ID, Scenario: s.scenario.Metadata.Name}.Stamp(pc)
+
+		_, err := classes.Create(ctx, pc, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			existing, getErr := classes.Get(ctx, pc.Name, metav1.GetOptions{})
+			if getErr != nil {
+				return fmt.Errorf("failed to get priority class %s: %w", pc.Name, getErr)
+			}
+			if existing.Value != pc.Value {
+				return fmt.Errorf("priority class %s exists with value %d, the scenario declares %d", pc.Name, existing.Value, pc.Value)
+			}
+			s.logger.Debugf("priority class %s already exists", pc.Name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create priority class %s: %w", pc.Name, err)
+		}
+		s.logger.Infof("priority class %s created with value %d", pc.Name, pc.Value)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var priorityScenarioYaml = `
+metadata:
+  name: priority
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "1", memory: 2Gi, pods: "10" }
+  priorityClasses:
+    - metadata:
+        name: batch
+      value: 10
+    - metadata:
+        name: serving
+      value: 1000
+events:
+  pods:
+    - name: batch
+      evictTime: 1h
+      podSpec:
+        metadata:
+          name: batch
+        spec:
+          priorityClassName: batch
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests: { cpu: "1", memory: 256Mi }
+    - name: serving
+      arrivalTime: 200ms
+      evictTime: 300ms
+      podSpec:
+        metadata:
+          name: serving
+        spec:
+          priorityClassName: serving
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests: { cpu: "1", memory: 256Mi }
+`
+
+func TestSimulation_Preemption(t *testing.T) {
+	scenario, err := Load([]byte(priorityScenarioYaml))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	require.NoError(t, sim.Start(ctx))
+	require.NoError(t, ctx.Err(), "the simulation ends when the last pod is deleted")
+
+	classes, err := cluster.Clientset().SchedulingV1().PriorityClasses().List(context.Background(), metav1.ListOptions{
+		LabelSelector: kube.SimulationSelector(sim.GetID()),
+	})
+	require.NoError(t, err)
+	assert.Len(t, classes.Items, 2)
+
+	stats := sim.GetStats()
+	require.Len(t, stats.Preemptions, 1)
+	p := stats.Preemptions[0]
+	assert.Equal(t, "batch", p.Victim.Name)
+	assert.Equal(t, int32(10), p.VictimPriority)
+	assert.Equal(t, "node-1", p.Node)
+	assert.Equal(t, "serving", p.Preemptor.Name)
+	assert.Equal(t, int32(1000), p.PreemptorPriority)
+
+	priorities := make(map[int32]int)
+	for _, s := range stats.SummarizeByPriority() {
+		priorities[s.Priority] = s.Pods
+	}
+	assert.Equal(t, map[int32]int{1000: 1, 10: 1}, priorities)
+}
+
+func TestSimulation_PriorityClassConflict(t *testing.T) {
+	scenario, err := Load([]byte(priorityScenarioYaml))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	existing := scenario.Cluster.PriorityClasses[0].DeepCopy()
+	existing.Value = 20
+	_, err = cluster.Clientset().SchedulingV1().PriorityClasses().Create(ctx, existing, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	assert.ErrorContains(t, sim.Start(ctx), "priority class batch exists with value 20")
+}
@@ -0,0 +1,56 @@
+package simulation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ResultsMetadataFile is the name of the metadata file written in the results directory.
+const ResultsMetadataFile = "metadata.json"
+
+// ResultsMetadata describes the run that produced a results directory.
+type ResultsMetadata struct {
+	// SimulationID is the ID of the simulation
+	SimulationID string `json:"simulationId"`
+	// Scenario is the name of the scenario
+	Scenario string `json:"scenario"`
+	// ScenarioFile is the path of the scenario file
+	ScenarioFile string `json:"scenarioFile,omitempty"`
+	// Params are the resolved parameter values, defaults included
+	Params map[string]interface{} `json:"params,omitempty"`
+	// Seed is the seed of the scenario distributions
+	Seed *int64 `json:"seed,omitempty"`
+	// EndReason tells which end condition ended the simulation
+	EndReason EndReason `json:"endReason,omitempty"`
+	// Weights are the scheduler plugin weights set before the run, if any
+	Weights map[string]int32 `json:"weights,omitempty"`
+	// ExportedAt is the time the results were written
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+// NewResultsMetadata returns the metadata of a simulation run of the scenario.
+func NewResultsMetadata(sim Simulation, scenario *Scenario, scenarioFile string) ResultsMetadata {
+	return ResultsMetadata{
+		SimulationID: sim.GetID(),
+		Scenario:     scenario.Metadata.Name,
+		ScenarioFile: scenarioFile,
+		Params:       scenario.ParamValues(),
+		Seed:         scenario.Seed,
+		EndReason:    sim.EndReason(),
+		ExportedAt:   time.Now(),
+	}
+}
+
+// WriteResultsMetadata writes the metadata as JSON into dir.
+func WriteResultsMetadata(dir string, m ResultsMetadata) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ResultsMetadataFile), append(data, '\n'), 0644)
+}
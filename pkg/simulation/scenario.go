@@ -1,9 +1,13 @@
 package simulation
 
 import (
-	"github.com/ghodss/yaml"
-	v1 "k8s.io/api/core/v1"
 	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 )
 
 type Metadata struct {
@@ -17,35 +21,175 @@ type Metadata struct {
 
 type Events struct {
 	Pods      []PodEvent           `yaml:"pods" json:"pods"`
+	Workloads []WorkloadEvent      `yaml:"workloads" json:"workloads"`
 	Scheduler []KubeSchedulerEvent `yaml:"scheduler" json:"scheduler"`
 }
 
 type Cluster struct {
 	Nodes []*v1.Node `yaml:"nodes" json:"nodes"`
+	// PriorityClasses are created when the simulation starts, for the pods to reference
+	PriorityClasses []*schedulingv1.PriorityClass `yaml:"priorityClasses" json:"priorityClasses,omitempty"`
 }
 
 type Scenario struct {
+	// Imports are scenario files, relative to this one, whose cluster, templates and events are included
+	Imports []string `yaml:"imports" json:"imports,omitempty"`
+	// Overlays patch imported events by name
+	Overlays []Overlay `yaml:"overlays" json:"overlays,omitempty"`
+	// Defaults are merged into every pod spec of the scenario
+	Defaults *PodDefaults `yaml:"defaults" json:"defaults,omitempty"`
+	// Seed seeds the distributions of the pod events. A random seed is used if unset.
+	Seed *int64 `yaml:"seed" json:"seed,omitempty"`
+	// Eviction is the eviction policy of the pod events that do not set their own
+	Eviction *EvictionPolicy `yaml:"eviction" json:"eviction,omitempty"`
+	// Termination declares when the simulation ends, besides once every pod with an evict time is deleted
+	Termination *Termination `yaml:"termination" json:"termination,omitempty"`
+	// Params are typed parameters referenced as ${name} from string values
+	Params map[string]Param `yaml:"params" json:"params,omitempty"`
 	// Metadata contains information about the scenario
 	Metadata Metadata `yaml:"metadata" json:"metadata"`
 	// Cluster represents the cluster configuration for the scenario
 	Cluster Cluster `yaml:"cluster" json:"cluster"`
+	// Templates are named pod specs that pod events can reference
+	Templates map[string]*v1.Pod `yaml:"templates" json:"templates"`
 	// Events contains the events that will be executed in the scenario
 	Events Events `yaml:"events" json:"events"`
+
+	// paramValues are the resolved parameter values
+	paramValues map[string]interface{}
+}
+
+// EffectiveSeed returns the seed of the scenario distributions, choosing a random one if unset.
+func (s *Scenario) EffectiveSeed() int64 {
+	if s.Seed == nil {
+		seed := time.Now().UnixNano()
+		s.Seed = &seed
+	}
+	return *s.Seed
+}
+
+// ParamValues returns the resolved parameter values, defaults included.
+func (s *Scenario) ParamValues() map[string]interface{} {
+	return s.paramValues
+}
+
+// SchedulerNames returns the sorted scheduler names the pods and the scheduler events of the scenario
+// refer to, default-scheduler included for pods that set none.
+func (s *Scenario) SchedulerNames() []string {
+	seen := s.podSchedulerNames()
+	for _, e := range s.Events.Scheduler {
+		if e.Profile != "" {
+			seen[e.Profile] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// podSchedulerNames returns the set of scheduler names of the pod specs and workload templates.
+func (s *Scenario) podSchedulerNames() map[string]bool {
+	seen := make(map[string]bool)
+	add := func(spec *v1.PodSpec) {
+		if spec.SchedulerName == "" {
+			seen[v1.DefaultSchedulerName] = true
+			return
+		}
+		seen[spec.SchedulerName] = true
+	}
+
+	for _, pod := range s.Templates {
+		if pod != nil {
+			add(&pod.Spec)
+		}
+	}
+	for i := range s.Events.Pods {
+		if pod := s.Events.Pods[i].PodSpec; pod != nil {
+			add(&pod.Spec)
+		}
+	}
+	for i := range s.Events.Workloads {
+		if template := s.Events.Workloads[i].podTemplate(); template != nil {
+			add(&template.Spec)
+		}
+	}
+	return seen
+}
+
+// LoadOpt configures how a scenario is loaded
+type LoadOpt func(*loadOptions)
+
+type loadOptions struct {
+	baseDir   string
+	params    map[string]string
+	noImports bool
 }
 
-func Load(data []byte) (*Scenario, error) {
-	var s Scenario
-	err := yaml.Unmarshal(data, &s)
+// WithBaseDir sets the directory imports are resolved against. Defaults to the working directory.
+func WithBaseDir(dir string) LoadOpt {
+	return func(o *loadOptions) {
+		o.baseDir = dir
+	}
+}
+
+// WithoutImports rejects scenarios with imports, e.g. scenarios submitted by untrusted clients that
+// could otherwise read local files.
+func WithoutImports() LoadOpt {
+	return func(o *loadOptions) {
+		o.noImports = true
+	}
+}
+
+// WithParams overrides the default values of scenario parameters. Values are parsed according to the
+// parameter types.
+func WithParams(params map[string]string) LoadOpt {
+	return func(o *loadOptions) {
+		o.params = params
+	}
+}
+
+// Load parses a scenario, resolves its imports, overlays and parameters and applies its defaults.
+func Load(data []byte, opts ...LoadOpt) (*Scenario, error) {
+	o := &loadOptions{baseDir: "."}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	doc, err := decodeDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &composer{noImports: o.noImports}
+	doc, err = c.compose(doc, o.baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := resolveParams(doc, o.params)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := unmarshalDocument(doc)
 	if err != nil {
 		return nil, err
 	}
-	return &s, nil
+	s.paramValues = values
+	s.applyDefaults()
+	return s, nil
 }
 
-func LoadFromYaml(filename string) (*Scenario, error) {
+// LoadFromYaml loads a scenario file. Imports are resolved relative to the file.
+func LoadFromYaml(filename string, opts ...LoadOpt) (*Scenario, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return Load(data)
+	opts = append([]LoadOpt{WithBaseDir(filepath.Dir(filename))}, opts...)
+	return Load(data, opts...)
 }
@@ -2,6 +2,7 @@ package simulation
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
@@ -14,18 +15,25 @@ import (
 type KubeSchedulerEvent struct {
 	*scheduler.BaseEvent
 	// Name of the scheduler event
-	Name    string           `yaml:"name" json:"name"`
+	Name string `yaml:"name" json:"name"`
+	// ArrivalTime is the time when the event arrives in the scheduler
+	ArrivalTime EventDuration `yaml:"arrivalTime" json:"arrivalTime"`
+	// Weights maps plugin names to their new weight
 	Weights map[string]int32 `yaml:"weights" json:"weights"`
+	// Profile is the scheduler name of the profile to update. The first profile of the scheduler
+	// configuration is updated if empty.
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
 	manager kube.SchedulerManager
 }
 
 // NewSchedulerEvent creates a new KubeSchedulerEvent
 func NewSchedulerEvent(name string, arrivalTime time.Duration, weights map[string]int32, manager kube.SchedulerManager) *KubeSchedulerEvent {
 	return &KubeSchedulerEvent{
-		Name:      name,
-		BaseEvent: scheduler.NewBaseEvent(arrivalTime, 30),
-		Weights:   weights,
-		manager:   manager,
+		Name:        name,
+		BaseEvent:   scheduler.NewBaseEvent(arrivalTime, 30),
+		ArrivalTime: EventDuration(arrivalTime),
+		Weights:     weights,
+		manager:     manager,
 	}
 }
 
@@ -52,10 +60,30 @@ func (e *KubeSchedulerEvent) Execute(ctx context.Context) error {
 	if e.manager == nil {
 		return errors.New("scheduler manager is nil")
 	}
-	err := e.manager.UpdatePluginWeights(ctx, e.Weights)
+	var err error
+	if e.Profile != "" {
+		err = e.manager.UpdateProfilePluginWeights(ctx, e.Profile, e.Weights)
+	} else {
+		err = e.manager.UpdatePluginWeights(ctx, e.Weights)
+	}
 	if err != nil {
 		return err
 	}
 	logger.Default().Infoln("scheduler event executed successfully")
 	return nil
 }
+
+// UnmarshalJSON implements custom JSON unmarshalling for KubeSchedulerEvent.
+func (e *KubeSchedulerEvent) UnmarshalJSON(data []byte) error {
+	type Alias KubeSchedulerEvent
+	var temp Alias
+
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	*e = KubeSchedulerEvent(temp)
+	e.BaseEvent = scheduler.NewBaseEvent(temp.ArrivalTime.Duration(), 0)
+
+	return nil
+}
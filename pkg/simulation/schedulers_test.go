@@ -0,0 +1,108 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+var schedulersScenarioYaml = `
+metadata:
+  name: schedulers
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "4", memory: 8Gi, pods: "10" }
+    - metadata:
+        name: node-2
+      status:
+        allocatable: { cpu: "4", memory: 8Gi, pods: "10" }
+templates:
+  default:
+    metadata:
+      name: default
+    spec:
+      containers:
+        - name: main
+          image: nginx
+          resources:
+            requests: { cpu: 500m, memory: 256Mi }
+  binpack:
+    metadata:
+      name: binpack
+    spec:
+      schedulerName: binpack
+      containers:
+        - name: main
+          image: nginx
+          resources:
+            requests: { cpu: 500m, memory: 256Mi }
+events:
+  pods:
+    - name: default
+      template: default
+      evictTime: 400ms
+      replicas: 2
+      spacing: 50ms
+    - name: binpack
+      template: binpack
+      evictTime: 400ms
+      replicas: 3
+      spacing: 50ms
+  scheduler:
+    - name: binpack-fit
+      profile: binpack
+      weights:
+        NodeResourcesFit: 5
+`
+
+func TestScenario_SchedulerNames(t *testing.T) {
+	scenario, err := Load([]byte(schedulersScenarioYaml))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"binpack", v1.DefaultSchedulerName}, scenario.SchedulerNames())
+}
+
+func TestSimulation_Schedulers(t *testing.T) {
+	scenario, err := Load([]byte(schedulersScenarioYaml))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes, offline.WithProfiles(scenario.SchedulerNames()...))
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	require.NoError(t, sim.Start(ctx))
+	require.NoError(t, ctx.Err(), "the simulation ends when the last pod is deleted")
+
+	stats := sim.GetStats()
+	scheduled := make(map[string]int)
+	for _, s := range stats.SummarizeBySchedulers() {
+		scheduled[s.Scheduler] = s.Scheduled
+		assert.Zero(t, s.Pending, s.Scheduler)
+	}
+	assert.Equal(t, map[string]int{"binpack": 3, v1.DefaultSchedulerName: 2}, scheduled)
+
+	config, err := cluster.Scheduler().GetConfiguration(context.Background())
+	require.NoError(t, err)
+	weights := make(map[string]int32)
+	for _, profile := range config.Profiles {
+		for _, plugin := range profile.Plugins.MultiPoint.Enabled {
+			if plugin.Name == kube.NodeResourcesFit {
+				weights[kube.ProfileSchedulerName(profile)] = *plugin.Weight
+			}
+		}
+	}
+	assert.Equal(t, map[string]int32{"binpack": 5, v1.DefaultSchedulerName: 1}, weights)
+}
@@ -0,0 +1,203 @@
+package simulation
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/maczg/kube-event-generator/pkg/distribution"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// SchemaID is the identifier of the published scenario JSON Schema
+	SchemaID = "https://github.com/maczg/kube-event-generator/schema/scenario.schema.json"
+	// durationPattern matches the strings accepted by time.ParseDuration
+	durationPattern = `^(0|((\d+(\.\d*)?|\.\d+)(ns|us|µs|μs|ms|s|m|h))+)$`
+	// quantityPattern matches the strings accepted by resource.ParseQuantity
+	quantityPattern = `^[+-]?(\d+(\.\d*)?|\.\d+)(([KMGTPE]i)|[numkMGTPE]|([eE][+-]?\d+))?$`
+	// paramRefSchemaPattern matches strings referencing a scenario parameter
+	paramRefSchemaPattern = `\$\{[A-Za-z_][A-Za-z0-9_.-]*\}`
+)
+
+// modulePkgPrefix is the import path prefix of the keg packages.
+var modulePkgPrefix = strings.TrimSuffix(scenarioPkgPath, "simulation")
+
+// schemaEnums lists the allowed values of the scenario enum types.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(PodEventType("")): {string(PodEventTypeCreate), string(PodEventTypeDelete)},
+	reflect.TypeOf(distribution.Type("")): {
+		string(distribution.TypeExponential), string(distribution.TypeWeibull),
+		string(distribution.TypeUniform), string(distribution.TypeConstant),
+	},
+	reflect.TypeOf(EvictFrom("")): {
+		string(EvictFromArrival), string(EvictFromCreated), string(EvictFromScheduled), string(EvictFromRunning),
+	},
+	reflect.TypeOf(StartTimeoutPolicy("")): {string(StartTimeoutDelete), string(StartTimeoutKeep), string(StartTimeoutFail)},
+	reflect.TypeOf(PodOutcome("")):         {string(OutcomeSucceed), string(OutcomeFail), string(OutcomeCrashLoop)},
+	reflect.TypeOf(ParamType("")): {
+		string(ParamTypeInt), string(ParamTypeFloat), string(ParamTypeBool),
+		string(ParamTypeString), string(ParamTypeQuantity), string(ParamTypeDuration),
+	},
+	reflect.TypeOf(WorkloadAction("")): {string(WorkloadActionCreate), string(WorkloadActionScale), string(WorkloadActionDelete)},
+	reflect.TypeOf(WorkloadKind("")): {
+		string(WorkloadKindDeployment), string(WorkloadKindReplicaSet), string(WorkloadKindStatefulSet), string(WorkloadKindJob),
+	},
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the scenario format. It is generated from the
+// scenario types; core/v1 objects such as Pod and Node are embedded as definitions.
+func JSONSchema() map[string]interface{} {
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	root := g.schemaFor(reflect.TypeOf(Scenario{}))
+
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         SchemaID,
+		"title":       "kube-event-generator scenario",
+		"description": "A scenario describing timed events applied to a Kubernetes cluster by keg.",
+		"$ref":        root["$ref"],
+		"$defs":       g.defs,
+	}
+}
+
+// MarshalJSONSchema returns the indented JSON encoding of JSONSchema.
+func MarshalJSONSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(JSONSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// defName returns the definition name of a named struct type, e.g. io.k8s.api.core.v1.Pod.
+func defName(t reflect.Type) string {
+	if t.PkgPath() == scenarioPkgPath {
+		return "keg." + t.Name()
+	}
+	if pkg, ok := strings.CutPrefix(t.PkgPath(), modulePkgPrefix); ok {
+		return "keg." + pkg + "." + t.Name()
+	}
+	if strings.HasPrefix(t.PkgPath(), "k8s.io/") {
+		return "io.k8s." + strings.ReplaceAll(strings.TrimPrefix(t.PkgPath(), "k8s.io/"), "/", ".") + "." + t.Name()
+	}
+	return strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case eventDurationType:
+		return withParamRef(map[string]interface{}{
+			"type":        "string",
+			"pattern":     durationPattern,
+			"description": "A non-negative duration such as 500ms, 10s or 1m30s.",
+		})
+	case reflect.TypeOf(resource.Quantity{}):
+		return withParamRef(map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string", "pattern": quantityPattern},
+				map[string]interface{}{"type": "number"},
+			},
+			"description": "A resource quantity such as 100m, 1.5 or 128Mi.",
+		})
+	case paramType:
+		return map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": []interface{}{"number", "boolean", "string"}},
+				g.structRef(t),
+			},
+			"description": "A parameter: either its default value or {type, default, description}.",
+		}
+	case reflect.TypeOf(intstr.IntOrString{}):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "integer"},
+			},
+		}
+	case reflect.TypeOf(metav1.Time{}), reflect.TypeOf(metav1.MicroTime{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]interface{}{"type": "string", "enum": values}
+	}
+
+	if t.PkgPath() != scenarioPkgPath && reflect.PtrTo(t).Implements(unmarshalerType) {
+		// opaque types with custom decoding accept any value
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.structRef(t)
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schemaFor(t.Elem()),
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": g.schemaFor(t.Elem()),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return withParamRef(map[string]interface{}{"type": "boolean"})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return withParamRef(map[string]interface{}{"type": "integer"})
+	case reflect.Float32, reflect.Float64:
+		return withParamRef(map[string]interface{}{"type": "number"})
+	}
+
+	return map[string]interface{}{}
+}
+
+// withParamRef extends a non-string schema to accept ${name} parameter references.
+func withParamRef(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			schema,
+			map[string]interface{}{"type": "string", "pattern": paramRefSchemaPattern},
+		},
+	}
+}
+
+// structRef registers the definition of a struct type and returns a reference to it.
+func (g *schemaGenerator) structRef(t reflect.Type) map[string]interface{} {
+	name := defName(t)
+	ref := map[string]interface{}{"$ref": "#/$defs/" + name}
+	if _, ok := g.defs[name]; ok {
+		return ref
+	}
+
+	// register before recursing so that recursive types terminate
+	def := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+	}
+	g.defs[name] = def
+
+	properties := make(map[string]interface{})
+	for fieldName, field := range jsonFields(t) {
+		properties[fieldName] = g.schemaFor(field.Type)
+	}
+	def["properties"] = properties
+
+	return ref
+}
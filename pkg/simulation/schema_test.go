@@ -0,0 +1,50 @@
+package simulation
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := JSONSchema()
+	defs := schema["$defs"].(map[string]interface{})
+
+	assert.Equal(t, "#/$defs/keg.Scenario", schema["$ref"])
+	for _, name := range []string{"keg.Scenario", "keg.Metadata", "keg.Events", "keg.PodEvent", "keg.KubeSchedulerEvent",
+		"io.k8s.api.core.v1.Pod", "io.k8s.api.core.v1.Node"} {
+		assert.Contains(t, defs, name)
+	}
+
+	// the scheduler base event is not part of the format
+	podEvent := defs["keg.PodEvent"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Contains(t, podEvent, "arrivalTime")
+	assert.NotContains(t, podEvent, "id")
+	assert.NotContains(t, podEvent, "status")
+
+	// durations also accept parameter references
+	arrival := podEvent["arrivalTime"].(map[string]interface{})["anyOf"].([]interface{})
+	require.Len(t, arrival, 2)
+	assert.Regexp(t, arrival[1].(map[string]interface{})["pattern"], "${delay}")
+
+	pattern := regexp.MustCompile(arrival[0].(map[string]interface{})["pattern"].(string))
+	for _, d := range []string{"0", "10s", "1m30s", "500ms", "1.5h"} {
+		assert.True(t, pattern.MatchString(d), d)
+	}
+	for _, d := range []string{"", "10", "-5s", "5x"} {
+		assert.False(t, pattern.MatchString(d), d)
+	}
+}
+
+// TestJSONSchemaUpToDate fails when the published schema is stale. Run `make schema` to update it.
+func TestJSONSchemaUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../../schema/scenario.schema.json")
+	require.NoError(t, err)
+
+	generated, err := MarshalJSONSchema()
+	require.NoError(t, err)
+	assert.Equal(t, string(generated), string(published), "schema/scenario.schema.json is stale, run `make schema`")
+}
@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	kubescheduler "k8s.io/kube-scheduler/config/v1"
 	"sync"
 	"time"
 )
@@ -19,8 +20,27 @@ import (
 type Simulation interface {
 	GetID() string
 	Start(ctx context.Context) error
+	// Stop stops dispatching events and waits for Start to return, or for the context to be done
 	Stop(ctx context.Context) error
 	GetStats() *cache.Stats
+	// ReadStats calls fn with the live stats, which must not be modified nor kept after fn returns
+	ReadStats(fn func(stats *cache.Stats))
+	// ReadProgress calls fn with the live progress, whose stats and nodes must not be modified nor kept after fn returns
+	ReadProgress(fn func(p Progress))
+	// Pause stops dispatching events until Resume, the event clock included
+	Pause() error
+	// Resume dispatches the events again, delayed by the pause
+	Resume() error
+	// Paused reports whether the simulation is paused
+	Paused() bool
+	// EndReason tells why the simulation ended, empty while it runs
+	EndReason() EndReason
+	// DeleteCreated deletes the objects created by the simulation that still exist
+	DeleteCreated(ctx context.Context) error
+	// RestoreScheduler restores the scheduler configuration found when the simulation started
+	RestoreScheduler(ctx context.Context) error
+	// Namespace returns the namespace of the pods and workloads that do not set one
+	Namespace() string
 }
 
 type simulation struct {
@@ -29,18 +49,44 @@ type simulation struct {
 	startTime        time.Time
 	scenario         *Scenario
 	scheduler        scheduler.Scheduler
-	clientset        *kubernetes.Clientset
+	clientset        kubernetes.Interface
 	schedulerManager kube.SchedulerManager
 	cache            *cache.Store
 
-	mu      sync.Mutex
-	running bool
-	errCh   chan error
-	stopCh  chan struct{}
-	podMap  []string
+	mu              sync.Mutex
+	running         bool
+	started         bool
+	errCh           chan error
+	stopCh          chan struct{}
+	stopOnce        sync.Once
+	done            chan struct{}
+	endCh           chan EndReason
+	endReason       EndReason
+	conditions      []EndCondition
+	podMap          []string
+	workloadMap     []string
+	created         *createdObjects
+	schedulerConfig *kubescheduler.KubeSchedulerConfiguration
+	// namespace is set on the pods and workloads without one
+	namespace string
+	// isolated runs the simulation in its own namespace
+	isolated bool
+	// barrier, if set, holds back the event clock until the parallel simulations are set up
+	barrier *StartBarrier
 }
 
-func NewSimulation(scn *Scenario, clientset *kubernetes.Clientset, sm kube.SchedulerManager, logger *logger.Logger) Simulation {
+// SimulationOpt configures a simulation.
+type SimulationOpt func(*simulation)
+
+// WithEndConditions adds end conditions to the ones declared by the scenario. The simulation ends when
+// the first one is met.
+func WithEndConditions(conditions ...EndCondition) SimulationOpt {
+	return func(s *simulation) {
+		s.conditions = append(s.conditions, conditions...)
+	}
+}
+
+func NewSimulation(scn *Scenario, clientset kubernetes.Interface, sm kube.SchedulerManager, logger *logger.Logger, opts ...SimulationOpt) Simulation {
 	scdl := scheduler.New(logger)
 	sim := &simulation{
 		ID:               fmt.Sprintf("sim-%s-%s", scn.Metadata.Name, time.Now().Format("15_04_05_020106")),
@@ -50,10 +96,21 @@ func NewSimulation(scn *Scenario, clientset *kubernetes.Clientset, sm kube.Sched
 		scheduler:        scdl,
 		clientset:        clientset,
 		schedulerManager: sm,
-		cache:            cache.NewStore(clientset),
 		stopCh:           make(chan struct{}),
+		done:             make(chan struct{}),
 		errCh:            make(chan error, 1),
+		endCh:            make(chan EndReason, 1),
 		podMap:           make([]string, 0),
+		created:          newCreatedObjects(),
+		namespace:        metav1.NamespaceDefault,
+	}
+	for _, opt := range opts {
+		opt(sim)
+	}
+	if sim.isolated {
+		sim.cache = cache.NewStore(clientset, cache.WithNamespace(sim.namespace))
+	} else {
+		sim.cache = cache.NewStore(clientset)
 	}
 	return sim
 }
@@ -66,13 +123,44 @@ func (s *simulation) Start(ctx context.Context) error {
 		return errors.New("simulation is already running")
 	}
 	s.running = true
+	s.started = true
 	s.startTime = time.Now()
 	s.mu.Unlock()
+	defer close(s.done)
+	arrived := false
+	defer func() {
+		if s.barrier != nil && !arrived {
+			s.barrier.leave()
+		}
+	}()
 
 	if err := s.loadEvents(); err != nil {
 		s.logger.Errorln("failed to load events:", err)
+		s.setEnded(EndError)
 		return err
 	}
+	s.saveSchedulerConfig(ctx)
+	if err := s.createPriorityClasses(ctx); err != nil {
+		s.setEnded(EndError)
+		return err
+	}
+	if s.isolated {
+		if err := s.createNamespace(ctx); err != nil {
+			s.setEnded(EndError)
+			return err
+		}
+	}
+	if s.barrier != nil {
+		arrived = true
+		if !s.awaitStart(ctx) {
+			return nil
+		}
+	}
+
+	// stops the watchers once the simulation is finalized
+	ctx = withEvictions(withCreatedObjects(ctx, s.created), s.onEvictionEnded)
+	ctx, cancel := context.WithCancel(withOwnership(ctx, s.ID, s.scenario.Metadata.Name))
+	defer cancel()
 
 	s.initialize(ctx)
 	defer s.finalize(ctx)
@@ -81,34 +169,130 @@ func (s *simulation) Start(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			s.logger.Infoln("context done, stopping simulation")
-			s.mu.Lock()
-			s.running = false
-			s.mu.Unlock()
+			s.setEnded(EndContextDone)
 			return nil
 		case err := <-s.errCh:
 			s.logger.Errorf("error in simulation: %v", err)
-			s.mu.Lock()
-			s.running = false
-			s.mu.Unlock()
+			s.setEnded(EndError)
 			return err
+		case reason := <-s.endCh:
+			s.logger.Infof("end condition %s met, stopping simulation", reason)
+			s.setEnded(reason)
+			return nil
 		case <-s.stopCh:
-			s.logger.Infoln("stop signal received, stopping simulation")
-			s.mu.Lock()
-			if !s.running {
-				s.logger.Warnln("simulation is not running, nothing to stop")
-				s.mu.Unlock()
-				return nil
-			}
-			s.running = false
-			s.mu.Unlock()
+			s.logger.Infoln("stop requested, stopping simulation")
+			s.setEnded(EndStopped)
 			return nil
 		}
 	}
 }
 
+// awaitStart waits for the simulations sharing the start barrier to be set up. It returns false if the
+// simulation ended meanwhile.
+func (s *simulation) awaitStart(ctx context.Context) bool {
+	s.logger.Infoln("waiting for the parallel simulations to be set up")
+	select {
+	case <-s.barrier.arrive():
+		s.mu.Lock()
+		s.startTime = time.Now()
+		s.mu.Unlock()
+		return true
+	case <-ctx.Done():
+		s.logger.Infoln("context done, stopping simulation")
+		s.setEnded(EndContextDone)
+	case <-s.stopCh:
+		s.logger.Infoln("stop requested, stopping simulation")
+		s.setEnded(EndStopped)
+	}
+	if s.isolated {
+		s.deleteNamespace()
+	}
+	return false
+}
+
+// setEnded marks the simulation as not running anymore, ended for the given reason.
+func (s *simulation) setEnded(reason EndReason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	s.endReason = reason
+}
+
+// end asks the simulation to end for the given reason. Only the first reason is kept.
+func (s *simulation) end(reason EndReason) {
+	select {
+	case s.endCh <- reason:
+	default:
+	}
+}
+
+// EndReason tells why the simulation ended, empty while it runs.
+func (s *simulation) EndReason() EndReason {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endReason
+}
+
+// Stop stops dispatching events and waits for Start to return, or for the context to be done.
 func (s *simulation) Stop(ctx context.Context) error {
-	<-s.stopCh
-	return nil
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if !started {
+		return errors.New("simulation is not started")
+	}
+
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pause stops dispatching events until Resume. The event clock stops too, so the pending events and
+// evictions are delayed by the pause, and the end conditions are not checked. The cluster keeps going:
+// created pods are scheduled and run.
+func (s *simulation) Pause() error {
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+	if !running {
+		return errors.New("simulation is not running")
+	}
+	return s.scheduler.Pause()
+}
+
+// Resume dispatches the events again, delayed by the pause.
+func (s *simulation) Resume() error {
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+	if !running {
+		return errors.New("simulation is not running")
+	}
+	return s.scheduler.Resume()
+}
+
+// Paused reports whether the simulation is paused.
+func (s *simulation) Paused() bool {
+	return s.scheduler.Paused()
+}
+
+// saveSchedulerConfig saves the scheduler configuration, for RestoreScheduler.
+func (s *simulation) saveSchedulerConfig(ctx context.Context) {
+	if s.schedulerManager == nil {
+		return
+	}
+	config, err := s.schedulerManager.GetConfiguration(ctx)
+	if err != nil {
+		s.logger.Warnf("failed to get the scheduler configuration, it cannot be restored: %v", err)
+		return
+	}
+	s.mu.Lock()
+	s.schedulerConfig = config
+	s.mu.Unlock()
 }
 
 func (s *simulation) GetID() string {
@@ -126,6 +310,31 @@ func (s *simulation) GetStats() *cache.Stats {
 	return &stats
 }
 
+// ReadStats calls fn with the live stats, which must not be modified nor kept after fn returns. Unlike
+// GetStats, it is safe while the simulation runs.
+func (s *simulation) ReadStats(fn func(stats *cache.Stats)) {
+	s.cache.ReadStats(fn)
+}
+
+// ReadProgress calls fn with the live progress, whose stats and nodes must not be modified nor kept after
+// fn returns. The elapsed time is zero until the simulation starts.
+func (s *simulation) ReadProgress(fn func(p Progress)) {
+	progress := Progress{
+		Now:              time.Now(),
+		QueuedEvents:     len(s.scheduler.GetEvents()),
+		DispatchedEvents: int(s.scheduler.Metrics().EventsExecuted.Value()),
+		FailedEvents:     int(s.scheduler.Metrics().EventsFailed.Value()),
+	}
+	if startedAt := s.scheduler.StartedAt(); !startedAt.IsZero() {
+		progress.Elapsed = progress.Now.Sub(startedAt)
+	}
+	s.cache.ReadSnapshot(func(stats *cache.Stats, nodes []*cache.NodeStore) {
+		progress.Stats = stats
+		progress.Nodes = nodes
+		fn(progress)
+	})
+}
+
 func (s *simulation) loadEvents() error {
 	if s.scenario == nil {
 		err := fmt.Errorf("simulation %s has no events", s.ID)
@@ -135,30 +344,55 @@ func (s *simulation) loadEvents() error {
 
 	s.logger.Debugf("loading events from %s", s.scenario.Metadata.Name)
 
-	if s.scenario.Events.Pods != nil {
-		for _, event := range s.scenario.Events.Pods {
+	podEvents, err := s.scenario.ExpandPodEvents()
+	if err != nil {
+		s.logger.Errorln(err)
+		return err
+	}
+
+	for _, event := range podEvents {
+		if event.PodSpec.Namespace == "" {
+			event.PodSpec.Namespace = s.namespace
+		}
+		event.SetClientset(s.clientset)
+		if err := s.scheduler.Schedule(event); err != nil {
+			s.logger.Errorln(err)
+			return err
+		}
+		if event.Eviction() != 0 {
+			s.podMap = append(s.podMap, event.PodSpec.Name)
+		} else {
+			s.logger.Warnf("event %s has duration 0. Simulation ends before it is evicted", event.PodSpec.Name)
+		}
+	}
+
+	if s.scenario.Events.Workloads != nil {
+		for _, event := range s.scenario.Events.Workloads {
+			if !event.pinsNamespace() {
+				event.Namespace = s.namespace
+			}
 			event.SetClientset(s.clientset)
 			if err := s.scheduler.Schedule(&event); err != nil {
 				s.logger.Errorln(err)
 				return err
 			}
-			if event.Eviction() != 0 {
-				s.podMap = append(s.podMap, event.PodSpec.Name)
-			} else {
-				s.logger.Warnf("event %s has duration 0. Simulation ends before it is evicted", event.PodSpec.Name)
+			if event.Action == WorkloadActionCreate && event.EvictTime > 0 {
+				s.workloadMap = append(s.workloadMap, workloadKey(string(event.Kind), event.TargetNamespace(), event.TargetName()))
 			}
 		}
 	}
 
 	if s.scenario.Events.Scheduler != nil {
 		for _, event := range s.scenario.Events.Scheduler {
+			event.SetManager(s.schedulerManager)
 			if err := s.scheduler.Schedule(&event); err != nil {
 				s.logger.Errorln(err)
 				return err
 			}
 		}
 	}
-	s.logger.Infof("loaded %d pod events and %d scheduler events", len(s.scenario.Events.Pods), len(s.scenario.Events.Scheduler))
+	s.logger.Infof("loaded %d pod events, %d workload events and %d scheduler events",
+		len(podEvents), len(s.scenario.Events.Workloads), len(s.scenario.Events.Scheduler))
 	return nil
 }
 
@@ -166,10 +400,50 @@ func (s *simulation) initialize(ctx context.Context) {
 	go s.startCache()
 	go s.startScheduler(ctx)
 	go s.podWatcher(ctx)
+
+	conditions := append(s.scenario.Termination.Conditions(s.startTime), s.conditions...)
+	if len(conditions) > 0 {
+		go s.watchConditions(ctx, conditions)
+	}
+}
+
+// watchConditions ends the simulation when the first end condition is met.
+func (s *simulation) watchConditions(ctx context.Context, conditions []EndCondition) {
+	ticker := time.NewTicker(conditionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.scheduler.StartedAt().IsZero() || s.scheduler.Paused() {
+				// the event clock is not running
+				continue
+			}
+			var met *EndCondition
+			s.ReadProgress(func(progress Progress) {
+				for i := range conditions {
+					if conditions[i].Met(progress) {
+						met = &conditions[i]
+						return
+					}
+				}
+			})
+			if met != nil {
+				s.end(met.Reason)
+				return
+			}
+		}
+	}
 }
 
 func (s *simulation) podWatcher(ctx context.Context) {
-	watcher, err := s.clientset.CoreV1().Pods("").Watch(ctx, metav1.ListOptions{})
+	namespace := metav1.NamespaceAll
+	if s.isolated {
+		namespace = s.namespace
+	}
+	watcher, err := s.clientset.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{})
 	if err != nil {
 		s.logger.Errorf("failed to watch pods: %v", err)
 		s.errCh <- err
@@ -190,28 +464,61 @@ func (s *simulation) podWatcher(ctx context.Context) {
 				case watch.Deleted:
 					if s.isLastPodEvent(pod) {
 						s.logger.Info("last pod deleted, stopping simulation")
-						s.stopCh <- struct{}{}
+						s.end(EndAllEvicted)
 						return
 					}
 					s.logger.Debugf("pod %s deleted", pod.Name)
+				case watch.Modified:
+					// pods marked failed on start timeout are never evicted
+					if pod.Status.Phase == v1.PodFailed && pod.Status.Reason == StartTimeoutReason && s.isLastPodEvent(pod) {
+						s.logger.Info("last pod failed, stopping simulation")
+						s.end(EndAllEvicted)
+						return
+					}
 				}
 			}
 		}
 	}
 }
 
+// isLastPodEvent stops tracking the pod and reports whether it was the last pod or workload left to
+// evict. Pods not created by the pod events, e.g. the pods of workloads, are ignored.
 func (s *simulation) isLastPodEvent(pod *v1.Pod) bool {
+	return s.untrack(&s.podMap, pod.Name)
+}
+
+// onEvictionEnded ends the simulation once the last pod or workload left to evict is evicted, or will never
+// be. The pods deleted are followed by the pod watcher, the pods kept in place on start timeout are
+// reported here.
+func (s *simulation) onEvictionEnded(kind, namespace, name string) {
+	tracked, key := &s.workloadMap, workloadKey(kind, namespace, name)
+	if kind == podKind {
+		tracked, key = &s.podMap, name
+	}
+	if s.untrack(tracked, key) {
+		s.logger.Infof("%s %s/%s was the last one left to evict, stopping simulation", kind, namespace, name)
+		s.end(EndAllEvicted)
+	}
+}
+
+// untrack removes key from tracked and reports whether nothing is left to evict. It returns false if
+// key is not tracked.
+func (s *simulation) untrack(tracked *[]string, key string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for i, existingPod := range s.podMap {
-		if existingPod == pod.Name {
-			s.podMap = append(s.podMap[:i], s.podMap[i+1:]...)
-			break
+	for i, existing := range *tracked {
+		if existing == key {
+			*tracked = append((*tracked)[:i], (*tracked)[i+1:]...)
+			return len(s.podMap) == 0 && len(s.workloadMap) == 0
 		}
 	}
+	return false
+}
 
-	return len(s.podMap) == 0
+// workloadKey identifies a workload tracked until its eviction.
+func workloadKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s %s/%s", kind, namespace, name)
 }
 
 func (s *simulation) startCache() {
@@ -237,6 +544,9 @@ func (s *simulation) finalize(ctx context.Context) {
 		s.logger.Errorf("failed to stop scheduler: %v", err)
 	}
 	s.cache.Stop()
+	if s.isolated {
+		s.deleteNamespace()
+	}
 	s.logger.Infof("simulation %s finalized at %v", s.ID, time.Now())
 	ctx.Done()
 }
@@ -0,0 +1,466 @@
+package simulation
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var offlineScenarioYaml = `
+metadata:
+  name: offline
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "2", memory: 2Gi, pods: "10" }
+events:
+  pods:
+    - name: burst
+      replicas: 3
+      spacing: 100ms
+      evictTime: 300ms
+      podSpec:
+        metadata:
+          name: burst
+          namespace: default
+        spec:
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests: { cpu: 500m, memory: 256Mi }
+`
+
+func TestSimulation_Offline(t *testing.T) {
+	scenario, err := Load([]byte(offlineScenarioYaml))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	require.NoError(t, sim.Start(ctx))
+	require.NoError(t, ctx.Err(), "the simulation ends when the last pod is deleted")
+
+	summary := sim.GetStats().Summarize()
+	assert.Equal(t, 3, summary.ScheduledPods)
+	assert.Equal(t, 0, summary.PendingPods)
+
+	pods, err := cluster.Clientset().CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items, "all pods are evicted")
+}
+
+func TestSimulation_EndConditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		evictTime   string
+		termination string
+		opts        []SimulationOpt
+		reason      EndReason
+	}{
+		{"all evicted", "300ms", "", nil, EndAllEvicted},
+		{"duration", "2s", "termination: { duration: 500ms }", nil, EndDuration},
+		{"all dispatched", "", "termination: { allEventsDispatched: true }", nil, EndAllDispatched},
+		{"predicate", "2s", "", []SimulationOpt{WithEndConditions(StopWhen("first-event", func(s *cache.Stats) bool {
+			return len(s.PodEventHistory) > 0
+		}))}, "first-event"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evictTime := ""
+			if tt.evictTime != "" {
+				evictTime = "evictTime: " + tt.evictTime
+			}
+			scenarioYaml := strings.Replace(offlineScenarioYaml, "evictTime: 300ms", evictTime, 1) + tt.termination
+			scenario, err := Load([]byte(scenarioYaml))
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+			require.NoError(t, err)
+			cluster.Start(ctx)
+
+			sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default(), tt.opts...)
+			require.NoError(t, sim.Start(ctx))
+			require.NoError(t, ctx.Err(), "the simulation ends before the test timeout")
+			assert.Equal(t, tt.reason, sim.EndReason())
+		})
+	}
+}
+
+func TestSimulation_EndsOnTrackedEvictions(t *testing.T) {
+	scenario, err := Load([]byte(offlineScenarioYaml + `
+  workloads:
+    - name: web
+      arrivalTime: 100ms
+      evictTime: 1s
+      deployment:
+        metadata:
+          name: web
+        spec:
+          selector:
+            matchLabels:
+              app: web
+          template:
+            metadata:
+              labels:
+                app: web
+            spec:
+              containers:
+                - name: web
+                  image: nginx
+`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	// a pod the scenario does not create
+	pods := cluster.Clientset().CoreV1().Pods("default")
+	_, err = pods.Create(ctx, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bystander"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	go func() {
+		// once the pods of the scenario are evicted, before the workload is
+		time.Sleep(800 * time.Millisecond)
+		assert.NoError(t, pods.Delete(ctx, "bystander", metav1.DeleteOptions{}))
+	}()
+	started := time.Now()
+	require.NoError(t, sim.Start(ctx))
+	require.NoError(t, ctx.Err())
+	assert.Equal(t, EndAllEvicted, sim.EndReason())
+	assert.GreaterOrEqual(t, time.Since(started), time.Second, "the simulation waits for the workload eviction")
+
+	_, err = cluster.Clientset().AppsV1().Deployments("default").Get(ctx, "web", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the workload is evicted before the simulation ends")
+}
+
+func TestSimulation_EndsWithFailedWorkloads(t *testing.T) {
+	scenario, err := Load([]byte(offlineScenarioYaml + `
+  workloads:
+    - name: web
+      kind: StatefulSet
+      evictTime: 1h
+      deployment:
+        metadata:
+          name: web
+        spec:
+          selector:
+            matchLabels:
+              app: web
+          template:
+            metadata:
+              labels:
+                app: web
+            spec:
+              containers:
+                - name: web
+                  image: nginx
+`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	require.NoError(t, sim.Start(ctx))
+	require.NoError(t, ctx.Err(), "the simulation does not wait for a workload that was not created")
+	assert.Equal(t, EndAllEvicted, sim.EndReason())
+}
+
+func TestSimulation_EndsWithKeptPods(t *testing.T) {
+	scenario, err := Load([]byte(offlineScenarioYaml + `
+    - name: too-big
+      evictTime: 1h
+      startTimeout: 200ms
+      onStartTimeout: keep
+      podSpec:
+        metadata:
+          name: too-big
+          namespace: default
+        spec:
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests: { cpu: "64" }
+`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	require.NoError(t, sim.Start(ctx))
+	require.NoError(t, ctx.Err(), "the simulation does not wait for the kept pod")
+	assert.Equal(t, EndAllEvicted, sim.EndReason())
+
+	_, err = cluster.Clientset().CoreV1().Pods("default").Get(ctx, "too-big", metav1.GetOptions{})
+	assert.NoError(t, err, "the pod is kept")
+}
+
+func TestSimulation_StopAndCleanup(t *testing.T) {
+	scenario, err := Load([]byte(strings.Replace(offlineScenarioYaml, "evictTime: 300ms", "evictTime: 1h", 1) + `
+  scheduler:
+    - name: pack
+      weights:
+        NodeResourcesFit: 7
+`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+	defaults, err := cluster.Scheduler().GetPluginWeights(ctx)
+	require.NoError(t, err)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	require.Error(t, sim.Stop(ctx), "the simulation is not started")
+
+	ended := make(chan error, 1)
+	go func() { ended <- sim.Start(ctx) }()
+	require.Eventually(t, func() bool {
+		pods, err := cluster.Clientset().CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+		return err == nil && len(pods.Items) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+	pods, err := cluster.Clientset().CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		LabelSelector: kube.SimulationSelector(sim.GetID()),
+	})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 3, "the pods are labelled with the simulation")
+	assert.Equal(t, "burst", pods.Items[0].Labels[kube.EventNameLabel])
+	assert.Equal(t, "offline", pods.Items[0].Annotations[kube.ScenarioLabel])
+	assert.NotEmpty(t, pods.Items[0].Labels[kube.EventIDLabel])
+
+	require.NoError(t, sim.Stop(ctx))
+	require.NoError(t, <-ended)
+	assert.Equal(t, EndStopped, sim.EndReason())
+	require.NoError(t, sim.Stop(ctx), "stopping an ended simulation returns at once")
+
+	weights, err := cluster.Scheduler().GetPluginWeights(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), weights["NodeResourcesFit"])
+	require.NoError(t, sim.RestoreScheduler(ctx))
+	weights, err = cluster.Scheduler().GetPluginWeights(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, defaults, weights)
+
+	require.NoError(t, sim.DeleteCreated(ctx))
+	pods, err = cluster.Clientset().CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items, "the pods created by the simulation are deleted")
+	require.NoError(t, sim.DeleteCreated(ctx), "deleted objects are skipped")
+}
+
+func TestSimulation_PauseResume(t *testing.T) {
+	scenario, err := Load([]byte(`
+metadata:
+  name: pause
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "2", memory: 2Gi, pods: "10" }
+events:
+  pods:
+    - name: late
+      arrivalTime: 300ms
+      evictTime: 100ms
+      podSpec:
+        metadata:
+          name: late
+        spec:
+          containers:
+            - name: main
+              image: nginx
+`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	require.Error(t, sim.Pause(), "the simulation is not running")
+
+	ended := make(chan error, 1)
+	go func() { ended <- sim.Start(ctx) }()
+	require.Eventually(t, func() bool { return sim.Pause() == nil }, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, sim.Paused())
+
+	time.Sleep(600 * time.Millisecond)
+	pods, err := cluster.Clientset().CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items, "no event is dispatched while paused")
+
+	require.NoError(t, sim.Resume())
+	assert.False(t, sim.Paused())
+	require.NoError(t, <-ended)
+	assert.Equal(t, EndAllEvicted, sim.EndReason())
+
+	sim.ReadStats(func(stats *cache.Stats) {
+		assert.Len(t, stats.PendingDurations, 1)
+	})
+}
+
+func TestSimulation_ReadProgress(t *testing.T) {
+	scenario, err := Load([]byte(`
+metadata:
+  name: progress
+cluster:
+  nodes:
+    - metadata:
+        name: node-2
+      status:
+        allocatable: { cpu: "2", memory: 2Gi, pods: "10" }
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "2", memory: 2Gi, pods: "10" }
+events:
+  pods:
+    - name: web
+      arrivalTime: 0s
+      evictTime: 1h
+      podSpec:
+        metadata:
+          name: web
+        spec:
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests: { cpu: "1" }
+`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default())
+	sim.ReadProgress(func(p Progress) {
+		assert.Zero(t, p.Elapsed, "the event clock starts with the simulation")
+		assert.Zero(t, p.DispatchedEvents)
+	})
+
+	ended := make(chan error, 1)
+	go func() { ended <- sim.Start(ctx) }()
+
+	// the pod runs on a node and its eviction is queued
+	require.Eventually(t, func() bool {
+		running := false
+		sim.ReadProgress(func(p Progress) {
+			running = p.DispatchedEvents == 1 && p.QueuedEvents == 1 && len(p.Nodes) == 2 &&
+				len(p.Nodes[0].RunningPods)+len(p.Nodes[1].RunningPods) == 1
+		})
+		return running
+	}, 5*time.Second, 20*time.Millisecond)
+	sim.ReadProgress(func(p Progress) {
+		assert.Positive(t, p.Elapsed)
+		assert.Zero(t, p.FailedEvents)
+		assert.Equal(t, "node-1", p.Nodes[0].Node.Name, "nodes are sorted by name")
+		assert.NotNil(t, p.Stats)
+	})
+
+	require.NoError(t, sim.Stop(ctx))
+	require.NoError(t, <-ended)
+}
+
+func TestSimulation_StartBarrier(t *testing.T) {
+	scenario := func() *Scenario {
+		scenario, err := Load([]byte(strings.Replace(offlineScenarioYaml, "evictTime: 300ms", "evictTime: 1h", 1)))
+		require.NoError(t, err)
+		return scenario
+	}
+	newCluster := func(ctx context.Context) *offline.Cluster {
+		cluster, err := offline.NewCluster(logger.Default(), scenario().Cluster.Nodes)
+		require.NoError(t, err)
+		cluster.Start(ctx)
+		return cluster
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	barrier := NewStartBarrier(2)
+	first, second := newCluster(ctx), newCluster(ctx)
+	sims := []Simulation{
+		NewSimulation(scenario(), first.Clientset(), first.Scheduler(), logger.Default(), WithStartBarrier(barrier)),
+		NewSimulation(scenario(), second.Clientset(), second.Scheduler(), logger.Default(), WithStartBarrier(barrier)),
+	}
+
+	ended := make(chan error, 2)
+	go func() { ended <- sims[0].Start(ctx) }()
+	time.Sleep(300 * time.Millisecond)
+	pods, err := first.Clientset().CoreV1().Pods("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items, "no event is dispatched until every simulation is set up")
+	assert.True(t, sims[0].(*simulation).scheduler.StartedAt().IsZero())
+
+	go func() { ended <- sims[1].Start(ctx) }()
+	require.Eventually(t, func() bool {
+		return !sims[0].(*simulation).scheduler.StartedAt().IsZero() && !sims[1].(*simulation).scheduler.StartedAt().IsZero()
+	}, 5*time.Second, 10*time.Millisecond)
+	skew := sims[0].(*simulation).scheduler.StartedAt().Sub(sims[1].(*simulation).scheduler.StartedAt())
+	assert.Less(t, skew.Abs(), 50*time.Millisecond, "the event clocks start together")
+
+	for _, sim := range sims {
+		require.NoError(t, sim.Stop(ctx))
+		require.NoError(t, <-ended)
+	}
+}
+
+func TestSimulation_StartBarrierStopped(t *testing.T) {
+	scenario, err := Load([]byte(offlineScenarioYaml))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cluster, err := offline.NewCluster(logger.Default(), scenario.Cluster.Nodes)
+	require.NoError(t, err)
+	cluster.Start(ctx)
+
+	// the other simulation never starts
+	sim := NewSimulation(scenario, cluster.Clientset(), cluster.Scheduler(), logger.Default(), WithStartBarrier(NewStartBarrier(2)))
+	ended := make(chan error, 1)
+	go func() { ended <- sim.Start(ctx) }()
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, sim.Stop(ctx))
+	require.NoError(t, <-ended)
+	assert.Equal(t, EndStopped, sim.EndReason())
+}
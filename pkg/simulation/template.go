@@ -0,0 +1,185 @@
+package simulation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/distribution"
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	v1 "k8s.io/api/core/v1"
+)
+
+// ReplicaOverride customizes some replicas of a pod event.
+type ReplicaOverride struct {
+	// Indices are the replica indices (starting at 0) the override applies to. Empty means all replicas.
+	Indices []int `yaml:"indices" json:"indices,omitempty"`
+	// Resources replaces the resources of the first container
+	Resources *v1.ResourceRequirements `yaml:"resources" json:"resources,omitempty"`
+	// Labels are merged into the pod labels
+	Labels map[string]string `yaml:"labels" json:"labels,omitempty"`
+	// Annotations are merged into the pod annotations
+	Annotations map[string]string `yaml:"annotations" json:"annotations,omitempty"`
+	// NodeSelector replaces the pod node selector
+	NodeSelector map[string]string `yaml:"nodeSelector" json:"nodeSelector,omitempty"`
+}
+
+// appliesTo reports whether the override applies to the replica with the given index.
+func (o *ReplicaOverride) appliesTo(index int) bool {
+	if len(o.Indices) == 0 {
+		return true
+	}
+	for _, i := range o.Indices {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// podOpts converts the override into object factory options.
+func (o *ReplicaOverride) podOpts() []kube.PodOpt {
+	opts := make([]kube.PodOpt, 0)
+	if o.Resources != nil {
+		opts = append(opts, kube.WithPodResourceRequirements(*o.Resources))
+	}
+	if o.Labels != nil {
+		opts = append(opts, kube.WithPodLabels(o.Labels))
+	}
+	if o.Annotations != nil {
+		opts = append(opts, kube.WithPodAnnotations(o.Annotations))
+	}
+	if o.NodeSelector != nil {
+		opts = append(opts, kube.WithPodNodeSelector(o.NodeSelector))
+	}
+	return opts
+}
+
+// ExpandPodEvents expands templates and replicas of the scenario pod events into one event per pod.
+// Replica i arrives at arrivalTime + i*spacing and is named <name>-<i>. Names are made unique across
+// the whole scenario by appending a numeric suffix to names that were already used.
+// Inter-arrival and lifetime distributions draw from random sources derived from the scenario seed
+// and the event, so the same seed always expands to the same events.
+func (s *Scenario) ExpandPodEvents() ([]*PodEvent, error) {
+	events := make([]*PodEvent, 0, len(s.Events.Pods))
+	used := make(map[string]bool)
+	seed := s.EffectiveSeed()
+
+	for i := range s.Events.Pods {
+		event := &s.Events.Pods[i]
+
+		base, err := s.resolvePodSpec(event)
+		if err != nil {
+			return nil, fmt.Errorf("pod event %d (%s): %w", i, event.Name, err)
+		}
+
+		baseName := base.Name
+		if baseName == "" {
+			baseName = event.Name
+		}
+		if baseName == "" {
+			baseName = fmt.Sprintf("pod-%d", i)
+		}
+
+		replicas := event.Replicas
+		if replicas < 1 {
+			replicas = 1
+		}
+
+		var interArrival, lifetime distribution.Distribution
+		if event.Distribution != nil {
+			if interArrival, err = distribution.New(*event.Distribution, distribution.NewRand(seed, i, event.Name, "arrival")); err != nil {
+				return nil, fmt.Errorf("pod event %d (%s): distribution: %w", i, event.Name, err)
+			}
+		}
+		if event.Lifetime != nil {
+			if lifetime, err = distribution.New(*event.Lifetime, distribution.NewRand(seed, i, event.Name, "lifetime")); err != nil {
+				return nil, fmt.Errorf("pod event %d (%s): lifetime: %w", i, event.Name, err)
+			}
+		}
+
+		arrival := event.ArrivalTime.Duration()
+		for r := 0; r < replicas; r++ {
+			name := baseName
+			if event.Replicas > 1 {
+				name = kube.ObjectFactory.GeneratePodName(baseName, r)
+			}
+			// delete events target pods created by earlier events, only created pods get a unique name
+			if event.EventType != PodEventTypeDelete {
+				name = uniqueName(name, used)
+			}
+
+			opts := make([]kube.PodOpt, 0)
+			for _, override := range event.Overrides {
+				if override.appliesTo(r) {
+					opts = append(opts, override.podOpts()...)
+				}
+			}
+
+			pod := kube.ObjectFactory.NewPodFromTemplate(base, name, opts...)
+			event.Lifecycle.annotate(&pod.ObjectMeta)
+			if r > 0 {
+				if interArrival != nil {
+					arrival += seconds(interArrival.Next())
+				} else {
+					arrival += event.Spacing.Duration()
+				}
+			}
+			evict := event.EvictTime.Duration()
+			if lifetime != nil {
+				evict = seconds(lifetime.Next())
+			}
+
+			expanded := NewCreatePodEvent(arrival, evict, pod)
+			if event.Name != "" {
+				expanded.Name = event.Name
+			}
+			expanded.ArrivalTime = EventDuration(arrival)
+			expanded.EvictTime = EventDuration(evict)
+			expanded.EvictionPolicy = event.EvictionPolicy.withDefaults(s.Eviction)
+			expanded.Lifecycle = event.Lifecycle
+			if event.EventType == PodEventTypeDelete {
+				expanded.EventType = PodEventTypeDelete
+				expanded.BaseEvent.SetEviction(0)
+			}
+			events = append(events, expanded)
+		}
+	}
+
+	return events, nil
+}
+
+// seconds converts a sample in seconds to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// resolvePodSpec returns the pod spec of the event, either inline or from a named template.
+func (s *Scenario) resolvePodSpec(event *PodEvent) (*v1.Pod, error) {
+	if event.Template == "" {
+		if event.PodSpec == nil {
+			return nil, fmt.Errorf("pod spec is nil")
+		}
+		return event.PodSpec, nil
+	}
+
+	if event.PodSpec != nil {
+		return nil, fmt.Errorf("both podSpec and template %q are set", event.Template)
+	}
+
+	template, ok := s.Templates[event.Template]
+	if !ok || template == nil {
+		return nil, fmt.Errorf("template %q not found", event.Template)
+	}
+	return template, nil
+}
+
+// uniqueName returns name, or name-dup<n> with the lowest n >= 2 not used yet, and marks it as used.
+// The dup suffix tells duplicates apart from the replica names, name-<index>.
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s-dup%d", name, n)
+	}
+	used[candidate] = true
+	return candidate
+}
@@ -0,0 +1,192 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var templateScenarioYaml = `
+metadata:
+  name: template-scenario
+templates:
+  small:
+    metadata:
+      name: small
+      namespace: default
+      labels:
+        tier: small
+    spec:
+      containers:
+        - name: main
+          image: nginx
+          resources:
+            requests:
+              cpu: 100m
+              memory: 128Mi
+events:
+  pods:
+    - name: burst
+      template: small
+      arrivalTime: 10s
+      evictTime: 30s
+      replicas: 3
+      spacing: 2s
+      overrides:
+        - indices: [2]
+          resources:
+            requests:
+              cpu: 500m
+              memory: 256Mi
+    - name: single
+      template: small
+      arrivalTime: 1s
+    - name: single
+      template: small
+      arrivalTime: 2s
+    - name: single-removed
+      template: small
+      eventType: delete
+      arrivalTime: 3s
+`
+
+func TestScenario_ExpandPodEvents(t *testing.T) {
+	scenario, err := Load([]byte(templateScenarioYaml))
+	require.NoError(t, err)
+
+	events, err := scenario.ExpandPodEvents()
+	require.NoError(t, err)
+	require.Len(t, events, 6)
+
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.PodSpec.Name)
+	}
+	assert.Equal(t, []string{"small-0", "small-1", "small-2", "small", "small-dup2", "small"}, names,
+		"duplicates get a dup suffix, delete events keep their target")
+
+	assert.Equal(t, 10*time.Second, events[0].Arrival())
+	assert.Equal(t, 12*time.Second, events[1].Arrival())
+	assert.Equal(t, 14*time.Second, events[2].Arrival())
+	assert.Equal(t, 30*time.Second, events[2].Eviction())
+	assert.Equal(t, "burst", events[2].Name)
+
+	assert.Equal(t, "100m", events[1].PodSpec.Spec.Containers[0].Resources.Requests.Cpu().String())
+	assert.Equal(t, "500m", events[2].PodSpec.Spec.Containers[0].Resources.Requests.Cpu().String())
+	assert.Equal(t, "small", events[2].PodSpec.Labels["tier"])
+
+	// the template itself is not modified by the overrides
+	assert.Equal(t, "100m", scenario.Templates["small"].Spec.Containers[0].Resources.Requests.Cpu().String())
+}
+
+func TestScenario_ExpandPodEventsMissingTemplate(t *testing.T) {
+	scenario := &Scenario{Events: Events{Pods: []PodEvent{{Name: "broken", Template: "missing"}}}}
+
+	_, err := scenario.ExpandPodEvents()
+	assert.ErrorContains(t, err, `template "missing" not found`)
+}
+
+var seededScenarioYaml = `
+metadata:
+  name: seeded
+events:
+  pods:
+    - name: poisson
+      arrivalTime: 5s
+      replicas: 20
+      distribution:
+        type: exponential
+        rate: 0.5
+      lifetime:
+        type: uniform
+        min: 10
+        max: 20
+      podSpec:
+        spec:
+          containers:
+            - name: main
+              image: nginx
+`
+
+func TestScenario_ExpandPodEventsSeeded(t *testing.T) {
+	expand := func(seed int64) []*PodEvent {
+		scenario, err := Load([]byte(seededScenarioYaml))
+		require.NoError(t, err)
+		scenario.Seed = &seed
+		events, err := scenario.ExpandPodEvents()
+		require.NoError(t, err)
+		require.Len(t, events, 20)
+		return events
+	}
+
+	first, again, other := expand(42), expand(42), expand(43)
+	assert.Equal(t, 5*time.Second, first[0].Arrival())
+
+	same, different := true, false
+	for i := range first {
+		if i > 0 {
+			assert.Greater(t, first[i].Arrival(), first[i-1].Arrival())
+		}
+		assert.GreaterOrEqual(t, first[i].Eviction(), 10*time.Second)
+		assert.LessOrEqual(t, first[i].Eviction(), 20*time.Second)
+
+		same = same && first[i].Arrival() == again[i].Arrival() && first[i].Eviction() == again[i].Eviction()
+		different = different || first[i].Arrival() != other[i].Arrival()
+	}
+	assert.True(t, same, "the same seed gives the same events")
+	assert.True(t, different, "another seed gives other events")
+}
+
+func TestScenario_ExpandPodEventsLifecycle(t *testing.T) {
+	scenario, err := Load([]byte(`
+metadata:
+  name: lifecycle
+events:
+  pods:
+    - name: batch
+      arrivalTime: 1s
+      replicas: 2
+      lifecycle:
+        outcome: fail
+        after: 30s
+        exitCode: 137
+      podSpec:
+        metadata:
+          name: batch
+        spec:
+          containers:
+            - name: main
+              image: busybox
+    - name: web
+      arrivalTime: 1s
+      podSpec:
+        metadata:
+          name: web
+        spec:
+          containers:
+            - name: main
+              image: nginx
+`))
+	require.NoError(t, err)
+
+	events, err := scenario.ExpandPodEvents()
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	for _, e := range events[:2] {
+		assert.Equal(t, map[string]string{
+			kube.OutcomeAnnotation:      "fail",
+			kube.OutcomeAfterAnnotation: "30s",
+			kube.ExitCodeAnnotation:     "137",
+		}, e.PodSpec.Annotations)
+
+		lifecycle, ok, err := kube.LifecycleOf(e.PodSpec)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, kube.PodLifecycle{Outcome: kube.OutcomeFail, After: 30 * time.Second, ExitCode: 137}, lifecycle)
+	}
+	assert.Empty(t, events[2].PodSpec.Annotations)
+}
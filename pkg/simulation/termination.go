@@ -0,0 +1,180 @@
+package simulation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+)
+
+// conditionCheckInterval is the interval end conditions are checked at.
+const conditionCheckInterval = 200 * time.Millisecond
+
+// EndReason tells why a simulation ended: the name of the end condition met, or one of the reasons below.
+type EndReason string
+
+const (
+	// EndAllEvicted ends a simulation once every pod with an evict time has been deleted
+	EndAllEvicted EndReason = "all-evicted"
+	// EndDuration ends a simulation once its simulated time reaches a duration
+	EndDuration EndReason = "duration"
+	// EndDeadline ends a simulation at a wall clock time
+	EndDeadline EndReason = "deadline"
+	// EndAllDispatched ends a simulation once all its events, evictions included, have been dispatched
+	EndAllDispatched EndReason = "all-dispatched"
+	// EndPendingIdle ends a simulation once no pod has been pending for a while
+	EndPendingIdle EndReason = "pending-idle"
+	// EndContextDone is reported when the context of the simulation is done
+	EndContextDone EndReason = "context-done"
+	// EndStopped is reported when the simulation is stopped
+	EndStopped EndReason = "stopped"
+	// EndError is reported when the simulation fails
+	EndError EndReason = "error"
+)
+
+// Progress is the state of a running simulation, checked by the end conditions.
+type Progress struct {
+	// Elapsed is the simulated time, measured on the event clock
+	Elapsed time.Duration
+	// Now is the wall clock time of the check
+	Now time.Time
+	// QueuedEvents is the number of events not dispatched yet, evictions of running pods included
+	QueuedEvents int
+	// DispatchedEvents is the number of events dispatched so far
+	DispatchedEvents int
+	// FailedEvents is the number of dispatched events whose execution failed
+	FailedEvents int
+	// Stats are the cluster statistics so far. They must not be modified nor kept.
+	Stats *cache.Stats
+	// Nodes are the cluster nodes, sorted by name. They must not be modified nor kept.
+	Nodes []*cache.NodeStore
+}
+
+// EndCondition ends a simulation when it is met.
+type EndCondition struct {
+	// Reason is reported when the condition ends the simulation
+	Reason EndReason
+	// Met reports whether the simulation should end
+	Met func(p Progress) bool
+}
+
+// AfterDuration ends the simulation once its simulated time reaches d.
+func AfterDuration(d time.Duration) EndCondition {
+	return EndCondition{Reason: EndDuration, Met: func(p Progress) bool {
+		return p.Elapsed >= d
+	}}
+}
+
+// Deadline ends the simulation at the wall clock time t.
+func Deadline(t time.Time) EndCondition {
+	return EndCondition{Reason: EndDeadline, Met: func(p Progress) bool {
+		return !p.Now.Before(t)
+	}}
+}
+
+// AllEventsDispatched ends the simulation once its event queue is empty. Evictions are queued when their
+// pod starts running, so pods still pending when the last event is dispatched are not evicted.
+func AllEventsDispatched() EndCondition {
+	return EndCondition{Reason: EndAllDispatched, Met: func(p Progress) bool {
+		return p.QueuedEvents == 0
+	}}
+}
+
+// PendingIdle ends the simulation once the pending queue has been empty for d, counted from the last pod
+// leaving it. It is not met before a pod has been pending.
+func PendingIdle(d time.Duration) EndCondition {
+	return EndCondition{Reason: EndPendingIdle, Met: func(p Progress) bool {
+		history := p.Stats.PendingQHistory
+		if len(history) == 0 {
+			return false
+		}
+		last := history[len(history)-1]
+		return last.Value == 0 && p.Now.Sub(last.At) >= d
+	}}
+}
+
+// StopWhen ends the simulation, reporting name, once the predicate over the cluster statistics holds.
+func StopWhen(name string, predicate func(stats *cache.Stats) bool) EndCondition {
+	return EndCondition{Reason: EndReason(name), Met: func(p Progress) bool {
+		return predicate(p.Stats)
+	}}
+}
+
+// Termination declares the end conditions of a scenario. The simulation ends when the first one is met,
+// or once every pod with an evict time has been deleted.
+type Termination struct {
+	// Duration ends the simulation once its simulated time reaches it
+	Duration EventDuration `yaml:"duration" json:"duration,omitempty"`
+	// Timeout ends the simulation once the wall clock time since its start, setup included, reaches it
+	Timeout EventDuration `yaml:"timeout" json:"timeout,omitempty"`
+	// AllEventsDispatched ends the simulation once all its events, evictions included, are dispatched
+	AllEventsDispatched bool `yaml:"allEventsDispatched" json:"allEventsDispatched,omitempty"`
+	// PendingIdle ends the simulation once no pod has been pending for it
+	PendingIdle EventDuration `yaml:"pendingIdle" json:"pendingIdle,omitempty"`
+}
+
+// Conditions returns the end conditions declared by t. The timeout is relative to start.
+func (t *Termination) Conditions(start time.Time) []EndCondition {
+	if t == nil {
+		return nil
+	}
+	conditions := make([]EndCondition, 0)
+	if t.Duration > 0 {
+		conditions = append(conditions, AfterDuration(t.Duration.Duration()))
+	}
+	if t.Timeout > 0 {
+		conditions = append(conditions, Deadline(start.Add(t.Timeout.Duration())))
+	}
+	if t.AllEventsDispatched {
+		conditions = append(conditions, AllEventsDispatched())
+	}
+	if t.PendingIdle > 0 {
+		conditions = append(conditions, PendingIdle(t.PendingIdle.Duration()))
+	}
+	return conditions
+}
+
+// Merge returns t with the fields set in o overriding its own.
+func (t *Termination) Merge(o *Termination) *Termination {
+	merged := &Termination{}
+	if t != nil {
+		*merged = *t
+	}
+	if o == nil {
+		return merged
+	}
+	if o.Duration != 0 {
+		merged.Duration = o.Duration
+	}
+	if o.Timeout != 0 {
+		merged.Timeout = o.Timeout
+	}
+	if o.AllEventsDispatched {
+		merged.AllEventsDispatched = true
+	}
+	if o.PendingIdle != 0 {
+		merged.PendingIdle = o.PendingIdle
+	}
+	return merged
+}
+
+// String describes the end conditions of t.
+func (t *Termination) String() string {
+	if t == nil {
+		return "all pods evicted"
+	}
+	s := "all pods evicted"
+	if t.Duration > 0 {
+		s += fmt.Sprintf(", duration %s", t.Duration.Duration())
+	}
+	if t.Timeout > 0 {
+		s += fmt.Sprintf(", timeout %s", t.Timeout.Duration())
+	}
+	if t.AllEventsDispatched {
+		s += ", all events dispatched"
+	}
+	if t.PendingIdle > 0 {
+		s += fmt.Sprintf(", no pending pod for %s", t.PendingIdle.Duration())
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndConditions(t *testing.T) {
+	now := time.Now()
+	pendingSince := func(value int, at time.Time) *cache.Stats {
+		return &cache.Stats{PendingQHistory: []cache.Record[int]{{At: at, Value: value}}}
+	}
+
+	tests := []struct {
+		name      string
+		condition EndCondition
+		progress  Progress
+		met       bool
+	}{
+		{"duration not reached", AfterDuration(time.Minute), Progress{Elapsed: 59 * time.Second}, false},
+		{"duration reached", AfterDuration(time.Minute), Progress{Elapsed: time.Minute}, true},
+		{"before deadline", Deadline(now), Progress{Now: now.Add(-time.Second)}, false},
+		{"at deadline", Deadline(now), Progress{Now: now}, true},
+		{"events queued", AllEventsDispatched(), Progress{QueuedEvents: 1}, false},
+		{"events dispatched", AllEventsDispatched(), Progress{}, true},
+		{"never pending", PendingIdle(time.Second), Progress{Now: now, Stats: &cache.Stats{}}, false},
+		{"pods pending", PendingIdle(time.Second), Progress{Now: now, Stats: pendingSince(2, now.Add(-time.Minute))}, false},
+		{"idle too short", PendingIdle(time.Second), Progress{Now: now, Stats: pendingSince(0, now.Add(-time.Millisecond))}, false},
+		{"idle", PendingIdle(time.Second), Progress{Now: now, Stats: pendingSince(0, now.Add(-time.Second))}, true},
+		{"predicate", StopWhen("one-pending", func(s *cache.Stats) bool { return s.PendingQHistory[0].Value == 1 }),
+			Progress{Stats: pendingSince(1, now)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.met, tt.condition.Met(tt.progress))
+		})
+	}
+	assert.Equal(t, EndReason("one-pending"), StopWhen("one-pending", nil).Reason)
+}
+
+func TestTermination_Conditions(t *testing.T) {
+	var none *Termination
+	assert.Empty(t, none.Conditions(time.Now()))
+
+	start := time.Now()
+	termination := &Termination{
+		Duration:            EventDuration(time.Minute),
+		Timeout:             EventDuration(time.Hour),
+		AllEventsDispatched: true,
+		PendingIdle:         EventDuration(30 * time.Second),
+	}
+	conditions := termination.Conditions(start)
+	reasons := make([]EndReason, 0, len(conditions))
+	for _, c := range conditions {
+		reasons = append(reasons, c.Reason)
+	}
+	assert.Equal(t, []EndReason{EndDuration, EndDeadline, EndAllDispatched, EndPendingIdle}, reasons)
+	assert.False(t, conditions[1].Met(Progress{Now: start.Add(59 * time.Minute)}), "the timeout is relative to start")
+	assert.True(t, conditions[1].Met(Progress{Now: start.Add(time.Hour)}))
+}
+
+func TestTermination_Merge(t *testing.T) {
+	scenario := &Termination{Duration: EventDuration(time.Minute), PendingIdle: EventDuration(time.Second)}
+	flags := &Termination{Duration: EventDuration(time.Hour), AllEventsDispatched: true}
+
+	merged := scenario.Merge(flags)
+	assert.Equal(t, &Termination{
+		Duration:            EventDuration(time.Hour),
+		AllEventsDispatched: true,
+		PendingIdle:         EventDuration(time.Second),
+	}, merged)
+	assert.Equal(t, EventDuration(time.Minute), scenario.Duration, "the receiver is not modified")
+
+	var none *Termination
+	assert.Equal(t, flags, none.Merge(flags))
+	assert.Equal(t, scenario, scenario.Merge(nil))
+	assert.Equal(t, "all pods evicted, duration 1h0m0s, all events dispatched, no pending pod for 1s", merged.String())
+}
@@ -0,0 +1,379 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TimelineEventKind is the kind of a planned event.
+type TimelineEventKind string
+
+const (
+	TimelineEventPod       TimelineEventKind = "pod"
+	TimelineEventWorkload  TimelineEventKind = "workload"
+	TimelineEventScheduler TimelineEventKind = "scheduler"
+)
+
+// ResourceAmount is an amount of cluster resources.
+type ResourceAmount struct {
+	// CPU is in millicores
+	CPU int64 `json:"cpuMillis"`
+	// Memory is in bytes
+	Memory int64 `json:"memoryBytes"`
+	// Pods is the number of pods
+	Pods int64 `json:"pods"`
+}
+
+func (a ResourceAmount) add(b ResourceAmount) ResourceAmount {
+	return ResourceAmount{CPU: a.CPU + b.CPU, Memory: a.Memory + b.Memory, Pods: a.Pods + b.Pods}
+}
+
+func (a ResourceAmount) times(n int64) ResourceAmount {
+	return ResourceAmount{CPU: a.CPU * n, Memory: a.Memory * n, Pods: a.Pods * n}
+}
+
+// TimelineEvent is an event of the scenario as it is planned to run.
+type TimelineEvent struct {
+	// Arrival is the time the event runs
+	Arrival EventDuration `json:"arrival"`
+	// Kind is the kind of the event
+	Kind TimelineEventKind `json:"kind"`
+	// Action is what the event does, e.g. create, scale, delete or weights
+	Action string `json:"action"`
+	// Name is the name of the scenario event
+	Name string `json:"name"`
+	// Target is the pod or workload (namespace/name) or the scheduler weights the event acts on
+	Target string `json:"target"`
+	// Eviction is the planned deletion time of the target, if any
+	Eviction EventDuration `json:"eviction,omitempty"`
+	// Requests are the resources requested by the target after the event
+	Requests ResourceAmount `json:"requests"`
+}
+
+// ResourcePoint is the amount of resources requested from a point in time.
+type ResourcePoint struct {
+	Time EventDuration `json:"time"`
+	ResourceAmount
+}
+
+// Timeline is the planned execution of a scenario, computed without a cluster.
+type Timeline struct {
+	// Scenario is the name of the scenario
+	Scenario string `json:"scenario"`
+	// Seed is the seed the pod event distributions were drawn with
+	Seed int64 `json:"seed"`
+	// Events are sorted by arrival
+	Events []TimelineEvent `json:"events"`
+	// Requested is the aggregate of the resources requested by the pods over time
+	Requested []ResourcePoint `json:"requested"`
+	// Capacity is the allocatable capacity of the nodes declared in the scenario cluster
+	Capacity ResourceAmount `json:"capacity"`
+}
+
+// timelineChange is a change of the requested resources at a point in time.
+type timelineChange struct {
+	at    time.Duration
+	apply func()
+}
+
+// Timeline expands the pod events and computes the planned timeline of the scenario. Requested
+// resources assume every pod runs from the arrival of its event until its planned deletion.
+func (s *Scenario) Timeline() (*Timeline, error) {
+	pods, err := s.ExpandPodEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Timeline{
+		Scenario: s.Metadata.Name,
+		Seed:     s.EffectiveSeed(),
+		Events:   make([]TimelineEvent, 0),
+		Capacity: clusterCapacity(s.Cluster.Nodes),
+	}
+	changes := make([]timelineChange, 0)
+	// running are the requests of the pods and workloads running, perPod the requests of a workload pod
+	running := make(map[string]ResourceAmount)
+	perPod := make(map[string]ResourceAmount)
+
+	for _, e := range pods {
+		namespace := e.PodSpec.Namespace
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		key := namespace + "/" + e.PodSpec.Name
+		arrival := e.ArrivalTime.Duration()
+		event := TimelineEvent{
+			Arrival: e.ArrivalTime,
+			Kind:    TimelineEventPod,
+			Action:  string(e.EventType),
+			Name:    e.Name,
+			Target:  key,
+		}
+
+		if e.EventType == PodEventTypeDelete {
+			changes = append(changes, timelineChange{at: arrival, apply: func() { delete(running, key) }})
+			t.Events = append(t.Events, event)
+			continue
+		}
+
+		requests := podRequests(&e.PodSpec.Spec)
+		event.Requests = requests
+		changes = append(changes, timelineChange{at: arrival, apply: func() { running[key] = requests }})
+		if evict := e.EvictTime.Duration(); evict > 0 {
+			event.Eviction = EventDuration(arrival + evict)
+			changes = append(changes, timelineChange{at: arrival + evict, apply: func() { delete(running, key) }})
+		}
+		t.Events = append(t.Events, event)
+	}
+
+	for i := range s.Events.Workloads {
+		e := &s.Events.Workloads[i]
+		kind := e.Kind
+		if kind == "" {
+			kind = e.inferKind()
+		}
+		key := string(kind) + ":" + e.TargetNamespace() + "/" + e.TargetName()
+		arrival := e.ArrivalTime.Duration()
+		action := e.Action
+		if action == "" {
+			action = WorkloadActionCreate
+		}
+		event := TimelineEvent{
+			Arrival: e.ArrivalTime,
+			Kind:    TimelineEventWorkload,
+			Action:  string(action),
+			Name:    e.Name,
+			Target:  e.TargetNamespace() + "/" + e.TargetName(),
+		}
+
+		switch action {
+		case WorkloadActionCreate:
+			var pod ResourceAmount
+			if template := e.podTemplate(); template != nil {
+				pod = podRequests(&template.Spec)
+			}
+			requests := pod.times(int64(e.desiredReplicas()))
+			event.Requests = requests
+			changes = append(changes, timelineChange{at: arrival, apply: func() {
+				running[key] = requests
+				perPod[key] = pod
+			}})
+			if evict := e.EvictTime.Duration(); evict > 0 {
+				event.Eviction = EventDuration(arrival + evict)
+				changes = append(changes, timelineChange{at: arrival + evict, apply: func() { delete(running, key) }})
+			}
+		case WorkloadActionScale:
+			replicas := int64(0)
+			if e.Replicas != nil {
+				replicas = int64(*e.Replicas)
+			}
+			changes = append(changes, timelineChange{at: arrival, apply: func() {
+				if _, ok := running[key]; ok {
+					running[key] = perPod[key].times(replicas)
+				}
+			}})
+		case WorkloadActionDelete:
+			changes = append(changes, timelineChange{at: arrival, apply: func() { delete(running, key) }})
+		}
+		t.Events = append(t.Events, event)
+	}
+
+	for _, e := range s.Events.Scheduler {
+		target := formatWeights(e.Weights)
+		if e.Profile != "" {
+			target = fmt.Sprintf("%s:%s", e.Profile, target)
+		}
+		t.Events = append(t.Events, TimelineEvent{
+			Arrival: e.ArrivalTime,
+			Kind:    TimelineEventScheduler,
+			Action:  "weights",
+			Name:    e.Name,
+			Target:  target,
+		})
+	}
+
+	sort.SliceStable(t.Events, func(i, j int) bool { return t.Events[i].Arrival < t.Events[j].Arrival })
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].at < changes[j].at })
+
+	t.Requested = make([]ResourcePoint, 0)
+	for i, change := range changes {
+		change.apply()
+		if i+1 < len(changes) && changes[i+1].at == change.at {
+			continue
+		}
+
+		var total ResourceAmount
+		for _, amount := range running {
+			total = total.add(amount)
+		}
+		if n := len(t.Requested); n > 0 && t.Requested[n-1].ResourceAmount == total {
+			continue
+		}
+		t.Requested = append(t.Requested, ResourcePoint{Time: EventDuration(change.at), ResourceAmount: total})
+	}
+	return t, nil
+}
+
+// Peak returns the maximum of each requested resource over the timeline.
+func (t *Timeline) Peak() ResourceAmount {
+	var peak ResourceAmount
+	for _, p := range t.Requested {
+		peak.CPU = max(peak.CPU, p.CPU)
+		peak.Memory = max(peak.Memory, p.Memory)
+		peak.Pods = max(peak.Pods, p.Pods)
+	}
+	return peak
+}
+
+// Write writes the timeline in the given format, table or json.
+func (t *Timeline) Write(out io.Writer, format string) error {
+	switch format {
+	case "table":
+		return t.WriteTable(out)
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t)
+	}
+	return fmt.Errorf("unknown output format %q, expected table or json", format)
+}
+
+// WriteTable writes the timeline as human readable tables: the planned events, then the requested
+// resources over time against the cluster capacity.
+func (t *Timeline) WriteTable(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Scenario %q, seed %d\n\n", t.Scenario, t.Seed)
+
+	fmt.Fprintln(w, "ARRIVAL\tKIND\tACTION\tNAME\tTARGET\tEVICTION\tCPU\tMEMORY")
+	for _, e := range t.Events {
+		eviction := "-"
+		if e.Eviction > 0 {
+			eviction = e.Eviction.Duration().String()
+		}
+		cpu, memory := "-", "-"
+		if e.Requests.Pods > 0 {
+			cpu, memory = formatCPU(e.Requests.CPU), formatMemory(e.Requests.Memory)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Arrival.Duration(), e.Kind, e.Action, e.Name, e.Target, eviction, cpu, memory)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "TIME\tCPU\tCPU%\tMEMORY\tMEMORY%\tPODS\tPODS%")
+	for _, p := range t.Requested {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", p.Time.Duration(),
+			formatCPU(p.CPU), percent(p.CPU, t.Capacity.CPU),
+			formatMemory(p.Memory), percent(p.Memory, t.Capacity.Memory),
+			p.Pods, percent(p.Pods, t.Capacity.Pods))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	peak := t.Peak()
+	fmt.Fprintf(out, "\ncapacity: cpu %s, memory %s, pods %d\n",
+		formatCPU(t.Capacity.CPU), formatMemory(t.Capacity.Memory), t.Capacity.Pods)
+	fmt.Fprintf(out, "peak requests: cpu %s (%s), memory %s (%s), pods %d (%s)\n",
+		formatCPU(peak.CPU), percent(peak.CPU, t.Capacity.CPU),
+		formatMemory(peak.Memory), percent(peak.Memory, t.Capacity.Memory),
+		peak.Pods, percent(peak.Pods, t.Capacity.Pods))
+	if t.Capacity == (ResourceAmount{}) {
+		fmt.Fprintln(out, "note: the scenario declares no cluster nodes")
+	} else if peak.CPU > t.Capacity.CPU || peak.Memory > t.Capacity.Memory || peak.Pods > t.Capacity.Pods {
+		fmt.Fprintln(out, "warning: the requests exceed the declared cluster capacity, some pods will stay pending")
+	}
+	return nil
+}
+
+// desiredReplicas returns the number of replicas the workload is created with.
+func (e *WorkloadEvent) desiredReplicas() int32 {
+	if e.Replicas != nil {
+		return *e.Replicas
+	}
+	var replicas *int32
+	switch {
+	case e.Deployment != nil:
+		replicas = e.Deployment.Spec.Replicas
+	case e.ReplicaSet != nil:
+		replicas = e.ReplicaSet.Spec.Replicas
+	case e.StatefulSet != nil:
+		replicas = e.StatefulSet.Spec.Replicas
+	case e.Job != nil:
+		replicas = e.Job.Spec.Parallelism
+	}
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// podRequests returns the effective requests of a pod: the sum of its containers, or the largest init
+// container if it is larger.
+func podRequests(spec *v1.PodSpec) ResourceAmount {
+	amount := ResourceAmount{Pods: 1}
+	for _, c := range spec.Containers {
+		amount.CPU += c.Resources.Requests.Cpu().MilliValue()
+		amount.Memory += c.Resources.Requests.Memory().Value()
+	}
+	for _, c := range spec.InitContainers {
+		amount.CPU = max(amount.CPU, c.Resources.Requests.Cpu().MilliValue())
+		amount.Memory = max(amount.Memory, c.Resources.Requests.Memory().Value())
+	}
+	return amount
+}
+
+// clusterCapacity sums the allocatable resources of the nodes, falling back to their capacity.
+func clusterCapacity(nodes []*v1.Node) ResourceAmount {
+	var amount ResourceAmount
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		resources := node.Status.Allocatable
+		if len(resources) == 0 {
+			resources = node.Status.Capacity
+		}
+		amount.CPU += resources.Cpu().MilliValue()
+		amount.Memory += resources.Memory().Value()
+		amount.Pods += resources.Pods().Value()
+	}
+	return amount
+}
+
+func formatWeights(weights map[string]int32) string {
+	plugins := make([]string, 0, len(weights))
+	for plugin := range weights {
+		plugins = append(plugins, plugin)
+	}
+	sort.Strings(plugins)
+
+	pairs := make([]string, 0, len(plugins))
+	for _, plugin := range plugins {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", plugin, weights[plugin]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func formatCPU(millis int64) string {
+	return resource.NewMilliQuantity(millis, resource.DecimalSI).String()
+}
+
+func formatMemory(bytes int64) string {
+	return resource.NewQuantity(bytes, resource.BinarySI).String()
+}
+
+func percent(value, capacity int64) string {
+	if capacity == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(value)/float64(capacity))
+}
@@ -0,0 +1,149 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var timelineScenarioYaml = `
+metadata:
+  name: timeline
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "1", memory: 2Gi, pods: "10" }
+    - metadata:
+        name: node-2
+      status:
+        capacity: { cpu: "1", memory: 2Gi, pods: "10" }
+events:
+  pods:
+    - name: batch
+      replicas: 2
+      spacing: 10s
+      evictTime: 30s
+      podSpec:
+        metadata:
+          name: batch
+        spec:
+          containers:
+            - name: main
+              image: nginx
+              resources:
+                requests: { cpu: 500m, memory: 256Mi }
+  workloads:
+    - name: web
+      arrivalTime: 15s
+      evictTime: 60s
+      replicas: 2
+      deployment:
+        metadata:
+          name: web
+        spec:
+          selector:
+            matchLabels: { app: web }
+          template:
+            metadata:
+              labels: { app: web }
+            spec:
+              containers:
+                - name: web
+                  image: nginx
+                  resources:
+                    requests: { cpu: 250m, memory: 128Mi }
+    - name: web-scale-up
+      arrivalTime: 20s
+      action: scale
+      kind: Deployment
+      target: web
+      replicas: 4
+  scheduler:
+    - name: weights
+      arrivalTime: 5s
+      weights:
+        NodeResourcesFit: 5
+        ImageLocality: 1
+`
+
+func TestScenario_Timeline(t *testing.T) {
+	scenario, err := Load([]byte(timelineScenarioYaml))
+	require.NoError(t, err)
+
+	timeline, err := scenario.Timeline()
+	require.NoError(t, err)
+
+	assert.Equal(t, ResourceAmount{CPU: 2000, Memory: 4 << 30, Pods: 20}, timeline.Capacity)
+
+	require.Len(t, timeline.Events, 5)
+	targets := make([]string, 0, len(timeline.Events))
+	for _, e := range timeline.Events {
+		targets = append(targets, e.Target)
+	}
+	assert.Equal(t, []string{"default/batch-0", "ImageLocality=1,NodeResourcesFit=5", "default/batch-1", "default/web", "default/web"}, targets)
+	assert.Equal(t, EventDuration(40*time.Second), timeline.Events[2].Eviction)
+	assert.Equal(t, ResourceAmount{CPU: 500, Memory: 256 << 20, Pods: 2}, timeline.Events[3].Requests)
+
+	type point struct {
+		at   time.Duration
+		cpu  int64
+		pods int64
+	}
+	expected := []point{{0, 500, 1}, {10 * time.Second, 1000, 2}, {15 * time.Second, 1500, 4},
+		{20 * time.Second, 2000, 6}, {30 * time.Second, 1500, 5}, {40 * time.Second, 1000, 4}, {75 * time.Second, 0, 0}}
+	actual := make([]point, 0, len(timeline.Requested))
+	for _, p := range timeline.Requested {
+		actual = append(actual, point{p.Time.Duration(), p.CPU, p.Pods})
+	}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, ResourceAmount{CPU: 2000, Memory: 2*(256<<20) + 4*(128<<20), Pods: 6}, timeline.Peak())
+
+	var table bytes.Buffer
+	require.NoError(t, timeline.WriteTable(&table))
+	assert.Contains(t, table.String(), "peak requests: cpu 2 (100.0%)")
+	assert.NotContains(t, table.String(), "warning")
+
+	data, err := json.Marshal(timeline)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"arrival":"15s"`)
+}
+
+func TestScenario_TimelineDeleteEvent(t *testing.T) {
+	scenario, err := Load([]byte(`
+metadata:
+  name: delete
+templates:
+  job:
+    metadata:
+      name: job
+    spec:
+      containers:
+        - name: main
+          image: nginx
+          resources:
+            requests: { cpu: 500m }
+events:
+  pods:
+    - name: job
+      template: job
+    - name: job-removed
+      template: job
+      eventType: delete
+      arrivalTime: 10s
+`))
+	require.NoError(t, err)
+
+	timeline, err := scenario.Timeline()
+	require.NoError(t, err)
+	require.Len(t, timeline.Events, 2)
+	assert.Equal(t, timeline.Events[0].Target, timeline.Events[1].Target, "the delete event targets the pod created")
+	require.Len(t, timeline.Requested, 2)
+	assert.Equal(t, int64(500), timeline.Requested[0].CPU)
+	assert.Equal(t, int64(0), timeline.Requested[1].CPU, "the requests drop when the pod is deleted")
+}
@@ -0,0 +1,797 @@
+package simulation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	eventscheduler "github.com/maczg/kube-event-generator/pkg/scheduler"
+	"gopkg.in/yaml.v3"
+	v1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Severity is the severity of a validation diagnostic.
+type Severity string
+
+const (
+	// SeverityError marks a problem that makes the scenario fail or behave incorrectly
+	SeverityError Severity = "error"
+	// SeverityWarning marks a suspicious construct that is still accepted
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found while validating a scenario.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	// Path is the YAML path of the offending node, e.g. events.pods[0].podSpec
+	Path string `json:"path"`
+	// Line and Column locate the offending node in the file. Zero means unknown.
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// String returns the diagnostic as line:column: severity: path: message.
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s: %s", d.Line, d.Column, d.Severity, d.Path, d.Message)
+}
+
+// Diagnostics is a list of validation diagnostics.
+type Diagnostics []Diagnostic
+
+// HasErrors reports whether at least one diagnostic is an error.
+func (d Diagnostics) HasErrors() bool {
+	return d.Count(SeverityError) > 0
+}
+
+// Count returns the number of diagnostics with the given severity.
+func (d Diagnostics) Count(severity Severity) int {
+	n := 0
+	for _, diag := range d {
+		if diag.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// ValidateOpt configures the scenario validation.
+type ValidateOpt func(*validator)
+
+// WithKnownPlugins adds plugin names accepted in scheduler event weights, besides the default plugins.
+func WithKnownPlugins(plugins ...string) ValidateOpt {
+	return func(v *validator) {
+		for _, p := range plugins {
+			v.knownPlugins[p] = true
+		}
+	}
+}
+
+// WithLoadOpts sets the options used to load the scenario, e.g. parameter overrides.
+func WithLoadOpts(opts ...LoadOpt) ValidateOpt {
+	return func(v *validator) {
+		v.loadOpts = append(v.loadOpts, opts...)
+	}
+}
+
+// withBaseDir sets the directory imports are resolved against.
+func withBaseDir(dir string) ValidateOpt {
+	return func(v *validator) {
+		v.baseDir = dir
+	}
+}
+
+// ValidateFile validates the scenario file. The returned error is only set if the file cannot be read.
+// Imports are resolved relative to the file.
+func ValidateFile(filename string, opts ...ValidateOpt) (Diagnostics, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]ValidateOpt{withBaseDir(filepath.Dir(filename))}, opts...)
+	return Validate(data, opts...), nil
+}
+
+// Validate checks the structure (unknown fields, wrong types, malformed durations and quantities)
+// and the semantics (missing pod specs, empty containers, unknown plugins, ...) of a scenario.
+// Each diagnostic carries the YAML path and line of the offending node.
+func Validate(data []byte, opts ...ValidateOpt) Diagnostics {
+	v := &validator{baseDir: ".", knownPlugins: make(map[string]bool)}
+	for _, p := range kube.DefaultPlugins {
+		v.knownPlugins[p] = true
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	v.validate(data)
+	v.sort()
+	return v.diagnostics
+}
+
+// validate collects the diagnostics of the scenario, in no particular order.
+func (v *validator) validate(data []byte) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		v.addAt(syntaxErrorLine(err), 0, SeverityError, nil, "%s", err.Error())
+		return
+	}
+	if len(doc.Content) == 0 {
+		v.addAt(0, 0, SeverityError, nil, "scenario is empty")
+		return
+	}
+	v.root = doc.Content[0]
+
+	v.walk(v.root, reflect.TypeOf(Scenario{}), nil)
+	if v.diagnostics.HasErrors() {
+		// the semantic checks need a scenario that unmarshals
+		return
+	}
+
+	composed, err := Load(data, append([]LoadOpt{WithBaseDir(v.baseDir)}, v.loadOpts...)...)
+	if err != nil {
+		var composeErr *ComposeError
+		if errors.As(err, &composeErr) {
+			v.add(SeverityError, composeErr.path, "%s", composeErr.Err.Error())
+		} else {
+			v.addAt(0, 0, SeverityError, nil, "%s", err.Error())
+		}
+		return
+	}
+
+	// events are checked on the file alone so that their paths match its lines,
+	// templates and events of the imported files are taken from the composed scenario
+	scenario, err := loadOwn(data, composed.ParamValues())
+	if err != nil {
+		v.addAt(0, 0, SeverityError, nil, "%s", err.Error())
+		return
+	}
+	v.checkScenario(scenario, composed)
+}
+
+// fieldPath is a YAML path made of mapping keys (string) and sequence indices (int).
+type fieldPath []interface{}
+
+// Key returns a copy of the path extended with a mapping key.
+func (p fieldPath) Key(key string) fieldPath {
+	return append(append(fieldPath{}, p...), key)
+}
+
+// Index returns a copy of the path extended with a sequence index.
+func (p fieldPath) Index(index int) fieldPath {
+	return append(append(fieldPath{}, p...), index)
+}
+
+// String returns the path in dotted notation, e.g. events.pods[0].podSpec.
+func (p fieldPath) String() string {
+	var b strings.Builder
+	for _, seg := range p {
+		switch s := seg.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(s) + "]")
+		case string:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(s)
+		}
+	}
+	return b.String()
+}
+
+var (
+	syntaxLineRe      = regexp.MustCompile(`line (\d+)`)
+	eventDurationType = reflect.TypeOf(EventDuration(0))
+	quantityType      = reflect.TypeOf(resource.Quantity{})
+	paramType         = reflect.TypeOf(Param{})
+	baseEventType     = reflect.TypeOf(eventscheduler.BaseEvent{})
+	unmarshalerType   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	scenarioPkgPath   = reflect.TypeOf(Scenario{}).PkgPath()
+)
+
+// syntaxErrorLine extracts the line number of a YAML syntax error, or 0 if unknown.
+func syntaxErrorLine(err error) int {
+	if m := syntaxLineRe.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+type validator struct {
+	baseDir      string
+	loadOpts     []LoadOpt
+	root         *yaml.Node
+	knownPlugins map[string]bool
+	diagnostics  Diagnostics
+}
+
+func (v *validator) addAt(line, column int, severity Severity, path fieldPath, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		Severity: severity,
+		Path:     path.String(),
+		Line:     line,
+		Column:   column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) addNode(node *yaml.Node, severity Severity, path fieldPath, format string, args ...interface{}) {
+	v.addAt(node.Line, node.Column, severity, path, format, args...)
+}
+
+// add reports a diagnostic located at the deepest existing node of path.
+func (v *validator) add(severity Severity, path fieldPath, format string, args ...interface{}) {
+	node := v.locate(path)
+	v.addAt(node.Line, node.Column, severity, path, format, args...)
+}
+
+func (v *validator) sort() {
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		if v.diagnostics[i].Line != v.diagnostics[j].Line {
+			return v.diagnostics[i].Line < v.diagnostics[j].Line
+		}
+		return v.diagnostics[i].Column < v.diagnostics[j].Column
+	})
+}
+
+// locate returns the deepest node of the document matching path.
+func (v *validator) locate(path fieldPath) *yaml.Node {
+	node := resolveAlias(v.root)
+	for _, seg := range path {
+		var next *yaml.Node
+		switch s := seg.(type) {
+		case string:
+			if node.Kind == yaml.MappingNode {
+				if _, value := mappingEntry(node, s); value != nil {
+					next = value
+				}
+			}
+		case int:
+			if node.Kind == yaml.SequenceNode && s < len(node.Content) {
+				next = node.Content[s]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = resolveAlias(next)
+	}
+	return node
+}
+
+// mappingEntry returns the key and value nodes of a mapping entry. Keys are matched
+// case-insensitively as a fallback, like the JSON decoder does.
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	var foldKey, foldValue *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k := node.Content[i]
+		if k.Value == key {
+			return k, node.Content[i+1]
+		}
+		if foldKey == nil && strings.EqualFold(k.Value, key) {
+			foldKey, foldValue = k, node.Content[i+1]
+		}
+	}
+	return foldKey, foldValue
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// walk checks that node matches the JSON shape of type t.
+func (v *validator) walk(node *yaml.Node, t reflect.Type, path fieldPath) {
+	node = resolveAlias(node)
+	if node == nil || node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && paramRefPattern.MatchString(node.Value) {
+		// parameter references are type checked when they are resolved
+		return
+	}
+
+	switch {
+	case t == paramType && node.Kind == yaml.ScalarNode:
+		// shorthand parameter, its type is inferred from the value
+		return
+	case t == eventDurationType:
+		if v.expectScalar(node, path, "duration") {
+			if _, err := time.ParseDuration(node.Value); err != nil {
+				v.addNode(node, SeverityError, path, "invalid duration %q: expected a value like 500ms, 10s or 1m30s", node.Value)
+			}
+		}
+		return
+	case t == quantityType:
+		if v.expectScalar(node, path, "quantity") {
+			if _, err := resource.ParseQuantity(node.Value); err != nil {
+				v.addNode(node, SeverityError, path, "invalid quantity %q", node.Value)
+			}
+		}
+		return
+	case t.PkgPath() != scenarioPkgPath && reflect.PtrTo(t).Implements(unmarshalerType):
+		// opaque types with custom decoding (times, int-or-string, ...)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if !v.expectKind(node, yaml.MappingNode, path, "mapping") {
+			return
+		}
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			if field, ok := fields[key.Value]; ok {
+				v.walk(value, field.Type, path.Key(key.Value))
+				continue
+			}
+			if name, ok := foldField(fields, key.Value); ok {
+				v.addNode(key, SeverityWarning, path.Key(key.Value), "field %q matched case-insensitively, use %q", key.Value, name)
+				v.walk(value, fields[name].Type, path.Key(key.Value))
+				continue
+			}
+			v.addNode(key, SeverityError, path.Key(key.Value), "unknown field %q", key.Value)
+		}
+	case reflect.Map:
+		if !v.expectKind(node, yaml.MappingNode, path, "mapping") {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.walk(node.Content[i+1], t.Elem(), path.Key(node.Content[i].Value))
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			v.expectScalar(node, path, "base64 string")
+			return
+		}
+		if !v.expectKind(node, yaml.SequenceNode, path, "sequence") {
+			return
+		}
+		for i, item := range node.Content {
+			v.walk(item, t.Elem(), path.Index(i))
+		}
+	case reflect.String:
+		v.expectScalar(node, path, "string")
+	case reflect.Bool:
+		if v.expectScalar(node, path, "boolean") && node.Tag != "!!bool" {
+			v.addNode(node, SeverityError, path, "expected boolean, got %q", node.Value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.expectScalar(node, path, "integer") && node.Tag != "!!int" {
+			v.addNode(node, SeverityError, path, "expected integer, got %q", node.Value)
+		}
+	case reflect.Float32, reflect.Float64:
+		if v.expectScalar(node, path, "number") && node.Tag != "!!int" && node.Tag != "!!float" {
+			v.addNode(node, SeverityError, path, "expected number, got %q", node.Value)
+		}
+	}
+}
+
+func (v *validator) expectScalar(node *yaml.Node, path fieldPath, what string) bool {
+	return v.expectKind(node, yaml.ScalarNode, path, what)
+}
+
+func (v *validator) expectKind(node *yaml.Node, kind yaml.Kind, path fieldPath, what string) bool {
+	if node.Kind == kind {
+		return true
+	}
+	v.addNode(node, SeverityError, path, "expected %s, got %s", what, kindName(node.Kind))
+	return false
+}
+
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "sequence"
+	case yaml.ScalarNode:
+		return "scalar"
+	}
+	return "unknown node"
+}
+
+// jsonFields returns the fields of a struct type keyed by their JSON name, following the
+// encoding/json rules for embedded structs. The scheduler BaseEvent is not part of the format.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	inlined := make([]reflect.StructField, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if ft != baseEventType {
+					inlined = append(inlined, field)
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+
+	// fields of embedded structs have lower precedence
+	for _, field := range inlined {
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		for name, f := range jsonFields(ft) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = f
+			}
+		}
+	}
+
+	return fields
+}
+
+func foldField(fields map[string]reflect.StructField, key string) (string, bool) {
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// checkScenario checks the scenario s; composed is s with its imports resolved.
+func (v *validator) checkScenario(s, composed *Scenario) {
+	if composed.Metadata.Name == "" {
+		v.add(SeverityWarning, fieldPath{"metadata", "name"}, "scenario name is empty")
+	}
+	if len(composed.Events.Pods) == 0 && len(composed.Events.Workloads) == 0 && len(composed.Events.Scheduler) == 0 {
+		v.add(SeverityWarning, fieldPath{"events"}, "scenario has no events")
+	}
+
+	if s.Eviction != nil {
+		v.checkEvictionPolicy(s.Eviction, fieldPath{"eviction"})
+	}
+	if t := s.Termination; t != nil {
+		v.checkDuration(t.Duration, fieldPath{"termination", "duration"})
+		v.checkDuration(t.Timeout, fieldPath{"termination", "timeout"})
+		v.checkDuration(t.PendingIdle, fieldPath{"termination", "pendingIdle"})
+	}
+
+	for i, node := range s.Cluster.Nodes {
+		path := fieldPath{"cluster", "nodes"}.Index(i)
+		if node == nil || node.Name == "" {
+			v.add(SeverityError, path.Key("metadata").Key("name"), "node name is required")
+		}
+	}
+	v.checkPriorityClasses(s.Cluster.PriorityClasses)
+
+	templates := make([]string, 0, len(s.Templates))
+	for name := range s.Templates {
+		templates = append(templates, name)
+	}
+	sort.Strings(templates)
+	for _, name := range templates {
+		v.checkPod(s.Templates[name], fieldPath{"templates", name})
+	}
+
+	names := make(map[string]fieldPath)
+	checkName := func(name string, path fieldPath) {
+		if name == "" {
+			return
+		}
+		if first, ok := names[name]; ok {
+			v.add(SeverityWarning, path.Key("name"), "event name %q already used by %s", name, first)
+			return
+		}
+		names[name] = path
+	}
+
+	for i := range s.Events.Pods {
+		path := fieldPath{"events", "pods"}.Index(i)
+		checkName(s.Events.Pods[i].Name, path)
+		v.checkPodEvent(composed, &s.Events.Pods[i], path)
+	}
+	for i := range s.Events.Workloads {
+		path := fieldPath{"events", "workloads"}.Index(i)
+		checkName(s.Events.Workloads[i].Name, path)
+		v.checkWorkloadEvent(&s.Events.Workloads[i], path)
+	}
+	schedulerNames := composed.podSchedulerNames()
+	for i := range s.Events.Scheduler {
+		path := fieldPath{"events", "scheduler"}.Index(i)
+		checkName(s.Events.Scheduler[i].Name, path)
+		v.checkSchedulerEvent(&s.Events.Scheduler[i], path)
+		if profile := s.Events.Scheduler[i].Profile; profile != "" && !schedulerNames[profile] {
+			v.add(SeverityWarning, path.Key("profile"), "no pod of the scenario uses scheduler %q", profile)
+		}
+	}
+}
+
+// checkEvictionPolicy checks the eviction policy fields, inlined in pod events, under path.
+func (v *validator) checkEvictionPolicy(e *EvictionPolicy, path fieldPath) {
+	switch e.EvictFrom {
+	case "", EvictFromArrival, EvictFromCreated, EvictFromScheduled, EvictFromRunning:
+	default:
+		v.add(SeverityError, path.Key("evictFrom"), "unknown reference point %q, expected arrival, created, scheduled or running", e.EvictFrom)
+	}
+	v.checkDuration(e.StartTimeout, path.Key("startTimeout"))
+	switch e.OnStartTimeout {
+	case "", StartTimeoutDelete, StartTimeoutKeep, StartTimeoutFail:
+	default:
+		v.add(SeverityError, path.Key("onStartTimeout"), "unknown start timeout policy %q, expected delete, keep or fail", e.OnStartTimeout)
+	}
+}
+
+// checkPriorityClasses checks the priority classes of the cluster: their names must be unique and their
+// values must not exceed the highest value allowed to user-defined classes.
+func (v *validator) checkPriorityClasses(classes []*schedulingv1.PriorityClass) {
+	names := make(map[string]fieldPath)
+	for i, pc := range classes {
+		path := fieldPath{"cluster", "priorityClasses"}.Index(i)
+		if pc == nil || pc.Name == "" {
+			v.add(SeverityError, path.Key("metadata").Key("name"), "priority class name is required")
+			continue
+		}
+		if first, ok := names[pc.Name]; ok {
+			v.add(SeverityError, path.Key("metadata").Key("name"), "priority class %q already declared by %s", pc.Name, first)
+		} else {
+			names[pc.Name] = path
+		}
+		if pc.Value > highestUserDefinablePriority {
+			v.add(SeverityError, path.Key("value"), "value %d exceeds %d, the highest value of a user-defined priority class", pc.Value, highestUserDefinablePriority)
+		}
+		if p := pc.PreemptionPolicy; p != nil && *p != v1.PreemptLowerPriority && *p != v1.PreemptNever {
+			v.add(SeverityError, path.Key("preemptionPolicy"), "unknown preemption policy %q, expected %s or %s", *p, v1.PreemptLowerPriority, v1.PreemptNever)
+		}
+	}
+}
+
+// checkLifecycle checks the lifecycle of the pods of an event, if set.
+func (v *validator) checkLifecycle(l *Lifecycle, path fieldPath) {
+	if l == nil {
+		return
+	}
+	switch l.Outcome {
+	case OutcomeSucceed, OutcomeFail, OutcomeCrashLoop:
+	case "":
+		v.add(SeverityError, path.Key("outcome"), "missing outcome, expected succeed, fail or crash-loop")
+	default:
+		v.add(SeverityError, path.Key("outcome"), "unknown outcome %q, expected succeed, fail or crash-loop", l.Outcome)
+	}
+	v.checkDuration(l.After, path.Key("after"))
+	if l.Outcome == OutcomeSucceed && l.ExitCode != 0 {
+		v.add(SeverityWarning, path.Key("exitCode"), "exit code %d is ignored by pods that succeed", l.ExitCode)
+	}
+}
+
+func (v *validator) checkDuration(d EventDuration, path fieldPath) {
+	if d < 0 {
+		v.add(SeverityError, path, "duration must not be negative, got %s", d.Duration())
+	}
+}
+
+func (v *validator) checkPodEvent(s *Scenario, e *PodEvent, path fieldPath) {
+	v.checkDuration(e.ArrivalTime, path.Key("arrivalTime"))
+	v.checkDuration(e.EvictTime, path.Key("evictTime"))
+	v.checkDuration(e.Spacing, path.Key("spacing"))
+	v.checkEvictionPolicy(&e.EvictionPolicy, path)
+	v.checkLifecycle(e.Lifecycle, path.Key("lifecycle"))
+
+	switch e.EventType {
+	case PodEventTypeCreate, PodEventTypeDelete:
+	default:
+		v.add(SeverityError, path.Key("eventType"), "unknown event type %q, expected create or delete", e.EventType)
+	}
+
+	switch {
+	case e.PodSpec == nil && e.Template == "":
+		v.add(SeverityError, path, "missing podSpec or template")
+	case e.PodSpec != nil && e.Template != "":
+		v.add(SeverityError, path.Key("template"), "podSpec and template are mutually exclusive")
+	case e.Template != "":
+		if _, ok := s.Templates[e.Template]; !ok {
+			v.add(SeverityError, path.Key("template"), "template %q not found", e.Template)
+		}
+	default:
+		v.checkPod(e.PodSpec, path.Key("podSpec"))
+	}
+
+	if e.Replicas < 0 {
+		v.add(SeverityError, path.Key("replicas"), "replicas must not be negative, got %d", e.Replicas)
+	}
+	if e.Spacing > 0 && e.Replicas <= 1 {
+		v.add(SeverityWarning, path.Key("spacing"), "spacing has no effect without replicas")
+	}
+	if e.Distribution != nil {
+		if err := e.Distribution.Validate(); err != nil {
+			v.add(SeverityError, path.Key("distribution"), "%s", err.Error())
+		}
+		if e.Spacing > 0 {
+			v.add(SeverityError, path.Key("distribution"), "spacing and distribution are mutually exclusive")
+		}
+		if e.Replicas <= 1 {
+			v.add(SeverityWarning, path.Key("distribution"), "distribution has no effect without replicas")
+		}
+	}
+	if e.Lifetime != nil {
+		if err := e.Lifetime.Validate(); err != nil {
+			v.add(SeverityError, path.Key("lifetime"), "%s", err.Error())
+		}
+		if e.EvictTime > 0 {
+			v.add(SeverityError, path.Key("lifetime"), "evictTime and lifetime are mutually exclusive")
+		}
+	}
+
+	replicas := e.Replicas
+	if replicas < 1 {
+		replicas = 1
+	}
+	for i, override := range e.Overrides {
+		for j, index := range override.Indices {
+			if index < 0 || index >= replicas {
+				v.add(SeverityError, path.Key("overrides").Index(i).Key("indices").Index(j),
+					"replica index %d out of range [0, %d)", index, replicas)
+			}
+		}
+	}
+}
+
+func (v *validator) checkPod(pod *v1.Pod, path fieldPath) {
+	if pod == nil {
+		v.add(SeverityError, path, "pod spec is empty")
+		return
+	}
+	v.checkPodSpec(&pod.Spec, path.Key("spec"))
+}
+
+func (v *validator) checkPodSpec(spec *v1.PodSpec, path fieldPath) {
+	if len(spec.Containers) == 0 {
+		v.add(SeverityError, path.Key("containers"), "pod must have at least one container")
+		return
+	}
+	for i, c := range spec.Containers {
+		cpath := path.Key("containers").Index(i)
+		if c.Name == "" {
+			v.add(SeverityError, cpath.Key("name"), "container name is required")
+		}
+		if c.Image == "" {
+			v.add(SeverityError, cpath.Key("image"), "container image is required")
+		}
+	}
+}
+
+func (v *validator) checkWorkloadEvent(e *WorkloadEvent, path fieldPath) {
+	v.checkDuration(e.ArrivalTime, path.Key("arrivalTime"))
+	v.checkDuration(e.EvictTime, path.Key("evictTime"))
+
+	if e.Replicas != nil && *e.Replicas < 0 {
+		v.add(SeverityError, path.Key("replicas"), "replicas must not be negative, got %d", *e.Replicas)
+	}
+	v.checkLifecycle(e.Lifecycle, path.Key("lifecycle"))
+	if e.Lifecycle != nil && e.Action != WorkloadActionCreate {
+		v.add(SeverityWarning, path.Key("lifecycle"), "lifecycle is only applied when the workload is created")
+	}
+
+	specs := 0
+	for _, set := range []bool{e.Deployment != nil, e.ReplicaSet != nil, e.StatefulSet != nil, e.Job != nil} {
+		if set {
+			specs++
+		}
+	}
+
+	knownKind := true
+	switch e.Kind {
+	case "", WorkloadKindDeployment, WorkloadKindReplicaSet, WorkloadKindStatefulSet, WorkloadKindJob:
+	default:
+		knownKind = false
+		v.add(SeverityError, path.Key("kind"), "unknown workload kind %q", e.Kind)
+	}
+
+	switch e.Action {
+	case WorkloadActionCreate:
+		if specs == 0 {
+			v.add(SeverityError, path, "missing workload spec: one of deployment, replicaSet, statefulSet or job is required")
+			return
+		}
+		if specs > 1 {
+			v.add(SeverityError, path, "only one of deployment, replicaSet, statefulSet or job can be set")
+			return
+		}
+		if knownKind && e.Kind != "" && !e.hasSpec(e.Kind) {
+			v.add(SeverityError, path.Key("kind"), "kind %s does not match the %s spec", e.Kind, e.inferKind())
+			return
+		}
+		switch {
+		case e.Deployment != nil:
+			if e.Deployment.Spec.Selector == nil {
+				v.add(SeverityError, path.Key("deployment").Key("spec").Key("selector"), "selector is required")
+			}
+			v.checkPodSpec(&e.Deployment.Spec.Template.Spec, path.Key("deployment").Key("spec").Key("template").Key("spec"))
+		case e.ReplicaSet != nil:
+			if e.ReplicaSet.Spec.Selector == nil {
+				v.add(SeverityError, path.Key("replicaSet").Key("spec").Key("selector"), "selector is required")
+			}
+			v.checkPodSpec(&e.ReplicaSet.Spec.Template.Spec, path.Key("replicaSet").Key("spec").Key("template").Key("spec"))
+		case e.StatefulSet != nil:
+			if e.StatefulSet.Spec.Selector == nil {
+				v.add(SeverityError, path.Key("statefulSet").Key("spec").Key("selector"), "selector is required")
+			}
+			v.checkPodSpec(&e.StatefulSet.Spec.Template.Spec, path.Key("statefulSet").Key("spec").Key("template").Key("spec"))
+		case e.Job != nil:
+			v.checkPodSpec(&e.Job.Spec.Template.Spec, path.Key("job").Key("spec").Key("template").Key("spec"))
+		}
+	case WorkloadActionScale, WorkloadActionDelete:
+		if e.Kind == "" {
+			v.add(SeverityError, path.Key("kind"), "kind is required on %s events", e.Action)
+		}
+		if e.TargetName() == "" {
+			v.add(SeverityError, path.Key("target"), "target is required on %s events", e.Action)
+		}
+		if e.Action == WorkloadActionScale && e.Replicas == nil {
+			v.add(SeverityError, path.Key("replicas"), "replicas is required on scale events")
+		}
+	default:
+		v.add(SeverityError, path.Key("action"), "unknown workload action %q, expected create, scale or delete", e.Action)
+	}
+}
+
+func (v *validator) checkSchedulerEvent(e *KubeSchedulerEvent, path fieldPath) {
+	v.checkDuration(e.ArrivalTime, path.Key("arrivalTime"))
+
+	if len(e.Weights) == 0 {
+		v.add(SeverityWarning, path.Key("weights"), "no plugin weights set")
+		return
+	}
+
+	plugins := make([]string, 0, len(e.Weights))
+	for name := range e.Weights {
+		plugins = append(plugins, name)
+	}
+	sort.Strings(plugins)
+
+	for _, name := range plugins {
+		if !v.knownPlugins[name] {
+			v.add(SeverityError, path.Key("weights").Key(name), "unknown plugin %q", name)
+		}
+		if weight := e.Weights[name]; weight < 1 {
+			v.add(SeverityError, path.Key("weights").Key(name), "weight of plugin %q must be at least 1, got %d", name, weight)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var invalidScenarioYaml = `metadata:
+  name: invalid
+events:
+  pods:
+    - name: no-spec
+      arrivalTime: 5s
+    - name: no-containers
+      arrivalTime: -5s
+      podSpec:
+        metadata:
+          name: empty
+          namespace: default
+        spec:
+          containers: []
+    - name: typo
+      arrivalTime: 5s
+      evictime: 10s
+      podSpec:
+        metadata:
+          name: typo
+  scheduler:
+    - name: weights
+      arrivalTime: 1m
+      weights:
+        NodeResourcesFit: 0
+        NotAPlugin: 2
+`
+
+func findDiagnostic(diags Diagnostics, path string) (Diagnostic, bool) {
+	for _, d := range diags {
+		if d.Path == path {
+			return d, true
+		}
+	}
+	return Diagnostic{}, false
+}
+
+func TestValidate_Structural(t *testing.T) {
+	diags := Validate([]byte(invalidScenarioYaml))
+	assert.True(t, diags.HasErrors())
+
+	// structural errors stop the validation before the semantic checks
+	d, ok := findDiagnostic(diags, "events.pods[2].evictime")
+	assert.True(t, ok)
+	assert.Equal(t, SeverityError, d.Severity)
+	assert.Equal(t, 17, d.Line)
+	assert.Equal(t, 7, d.Column)
+}
+
+var semanticErrorsScenarioYaml = `metadata:
+  name: semantic
+events:
+  pods:
+    - name: no-spec
+      arrivalTime: 5s
+    - name: no-containers
+      arrivalTime: -5s
+      podSpec:
+        metadata:
+          name: empty
+          namespace: default
+        spec:
+          containers: []
+  scheduler:
+    - name: weights
+      arrivalTime: 1m
+      weights:
+        NodeResourcesFit: 0
+        NotAPlugin: 2
+`
+
+func TestValidate_Semantic(t *testing.T) {
+	diags := Validate([]byte(semanticErrorsScenarioYaml))
+
+	expected := map[string]int{
+		"events.pods[0]":                               5,
+		"events.pods[1].arrivalTime":                   8,
+		"events.pods[1].podSpec.spec.containers":       14,
+		"events.scheduler[0].weights.NodeResourcesFit": 19,
+		"events.scheduler[0].weights.NotAPlugin":       20,
+	}
+	for path, line := range expected {
+		d, ok := findDiagnostic(diags, path)
+		if assert.True(t, ok, "missing diagnostic for %s in %v", path, diags) {
+			assert.Equal(t, line, d.Line, path)
+			assert.Equal(t, SeverityError, d.Severity, path)
+		}
+	}
+}
+
+func TestValidate_ValidScenario(t *testing.T) {
+	diags := Validate([]byte(templateScenarioYaml))
+	assert.False(t, diags.HasErrors(), "%v", diags)
+
+	diags = Validate([]byte(templateScenarioYaml), WithKnownPlugins("MyPlugin"))
+	assert.False(t, diags.HasErrors(), "%v", diags)
+}
+
+func TestValidate_SyntaxError(t *testing.T) {
+	diags := Validate([]byte("metadata:\n  name: x\n events: [\n"))
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, SeverityError, diags[0].Severity)
+		assert.Greater(t, diags[0].Line, 0)
+	}
+}
+
+var distributionScenarioYaml = `metadata:
+  name: distributions
+events:
+  pods:
+    - name: bad-rate
+      replicas: 5
+      distribution: { type: exponential, rate: 0 }
+      podSpec:
+        spec:
+          containers: [{ name: main, image: nginx }]
+    - name: both
+      replicas: 5
+      spacing: 1s
+      evictTime: 10s
+      distribution: { type: constant, value: 1 }
+      lifetime: { type: uniform, min: 5, max: 10 }
+      podSpec:
+        spec:
+          containers: [{ name: main, image: nginx }]
+    - name: single
+      distribution: { type: weibull, shape: 1.5, scale: 2 }
+      podSpec:
+        spec:
+          containers: [{ name: main, image: nginx }]
+`
+
+func TestValidate_Distributions(t *testing.T) {
+	diags := Validate([]byte(distributionScenarioYaml))
+
+	tests := []struct {
+		path     string
+		severity Severity
+		message  string
+	}{
+		{path: "events.pods[0].distribution", severity: SeverityError, message: "rate must be positive"},
+		{path: "events.pods[1].distribution", severity: SeverityError, message: "mutually exclusive"},
+		{path: "events.pods[1].lifetime", severity: SeverityError, message: "mutually exclusive"},
+		{path: "events.pods[2].distribution", severity: SeverityWarning, message: "no effect"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			d, ok := findDiagnostic(diags, tt.path)
+			if assert.True(t, ok, diags) {
+				assert.Equal(t, tt.severity, d.Severity)
+				assert.Contains(t, d.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidate_Termination(t *testing.T) {
+	diags := Validate([]byte(`metadata:
+  name: termination
+termination:
+  duration: -1s
+  pendingIdle: 30s
+events:
+  pods: []
+`))
+	d, ok := findDiagnostic(diags, "termination.duration")
+	if assert.True(t, ok, "missing diagnostic in %v", diags) {
+		assert.Equal(t, SeverityError, d.Severity)
+		assert.Equal(t, 4, d.Line)
+	}
+	_, ok = findDiagnostic(diags, "termination.pendingIdle")
+	assert.False(t, ok)
+}
+
+func TestValidate_EvictionPolicy(t *testing.T) {
+	diags := Validate([]byte(`metadata:
+  name: eviction
+eviction:
+  evictFrom: bound
+events:
+  pods:
+    - name: web
+      evictTime: 1m
+      onStartTimeout: retry
+      podSpec:
+        spec:
+          containers:
+            - name: main
+              image: nginx
+`))
+	for path, line := range map[string]int{"eviction.evictFrom": 4, "events.pods[0].onStartTimeout": 9} {
+		d, ok := findDiagnostic(diags, path)
+		if assert.True(t, ok, "missing diagnostic for %s in %v", path, diags) {
+			assert.Equal(t, SeverityError, d.Severity, path)
+			assert.Equal(t, line, d.Line, path)
+		}
+	}
+}
+
+func TestValidate_WorkloadKindMismatch(t *testing.T) {
+	diags := Validate([]byte(`metadata:
+  name: workloads
+events:
+  workloads:
+    - name: web
+      kind: StatefulSet
+      deployment:
+        metadata:
+          name: web
+        spec:
+          selector:
+            matchLabels: { app: web }
+          template:
+            metadata:
+              labels: { app: web }
+            spec:
+              containers:
+                - name: web
+                  image: nginx
+`))
+	d, ok := findDiagnostic(diags, "events.workloads[0].kind")
+	if assert.True(t, ok, "missing diagnostic in %v", diags) {
+		assert.Equal(t, SeverityError, d.Severity)
+		assert.Equal(t, 6, d.Line)
+		assert.Contains(t, d.Message, "kind StatefulSet does not match the Deployment spec")
+	}
+}
+
+func TestValidate_Lifecycle(t *testing.T) {
+	diags := Validate([]byte(`metadata:
+  name: lifecycle
+events:
+  pods:
+    - name: batch
+      lifecycle:
+        outcome: explode
+        after: -5s
+      podSpec:
+        spec:
+          containers:
+            - name: main
+              image: busybox
+    - name: task
+      lifecycle:
+        outcome: succeed
+        after: 10s
+        exitCode: 3
+      podSpec:
+        spec:
+          containers:
+            - name: main
+              image: busybox
+`))
+	tests := []struct {
+		path     string
+		severity Severity
+		line     int
+	}{
+		{path: "events.pods[0].lifecycle.outcome", severity: SeverityError, line: 7},
+		{path: "events.pods[0].lifecycle.after", severity: SeverityError, line: 8},
+		{path: "events.pods[1].lifecycle.exitCode", severity: SeverityWarning, line: 18},
+	}
+	for _, tt := range tests {
+		d, ok := findDiagnostic(diags, tt.path)
+		if assert.True(t, ok, "missing diagnostic for %s in %v", tt.path, diags) {
+			assert.Equal(t, tt.severity, d.Severity, tt.path)
+			assert.Equal(t, tt.line, d.Line, tt.path)
+		}
+	}
+}
+
+func TestValidate_PriorityClasses(t *testing.T) {
+	diags := Validate([]byte(`metadata:
+  name: priority
+cluster:
+  priorityClasses:
+    - metadata:
+        name: batch
+      value: 10
+    - metadata:
+        name: batch
+      value: 2000000000
+      preemptionPolicy: Sometimes
+events:
+  pods: []
+`))
+	for path, line := range map[string]int{
+		"cluster.priorityClasses[1].metadata.name":    9,
+		"cluster.priorityClasses[1].value":            10,
+		"cluster.priorityClasses[1].preemptionPolicy": 11,
+	} {
+		d, ok := findDiagnostic(diags, path)
+		if assert.True(t, ok, "missing diagnostic for %s in %v", path, diags) {
+			assert.Equal(t, SeverityError, d.Severity, path)
+			assert.Equal(t, line, d.Line, path)
+		}
+	}
+	_, ok := findDiagnostic(diags, "cluster.priorityClasses[0].metadata.name")
+	assert.False(t, ok)
+}
+
+func TestValidate_SchedulerProfile(t *testing.T) {
+	diags := Validate([]byte(schedulersScenarioYaml))
+	assert.False(t, diags.HasErrors(), "%v", diags)
+	_, ok := findDiagnostic(diags, "events.scheduler[0].profile")
+	assert.False(t, ok, "the binpack profile is used by the binpack pods")
+
+	diags = Validate([]byte(strings.Replace(schedulersScenarioYaml, "profile: binpack", "profile: spread", 1)))
+	d, ok := findDiagnostic(diags, "events.scheduler[0].profile")
+	if assert.True(t, ok, "%v", diags) {
+		assert.Equal(t, SeverityWarning, d.Severity)
+		assert.Equal(t, 48, d.Line)
+	}
+}
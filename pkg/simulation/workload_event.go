@@ -0,0 +1,449 @@
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	eventscheduler "github.com/maczg/kube-event-generator/pkg/scheduler"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// WorkloadKind represents the kind of controller managed by a WorkloadEvent
+type WorkloadKind string
+
+const (
+	WorkloadKindDeployment  WorkloadKind = "Deployment"
+	WorkloadKindReplicaSet  WorkloadKind = "ReplicaSet"
+	WorkloadKindStatefulSet WorkloadKind = "StatefulSet"
+	WorkloadKindJob         WorkloadKind = "Job"
+)
+
+// WorkloadAction represents the action performed by a WorkloadEvent
+type WorkloadAction string
+
+const (
+	// WorkloadActionCreate creates the workload
+	WorkloadActionCreate WorkloadAction = "create"
+	// WorkloadActionScale changes the number of replicas (or parallelism for jobs)
+	WorkloadActionScale WorkloadAction = "scale"
+	// WorkloadActionDelete deletes the workload and its pods
+	WorkloadActionDelete WorkloadAction = "delete"
+)
+
+// WorkloadEvent represents a controller-level event (create, scale or delete a workload).
+// Pods are created by the controllers running in the cluster, so they carry ownerReferences
+// and follow the rollout strategy (e.g. maxSurge) declared in the workload spec.
+type WorkloadEvent struct {
+	*eventscheduler.BaseEvent
+	// Name is the name of the workload event
+	Name string `yaml:"name" json:"name"`
+	// ArrivalTime is the time when the event arrives in the scheduler
+	ArrivalTime EventDuration `yaml:"arrivalTime" json:"arrivalTime"`
+	// EvictTime is the time after creation when the workload should be deleted
+	EvictTime EventDuration `yaml:"evictTime" json:"evictTime"`
+	// Action is the action performed on the workload (create, scale or delete)
+	Action WorkloadAction `yaml:"action" json:"action"`
+	// Kind is the kind of workload. It is inferred from the spec on create events.
+	Kind WorkloadKind `yaml:"kind" json:"kind"`
+	// Target is the name of the workload to scale or delete. Defaults to the spec name.
+	Target string `yaml:"target" json:"target"`
+	// Namespace is the namespace of the workload. Defaults to the spec namespace or the namespace of the simulation.
+	Namespace string `yaml:"namespace" json:"namespace"`
+	// Replicas is the desired number of replicas on create and scale events
+	Replicas *int32 `yaml:"replicas" json:"replicas"`
+	// Deployment is the deployment to create
+	Deployment *appsv1.Deployment `yaml:"deployment" json:"deployment,omitempty"`
+	// ReplicaSet is the replica set to create
+	ReplicaSet *appsv1.ReplicaSet `yaml:"replicaSet" json:"replicaSet,omitempty"`
+	// StatefulSet is the stateful set to create
+	StatefulSet *appsv1.StatefulSet `yaml:"statefulSet" json:"statefulSet,omitempty"`
+	// Job is the job to create
+	Job *batchv1.Job `yaml:"job" json:"job,omitempty"`
+	// Lifecycle makes the pods of the workload succeed, fail or crash-loop on their own once running
+	Lifecycle *Lifecycle `yaml:"lifecycle" json:"lifecycle,omitempty"`
+	// Clientset is the Kubernetes clientset used to interact with the cluster
+	clientset kubernetes.Interface
+}
+
+// NewCreateWorkloadEvent creates a new workload creation event. The workload kind is inferred from obj.
+func NewCreateWorkloadEvent(name string, arrivalTime, evictionTime time.Duration, obj metav1.Object) (*WorkloadEvent, error) {
+	e := &WorkloadEvent{
+		BaseEvent: eventscheduler.NewBaseEvent(arrivalTime, evictionTime),
+		Name:      name,
+		Action:    WorkloadActionCreate,
+	}
+
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		e.Deployment = o
+	case *appsv1.ReplicaSet:
+		e.ReplicaSet = o
+	case *appsv1.StatefulSet:
+		e.StatefulSet = o
+	case *batchv1.Job:
+		e.Job = o
+	default:
+		return nil, fmt.Errorf("unsupported workload type %T", obj)
+	}
+
+	e.Kind = e.inferKind()
+	return e, nil
+}
+
+// NewScaleWorkloadEvent creates a new event that scales the named workload to replicas
+func NewScaleWorkloadEvent(arrivalTime time.Duration, kind WorkloadKind, namespace, target string, replicas int32) *WorkloadEvent {
+	return &WorkloadEvent{
+		BaseEvent: eventscheduler.NewBaseEvent(arrivalTime, 0),
+		Name:      fmt.Sprintf("scale-%s-%s", kind, target),
+		Action:    WorkloadActionScale,
+		Kind:      kind,
+		Target:    target,
+		Namespace: namespace,
+		Replicas:  &replicas,
+	}
+}
+
+// NewDeleteWorkloadEvent creates a new event that deletes the named workload
+func NewDeleteWorkloadEvent(arrivalTime time.Duration, kind WorkloadKind, namespace, target string) *WorkloadEvent {
+	return &WorkloadEvent{
+		BaseEvent: eventscheduler.NewBaseEvent(arrivalTime, 0),
+		Name:      fmt.Sprintf("delete-%s-%s", kind, target),
+		Action:    WorkloadActionDelete,
+		Kind:      kind,
+		Target:    target,
+		Namespace: namespace,
+	}
+}
+
+func (e *WorkloadEvent) SetClientset(clientset kubernetes.Interface) {
+	e.clientset = clientset
+}
+
+// Execute implements the workload-specific execution logic
+func (e *WorkloadEvent) Execute(ctx context.Context) error {
+	e.SetStatus(eventscheduler.EventStatusExecuting)
+	defer func() {
+		if e.GetStatus() == eventscheduler.EventStatusExecuting {
+			e.SetStatus(eventscheduler.EventStatusCompleted)
+		}
+	}()
+
+	if e.clientset == nil {
+		return errors.New("clientset is nil")
+	}
+
+	var err error
+	switch e.Action {
+	case WorkloadActionCreate:
+		err = e.create(ctx)
+	case WorkloadActionScale:
+		err = e.scale(ctx)
+	case WorkloadActionDelete:
+		err = e.delete(ctx)
+	default:
+		err = fmt.Errorf("unknown workload action %q", e.Action)
+	}
+
+	if err != nil {
+		logger.Default().Errorf("failed to %s %s %s: %v", e.Action, e.Kind, e.TargetName(), err)
+		if e.Action == WorkloadActionCreate {
+			// a workload that is not created, or whose deletion is not scheduled, is never evicted
+			trackEvictionEnded(ctx, string(e.Kind), e.TargetNamespace(), e.TargetName())
+		}
+		return err
+	}
+	return nil
+}
+
+// TargetName returns the name of the workload the event acts on
+func (e *WorkloadEvent) TargetName() string {
+	if e.Target != "" {
+		return e.Target
+	}
+	if obj := e.object(); obj != nil && obj.GetName() != "" {
+		return obj.GetName()
+	}
+	return e.Name
+}
+
+// TargetNamespace returns the namespace of the workload the event acts on
+func (e *WorkloadEvent) TargetNamespace() string {
+	if e.Namespace != "" {
+		return e.Namespace
+	}
+	if obj := e.object(); obj != nil && obj.GetNamespace() != "" {
+		return obj.GetNamespace()
+	}
+	return metav1.NamespaceDefault
+}
+
+// pinsNamespace reports whether the event, or its workload spec, sets the namespace of the workload
+func (e *WorkloadEvent) pinsNamespace() bool {
+	if e.Namespace != "" {
+		return true
+	}
+	obj := e.object()
+	return obj != nil && obj.GetNamespace() != ""
+}
+
+// object returns the workload spec carried by the event, if any
+func (e *WorkloadEvent) object() metav1.Object {
+	switch {
+	case e.Deployment != nil:
+		return e.Deployment
+	case e.ReplicaSet != nil:
+		return e.ReplicaSet
+	case e.StatefulSet != nil:
+		return e.StatefulSet
+	case e.Job != nil:
+		return e.Job
+	}
+	return nil
+}
+
+// podTemplate returns the pod template of the workload spec carried by the event, if any
+func (e *WorkloadEvent) podTemplate() *v1.PodTemplateSpec {
+	switch {
+	case e.Deployment != nil:
+		return &e.Deployment.Spec.Template
+	case e.ReplicaSet != nil:
+		return &e.ReplicaSet.Spec.Template
+	case e.StatefulSet != nil:
+		return &e.StatefulSet.Spec.Template
+	case e.Job != nil:
+		return &e.Job.Spec.Template
+	}
+	return nil
+}
+
+// hasSpec reports whether the event carries a workload spec of the given kind
+func (e *WorkloadEvent) hasSpec(kind WorkloadKind) bool {
+	switch kind {
+	case WorkloadKindDeployment:
+		return e.Deployment != nil
+	case WorkloadKindReplicaSet:
+		return e.ReplicaSet != nil
+	case WorkloadKindStatefulSet:
+		return e.StatefulSet != nil
+	case WorkloadKindJob:
+		return e.Job != nil
+	}
+	return false
+}
+
+// inferKind returns the kind of the workload spec carried by the event
+func (e *WorkloadEvent) inferKind() WorkloadKind {
+	switch {
+	case e.Deployment != nil:
+		return WorkloadKindDeployment
+	case e.ReplicaSet != nil:
+		return WorkloadKindReplicaSet
+	case e.StatefulSet != nil:
+		return WorkloadKindStatefulSet
+	case e.Job != nil:
+		return WorkloadKindJob
+	}
+	return ""
+}
+
+// create creates the workload and schedules its deletion if an eviction time is set
+func (e *WorkloadEvent) create(ctx context.Context) error {
+	if !e.hasSpec(e.Kind) {
+		return fmt.Errorf("no workload spec for kind %q", e.Kind)
+	}
+	name := e.TargetName()
+	ns := e.TargetNamespace()
+	apps := e.clientset.AppsV1()
+
+	var err error
+	switch e.Kind {
+	case WorkloadKindDeployment:
+		obj := e.prepare(ctx, e.Deployment.DeepCopy()).(*appsv1.Deployment)
+		stampOwnership(ctx, &obj.Spec.Template, e.Name, e.GetID())
+		e.Lifecycle.annotate(&obj.Spec.Template.ObjectMeta)
+		if e.Replicas != nil {
+			obj.Spec.Replicas = e.Replicas
+		}
+		_, err = apps.Deployments(ns).Create(ctx, obj, metav1.CreateOptions{})
+	case WorkloadKindReplicaSet:
+		obj := e.prepare(ctx, e.ReplicaSet.DeepCopy()).(*appsv1.ReplicaSet)
+		stampOwnership(ctx, &obj.Spec.Template, e.Name, e.GetID())
+		e.Lifecycle.annotate(&obj.Spec.Template.ObjectMeta)
+		if e.Replicas != nil {
+			obj.Spec.Replicas = e.Replicas
+		}
+		_, err = apps.ReplicaSets(ns).Create(ctx, obj, metav1.CreateOptions{})
+	case WorkloadKindStatefulSet:
+		obj := e.prepare(ctx, e.StatefulSet.DeepCopy()).(*appsv1.StatefulSet)
+		stampOwnership(ctx, &obj.Spec.Template, e.Name, e.GetID())
+		e.Lifecycle.annotate(&obj.Spec.Template.ObjectMeta)
+		if e.Replicas != nil {
+			obj.Spec.Replicas = e.Replicas
+		}
+		_, err = apps.StatefulSets(ns).Create(ctx, obj, metav1.CreateOptions{})
+	case WorkloadKindJob:
+		obj := e.prepare(ctx, e.Job.DeepCopy()).(*batchv1.Job)
+		stampOwnership(ctx, &obj.Spec.Template, e.Name, e.GetID())
+		e.Lifecycle.annotate(&obj.Spec.Template.ObjectMeta)
+		if e.Replicas != nil {
+			obj.Spec.Parallelism = e.Replicas
+		}
+		_, err = e.clientset.BatchV1().Jobs(ns).Create(ctx, obj, metav1.CreateOptions{})
+	default:
+		return fmt.Errorf("no workload spec for kind %q", e.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Default().Infof("event %s with %s %s created successfully", e.GetID(), e.Kind, name)
+	trackCreated(ctx, string(e.Kind), ns, name)
+
+	if e.EvictTime <= 0 {
+		return nil
+	}
+	return e.scheduleDeletion(ctx)
+}
+
+// prepare sets the name, namespace and ownership of a workload copy before it is created
+func (e *WorkloadEvent) prepare(ctx context.Context, obj metav1.Object) metav1.Object {
+	obj.SetName(e.TargetName())
+	obj.SetNamespace(e.TargetNamespace())
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	stampOwnership(ctx, obj, e.Name, e.GetID())
+	return obj
+}
+
+// scale updates the replicas of the workload. Jobs are scaled by changing their parallelism.
+func (e *WorkloadEvent) scale(ctx context.Context) error {
+	if e.Replicas == nil {
+		return errors.New("replicas not set on scale event")
+	}
+
+	name := e.TargetName()
+	ns := e.TargetNamespace()
+	replicas := *e.Replicas
+	apps := e.clientset.AppsV1()
+
+	switch e.Kind {
+	case WorkloadKindDeployment:
+		scale, err := apps.Deployments(ns).GetScale(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		scale.Spec.Replicas = replicas
+		if _, err = apps.Deployments(ns).UpdateScale(ctx, name, scale, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	case WorkloadKindReplicaSet:
+		scale, err := apps.ReplicaSets(ns).GetScale(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		scale.Spec.Replicas = replicas
+		if _, err = apps.ReplicaSets(ns).UpdateScale(ctx, name, scale, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	case WorkloadKindStatefulSet:
+		scale, err := apps.StatefulSets(ns).GetScale(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		scale.Spec.Replicas = replicas
+		if _, err = apps.StatefulSets(ns).UpdateScale(ctx, name, scale, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	case WorkloadKindJob:
+		patch := fmt.Sprintf(`{"spec":{"parallelism":%d}}`, replicas)
+		if _, err := e.clientset.BatchV1().Jobs(ns).Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown workload kind %q", e.Kind)
+	}
+
+	logger.Default().Infof("%s %s scaled to %d replicas", e.Kind, name, replicas)
+	return nil
+}
+
+// delete removes the workload. Owned pods are removed by the garbage collector.
+func (e *WorkloadEvent) delete(ctx context.Context) error {
+	name := e.TargetName()
+	ns := e.TargetNamespace()
+	apps := e.clientset.AppsV1()
+
+	propagation := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+
+	var err error
+	switch e.Kind {
+	case WorkloadKindDeployment:
+		err = apps.Deployments(ns).Delete(ctx, name, opts)
+	case WorkloadKindReplicaSet:
+		err = apps.ReplicaSets(ns).Delete(ctx, name, opts)
+	case WorkloadKindStatefulSet:
+		err = apps.StatefulSets(ns).Delete(ctx, name, opts)
+	case WorkloadKindJob:
+		err = e.clientset.BatchV1().Jobs(ns).Delete(ctx, name, opts)
+	default:
+		return fmt.Errorf("unknown workload kind %q", e.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Default().Infof("%s %s deleted successfully", e.Kind, name)
+	trackEvictionEnded(ctx, string(e.Kind), ns, name)
+	return nil
+}
+
+// scheduleDeletion schedules the deletion of the workload EvictTime after its creation
+func (e *WorkloadEvent) scheduleDeletion(ctx context.Context) error {
+	scheduler, ok := ctx.Value(eventscheduler.SchedulerContextKey).(eventscheduler.Scheduler)
+	if !ok {
+		return errors.New("scheduler not found in context")
+	}
+
+	deletionTime := time.Since(scheduler.StartedAt()) + e.EvictTime.Duration()
+	deleteEvent := NewDeleteWorkloadEvent(deletionTime, e.Kind, e.TargetNamespace(), e.TargetName())
+	deleteEvent.SetClientset(e.clientset)
+
+	if err := scheduler.Schedule(deleteEvent); err != nil {
+		return err
+	}
+
+	logger.Default().Debugf("scheduled deletion for %s %s at %s", e.Kind, e.TargetName(), deletionTime)
+	return nil
+}
+
+// UnmarshalJSON implements custom JSON unmarshalling for WorkloadEvent.
+// It defaults the action to create and infers the kind from the workload spec.
+func (e *WorkloadEvent) UnmarshalJSON(data []byte) error {
+	type Alias WorkloadEvent
+	var temp Alias
+
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	*e = WorkloadEvent(temp)
+	if e.Action == "" {
+		e.Action = WorkloadActionCreate
+	}
+	if e.Kind == "" {
+		e.Kind = e.inferKind()
+	}
+	e.BaseEvent = eventscheduler.NewBaseEvent(temp.ArrivalTime.Duration(), temp.EvictTime.Duration())
+
+	return nil
+}
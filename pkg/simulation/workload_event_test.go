@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var workloadEventsYaml = `
+- name: web
+  arrivalTime: 5s
+  evictTime: 1m
+  replicas: 3
+  deployment:
+    metadata:
+      name: web
+    spec:
+      selector:
+        matchLabels:
+          app: web
+      strategy:
+        rollingUpdate:
+          maxSurge: 50%
+      template:
+        metadata:
+          labels:
+            app: web
+        spec:
+          containers:
+            - name: web
+              image: nginx
+- name: web-scale-up
+  arrivalTime: 20s
+  action: scale
+  kind: Deployment
+  target: web
+  replicas: 6
+`
+
+func TestWorkloadEvent_UnmarshalJSON(t *testing.T) {
+	var events []WorkloadEvent
+	require.NoError(t, yaml.Unmarshal([]byte(workloadEventsYaml), &events))
+	require.Len(t, events, 2)
+
+	create := events[0]
+	assert.Equal(t, WorkloadActionCreate, create.Action)
+	assert.Equal(t, WorkloadKindDeployment, create.Kind)
+	assert.Equal(t, "web", create.TargetName())
+	assert.Equal(t, "default", create.TargetNamespace())
+	assert.Equal(t, int32(3), *create.Replicas)
+	assert.Equal(t, 5*time.Second, create.Arrival())
+	assert.Equal(t, time.Minute, create.Eviction())
+
+	scale := events[1]
+	assert.Equal(t, WorkloadActionScale, scale.Action)
+	assert.Equal(t, WorkloadKindDeployment, scale.Kind)
+	assert.Equal(t, "web", scale.TargetName())
+	assert.Equal(t, int32(6), *scale.Replicas)
+	assert.Equal(t, 20*time.Second, scale.Arrival())
+}
+
+func TestWorkloadEvent_CreateKindMismatch(t *testing.T) {
+	var events []WorkloadEvent
+	require.NoError(t, yaml.Unmarshal([]byte(workloadEventsYaml), &events))
+	event := events[0]
+	event.Kind = WorkloadKindStatefulSet
+	event.SetClientset(fake.NewSimpleClientset())
+
+	assert.ErrorContains(t, event.Execute(context.Background()), `no workload spec for kind "StatefulSet"`)
+}
@@ -0,0 +1,94 @@
+package simulation
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ghodss/yaml"
+)
+
+// MarshalScenario marshals a scenario to YAML. Null values and empty statuses, such as the creation
+// timestamp and status of pod specs, are left out.
+func MarshalScenario(s *Scenario) ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(prune(doc))
+}
+
+// WriteScenario writes a scenario to a YAML file.
+func WriteScenario(filename string, s *Scenario) error {
+	data, err := MarshalScenario(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// prune removes null values and empty statuses from a decoded JSON document, along with the zero
+// valued structs of statuses, such as the node info of a node. Other empty values are kept, as they
+// can be meaningful, e.g. an emptyDir volume.
+func prune(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == nil {
+				delete(v, key)
+				continue
+			}
+			if key == "status" {
+				if status, ok := item.(map[string]interface{}); ok {
+					if pruneZero(status); len(status) == 0 {
+						delete(v, key)
+						continue
+					}
+				}
+			}
+			v[key] = prune(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = prune(item)
+		}
+	}
+	return value
+}
+
+// pruneZero removes the fields of m holding objects whose values are all zero.
+func pruneZero(m map[string]interface{}) {
+	for key, item := range m {
+		if child, ok := item.(map[string]interface{}); ok {
+			if pruneZero(child); isZero(child) {
+				delete(m, key)
+			}
+		}
+	}
+}
+
+// isZero reports whether value is null, an empty string, zero, false, or an object of zero values.
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isZero(item) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalScenario(t *testing.T) {
+	scenario, err := Load([]byte(timelineScenarioYaml))
+	require.NoError(t, err)
+
+	data, err := MarshalScenario(scenario)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "creationTimestamp")
+	assert.NotContains(t, string(data), "nodeInfo")
+	assert.NotContains(t, string(data), "null")
+
+	reloaded, err := Load(data)
+	require.NoError(t, err)
+	assert.Equal(t, scenario.Metadata, reloaded.Metadata)
+	assert.Equal(t, scenario.Cluster.Nodes, reloaded.Cluster.Nodes)
+	require.Len(t, reloaded.Events.Pods, len(scenario.Events.Pods))
+	for i := range scenario.Events.Pods {
+		assert.Equal(t, scenario.Events.Pods[i].Name, reloaded.Events.Pods[i].Name)
+		assert.Equal(t, scenario.Events.Pods[i].EvictTime, reloaded.Events.Pods[i].EvictTime)
+		assert.Equal(t, scenario.Events.Pods[i].PodSpec, reloaded.Events.Pods[i].PodSpec)
+	}
+	require.Len(t, reloaded.Events.Workloads, len(scenario.Events.Workloads))
+}
+
+func TestPrune(t *testing.T) {
+	doc := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "pod", "creationTimestamp": nil},
+		"spec": map[string]interface{}{
+			"volumes": []interface{}{map[string]interface{}{"name": "tmp", "emptyDir": map[string]interface{}{}}},
+		},
+		"status": map[string]interface{}{
+			"capacity": map[string]interface{}{"cpu": "1"},
+			"nodeInfo": map[string]interface{}{"bootID": "", "port": float64(0)},
+		},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "pod"},
+		"spec": map[string]interface{}{
+			"volumes": []interface{}{map[string]interface{}{"name": "tmp", "emptyDir": map[string]interface{}{}}},
+		},
+		"status": map[string]interface{}{
+			"capacity": map[string]interface{}{"cpu": "1"},
+		},
+	}, prune(doc))
+}
@@ -0,0 +1,161 @@
+package sweep
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+)
+
+// AggregateFile is the name of the per-combination aggregate written when runs are repeated.
+const AggregateFile = "aggregate.csv"
+
+// Metric is a figure of the run summary aggregated across repetitions.
+type Metric struct {
+	Name  string
+	Value func(cache.Summary) float64
+}
+
+// Metrics are the figures aggregated across repetitions.
+var Metrics = []Metric{
+	{Name: "pending_mean_s", Value: func(s cache.Summary) float64 { return s.PendingMean.Seconds() }},
+	{Name: "pending_p95_s", Value: func(s cache.Summary) float64 { return s.PendingP95.Seconds() }},
+	{Name: "cpu_allocation", Value: func(s cache.Summary) float64 { return s.CPUAllocation }},
+	{Name: "memory_allocation", Value: func(s cache.Summary) float64 { return s.MemoryAllocation }},
+	{Name: "makespan_s", Value: func(s cache.Summary) float64 { return s.Makespan.Seconds() }},
+}
+
+// Estimate is the mean of a sample and the half width of its 95% confidence interval.
+type Estimate struct {
+	Mean float64
+	CI95 float64
+}
+
+// NewEstimate computes the mean and the 95% confidence interval (Student's t) of values.
+// The interval is zero with less than two values.
+func NewEstimate(values []float64) Estimate {
+	n := len(values)
+	if n == 0 {
+		return Estimate{}
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(n)
+	if n < 2 {
+		return Estimate{Mean: mean}
+	}
+
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(squares / float64(n-1))
+	return Estimate{Mean: mean, CI95: tQuantile975(n-1) * stddev / math.Sqrt(float64(n))}
+}
+
+// tTable holds the 0.975 quantiles of Student's t distribution for 1 to 30 degrees of freedom.
+var tTable = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// tQuantile975 returns the 0.975 quantile of Student's t distribution with df degrees of freedom.
+func tQuantile975(df int) float64 {
+	switch {
+	case df <= len(tTable):
+		return tTable[df-1]
+	case df <= 40:
+		return 2.021
+	case df <= 60:
+		return 2.000
+	case df <= 120:
+		return 1.980
+	}
+	return 1.960
+}
+
+// Aggregate is the estimate of every metric for a combination of the matrix.
+type Aggregate struct {
+	Run
+	// Repetitions is the number of successful repetitions
+	Repetitions int
+	// Estimates are in the order of Metrics
+	Estimates []Estimate
+}
+
+// AggregateResults groups the successful results by combination and estimates every metric.
+func AggregateResults(results []Result) []Aggregate {
+	aggregates := make([]Aggregate, 0)
+	values := make(map[int][][]float64)
+	index := make(map[int]int)
+
+	for _, result := range results {
+		i, ok := index[result.Index]
+		if !ok {
+			i = len(aggregates)
+			index[result.Index] = i
+			aggregates = append(aggregates, Aggregate{Run: result.Run})
+			values[i] = make([][]float64, len(Metrics))
+		}
+		if result.Err != nil {
+			continue
+		}
+		aggregates[i].Repetitions++
+		for m, metric := range Metrics {
+			values[i][m] = append(values[i][m], metric.Value(result.Summary))
+		}
+	}
+
+	for i := range aggregates {
+		for m := range Metrics {
+			aggregates[i].Estimates = append(aggregates[i].Estimates, NewEstimate(values[i][m]))
+		}
+	}
+	return aggregates
+}
+
+// WriteAggregate writes one row per combination with the mean and 95% confidence interval of every metric.
+func WriteAggregate(filename string, axes []Axis, aggregates []Aggregate) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := []string{"run"}
+	for _, axis := range axes {
+		header = append(header, axis.Column())
+	}
+	header = append(header, "repetitions")
+	for _, metric := range Metrics {
+		header = append(header, metric.Name+"_mean", metric.Name+"_ci95")
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, a := range aggregates {
+		row := append([]string{a.Name()}, a.Values...)
+		row = append(row, strconv.Itoa(a.Repetitions))
+		for _, e := range a.Estimates {
+			row = append(row, strconv.FormatFloat(e.Mean, 'f', 4, 64), strconv.FormatFloat(e.CI95, 'f', 4, 64))
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
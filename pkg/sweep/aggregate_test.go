@@ -0,0 +1,55 @@
+package sweep
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEstimate(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   Estimate
+	}{
+		{name: "empty", values: nil, want: Estimate{}},
+		{name: "single value", values: []float64{3}, want: Estimate{Mean: 3}},
+		{name: "constant", values: []float64{2, 2, 2}, want: Estimate{Mean: 2}},
+		// sd = 1, t(0.975, 2) = 4.303
+		{name: "three values", values: []float64{1, 2, 3}, want: Estimate{Mean: 2, CI95: 4.303 / 1.7320508075688772}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewEstimate(tt.values)
+			assert.InDelta(t, tt.want.Mean, got.Mean, 1e-9)
+			assert.InDelta(t, tt.want.CI95, got.CI95, 1e-9)
+		})
+	}
+}
+
+func TestAggregateResults(t *testing.T) {
+	run1 := Run{Index: 1, Values: []string{"1"}}
+	run2 := Run{Index: 2, Values: []string{"5"}}
+	results := []Result{
+		{Run: run1, Repetition: 1, Summary: cache.Summary{Makespan: 10 * time.Second}},
+		{Run: run1, Repetition: 2, Summary: cache.Summary{Makespan: 20 * time.Second}},
+		{Run: run2, Repetition: 1, Summary: cache.Summary{Makespan: 30 * time.Second}},
+		{Run: run2, Repetition: 2, Err: errors.New("failed")},
+	}
+
+	aggregates := AggregateResults(results)
+	require.Len(t, aggregates, 2)
+	require.Len(t, aggregates[0].Estimates, len(Metrics))
+
+	makespan := len(Metrics) - 1
+	assert.Equal(t, 2, aggregates[0].Repetitions)
+	assert.InDelta(t, 15, aggregates[0].Estimates[makespan].Mean, 1e-9)
+	assert.Greater(t, aggregates[0].Estimates[makespan].CI95, 0.0)
+	assert.Equal(t, 1, aggregates[1].Repetitions, "failed repetitions are not aggregated")
+	assert.InDelta(t, 30, aggregates[1].Estimates[makespan].Mean, 1e-9)
+}
@@ -0,0 +1,124 @@
+package sweep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AxisKind is the kind of value an axis varies.
+type AxisKind string
+
+const (
+	// AxisWeight varies the weight of a scheduler plugin
+	AxisWeight AxisKind = "weight"
+	// AxisParam varies a scenario parameter
+	AxisParam AxisKind = "param"
+)
+
+// Axis is a dimension of the sweep matrix.
+type Axis struct {
+	Kind   AxisKind
+	Name   string
+	Values []string
+}
+
+// Column returns the name of the axis in the summary table, e.g. weight:NodeResourcesFit.
+func (a Axis) Column() string {
+	return string(a.Kind) + ":" + a.Name
+}
+
+// ParseAxis parses an axis given as name=values, where values is either a comma separated list
+// (0.5,1,2) or an inclusive integer range with an optional step (1..10 or 1..10:3).
+func ParseAxis(kind AxisKind, spec string) (Axis, error) {
+	name, values, ok := strings.Cut(spec, "=")
+	if !ok || name == "" || values == "" {
+		return Axis{}, fmt.Errorf("invalid %s axis %q, expected name=values", kind, spec)
+	}
+
+	axis := Axis{Kind: kind, Name: name}
+	if from, to, ok := strings.Cut(values, ".."); ok {
+		step := "1"
+		if t, st, ok := strings.Cut(to, ":"); ok {
+			to, step = t, st
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		inc, err3 := strconv.Atoi(step)
+		if err1 != nil || err2 != nil || err3 != nil || start > end || inc < 1 {
+			return Axis{}, fmt.Errorf("invalid range in %s axis %q", kind, spec)
+		}
+		for v := start; v <= end; v += inc {
+			axis.Values = append(axis.Values, strconv.Itoa(v))
+		}
+	} else {
+		for _, v := range strings.Split(values, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				axis.Values = append(axis.Values, v)
+			}
+		}
+	}
+
+	if kind == AxisWeight {
+		for _, v := range axis.Values {
+			if w, err := strconv.ParseInt(v, 10, 32); err != nil || w < 1 {
+				return Axis{}, fmt.Errorf("invalid weight %q for plugin %s, expected an integer >= 1", v, name)
+			}
+		}
+	}
+	return axis, nil
+}
+
+// Run is a combination of the sweep matrix.
+type Run struct {
+	// Index is the position of the run in the matrix, starting at 1
+	Index int
+	// Values are the axis values of the run, in axis order
+	Values []string
+	// Weights are the scheduler plugin weights applied before the run
+	Weights map[string]int32
+	// Params are the scenario parameter overrides of the run
+	Params map[string]string
+}
+
+// Name returns the name of the run, used as its results subdirectory.
+func (r Run) Name() string {
+	return fmt.Sprintf("run-%03d", r.Index)
+}
+
+// Matrix returns the cartesian product of the axes. The first axis varies the slowest.
+func Matrix(axes []Axis) []Run {
+	runs := []Run{{Weights: map[string]int32{}, Params: map[string]string{}}}
+	for _, axis := range axes {
+		next := make([]Run, 0, len(runs)*len(axis.Values))
+		for _, run := range runs {
+			for _, value := range axis.Values {
+				r := Run{
+					Values:  append(append([]string{}, run.Values...), value),
+					Weights: make(map[string]int32, len(run.Weights)+1),
+					Params:  make(map[string]string, len(run.Params)+1),
+				}
+				for k, v := range run.Weights {
+					r.Weights[k] = v
+				}
+				for k, v := range run.Params {
+					r.Params[k] = v
+				}
+				switch axis.Kind {
+				case AxisWeight:
+					w, _ := strconv.ParseInt(value, 10, 32)
+					r.Weights[axis.Name] = int32(w)
+				case AxisParam:
+					r.Params[axis.Name] = value
+				}
+				next = append(next, r)
+			}
+		}
+		runs = next
+	}
+
+	for i := range runs {
+		runs[i].Index = i + 1
+	}
+	return runs
+}
@@ -0,0 +1,64 @@
+package sweep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAxis(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    AxisKind
+		spec    string
+		values  []string
+		wantErr bool
+	}{
+		{name: "list", kind: AxisParam, spec: "rate=0.5,1,2", values: []string{"0.5", "1", "2"}},
+		{name: "range", kind: AxisWeight, spec: "NodeResourcesFit=1..4", values: []string{"1", "2", "3", "4"}},
+		{name: "range with step", kind: AxisWeight, spec: "NodeResourcesFit=1..10:4", values: []string{"1", "5", "9"}},
+		{name: "missing values", kind: AxisParam, spec: "rate=", wantErr: true},
+		{name: "missing name", kind: AxisParam, spec: "=1", wantErr: true},
+		{name: "reversed range", kind: AxisWeight, spec: "ImageLocality=5..1", wantErr: true},
+		{name: "invalid weight", kind: AxisWeight, spec: "ImageLocality=0,1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			axis, err := ParseAxis(tt.kind, tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.values, axis.Values)
+		})
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	axes := []Axis{
+		{Kind: AxisWeight, Name: "NodeResourcesFit", Values: []string{"1", "2"}},
+		{Kind: AxisParam, Name: "rate", Values: []string{"0.5", "1", "2"}},
+	}
+
+	runs := Matrix(axes)
+	require.Len(t, runs, 6)
+
+	assert.Equal(t, 1, runs[0].Index)
+	assert.Equal(t, "run-001", runs[0].Name())
+	assert.Equal(t, []string{"1", "0.5"}, runs[0].Values)
+	assert.Equal(t, map[string]int32{"NodeResourcesFit": 1}, runs[0].Weights)
+	assert.Equal(t, map[string]string{"rate": "0.5"}, runs[0].Params)
+
+	assert.Equal(t, []string{"2", "2"}, runs[5].Values)
+	assert.Equal(t, map[string]int32{"NodeResourcesFit": 2}, runs[5].Weights)
+	assert.Equal(t, map[string]string{"rate": "2"}, runs[5].Params)
+}
+
+func TestMatrix_NoAxes(t *testing.T) {
+	runs := Matrix(nil)
+	require.Len(t, runs, 1)
+	assert.Empty(t, runs[0].Weights)
+}
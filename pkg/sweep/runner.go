@@ -0,0 +1,325 @@
+package sweep
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	"github.com/maczg/kube-event-generator/pkg/distribution"
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SummaryFile is the name of the aggregated summary written in the sweep output directory.
+const SummaryFile = "summary.csv"
+
+// cleanupTimeout bounds the cleanup of a run once it ends.
+const cleanupTimeout = time.Minute
+
+// ResetFunc restores the cluster and the scheduler between runs.
+type ResetFunc func(ctx context.Context) error
+
+// Result is the outcome of a repetition of a run.
+type Result struct {
+	Run
+	// Repetition is the repetition index, starting at 1
+	Repetition int
+	// Seed is the seed of the scenario distributions
+	Seed int64
+	// Dir is the results directory of the run
+	Dir string
+	// SimulationID is the ID of the simulation of the run
+	SimulationID string
+	// EndReason tells which end condition ended the simulation of the run
+	EndReason simulation.EndReason
+	// Summary aggregates the stats of the run
+	Summary cache.Summary
+	// Err is set if the run failed
+	Err error
+}
+
+// Runner runs the combinations of a sweep sequentially.
+type Runner struct {
+	logger      *logger.Logger
+	clientset   kubernetes.Interface
+	manager     kube.SchedulerManager
+	reset       ResetFunc
+	outputDir   string
+	params      map[string]string
+	repetitions int
+	seed        *int64
+	termination *simulation.Termination
+	simOpts     []simulation.SimulationOpt
+	cleanup     bool
+
+	// newSimulation creates the simulation of a run
+	newSimulation func(scenario *simulation.Scenario) simulation.Simulation
+}
+
+// RunnerOpt configures a Runner.
+type RunnerOpt func(*Runner)
+
+// WithOutputDir sets the directory where the run subdirectories and the summary are written.
+func WithOutputDir(dir string) RunnerOpt {
+	return func(r *Runner) {
+		r.outputDir = dir
+	}
+}
+
+// WithReset sets the function called before each run.
+func WithReset(reset ResetFunc) RunnerOpt {
+	return func(r *Runner) {
+		r.reset = reset
+	}
+}
+
+// WithCleanup deletes the objects created by the simulation of each run once it ends, and waits for them
+// to be gone before the next run. Only the objects labeled with the ID of the simulation are deleted, so
+// other simulations running on the cluster are left alone.
+func WithCleanup(cleanup bool) RunnerOpt {
+	return func(r *Runner) {
+		r.cleanup = cleanup
+	}
+}
+
+// WithBaseParams sets parameter overrides applied to every run. Axis values win.
+func WithBaseParams(params map[string]string) RunnerOpt {
+	return func(r *Runner) {
+		r.params = params
+	}
+}
+
+// WithRepetitions repeats every combination n times. Repetitions use different seeds, and the mean
+// and 95% confidence interval of the key metrics are written to aggregate.csv.
+func WithRepetitions(n int) RunnerOpt {
+	return func(r *Runner) {
+		r.repetitions = n
+	}
+}
+
+// WithSeed sets the base seed. Repetition i of every combination uses a seed derived from it and i,
+// so combinations are compared on the same random workloads. Repeated runs default to the scenario
+// seed, or a random one.
+func WithSeed(seed int64) RunnerOpt {
+	return func(r *Runner) {
+		r.seed = &seed
+	}
+}
+
+// WithTermination sets end conditions overriding the ones of the scenario in every run.
+func WithTermination(t *simulation.Termination) RunnerOpt {
+	return func(r *Runner) {
+		r.termination = t
+	}
+}
+
+// WithSimulationOpts sets options applied to the simulation of every run.
+func WithSimulationOpts(opts ...simulation.SimulationOpt) RunnerOpt {
+	return func(r *Runner) {
+		r.simOpts = append(r.simOpts, opts...)
+	}
+}
+
+// NewRunner creates a new sweep runner.
+func NewRunner(log *logger.Logger, clientset kubernetes.Interface, manager kube.SchedulerManager, opts ...RunnerOpt) *Runner {
+	r := &Runner{
+		logger:      log,
+		clientset:   clientset,
+		manager:     manager,
+		outputDir:   "results/sweep",
+		params:      map[string]string{},
+		repetitions: 1,
+	}
+	r.newSimulation = func(scenario *simulation.Scenario) simulation.Simulation {
+		return simulation.NewSimulation(scenario, r.clientset, r.manager, r.logger, r.simOpts...)
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run runs the scenario once per combination and repetition and writes the summary of all runs. A failed
+// run is recorded in the summary and does not stop the sweep. Once the context is cancelled, the running
+// simulation ends, the summary of the runs done so far is written and the context error is returned.
+func (r *Runner) Run(ctx context.Context, scenarioFile string, axes []Axis) ([]Result, error) {
+	runs := Matrix(axes)
+	results := make([]Result, 0, len(runs)*r.repetitions)
+
+runs:
+	for _, run := range runs {
+		for rep := 1; rep <= r.repetitions; rep++ {
+			if ctx.Err() != nil {
+				break runs
+			}
+
+			dir := r.outputDir
+			if len(axes) > 0 {
+				dir = filepath.Join(dir, run.Name())
+			}
+			if r.repetitions > 1 {
+				dir = filepath.Join(dir, fmt.Sprintf("rep-%03d", rep))
+			}
+
+			r.logger.Infof("sweep %s (%d/%d) repetition %d/%d: weights %v, params %v",
+				run.Name(), run.Index, len(runs), rep, r.repetitions, run.Weights, run.Params)
+			result := r.runOne(ctx, scenarioFile, run, rep, dir)
+			if result.Err != nil {
+				r.logger.Errorf("sweep %s repetition %d failed: %v", run.Name(), rep, result.Err)
+			}
+			results = append(results, result)
+		}
+	}
+
+	if err := WriteSummary(filepath.Join(r.outputDir, SummaryFile), axes, results); err != nil {
+		return results, err
+	}
+	if r.repetitions > 1 {
+		if err := WriteAggregate(filepath.Join(r.outputDir, AggregateFile), axes, AggregateResults(results)); err != nil {
+			return results, err
+		}
+	}
+	return results, ctx.Err()
+}
+
+func (r *Runner) runOne(ctx context.Context, scenarioFile string, run Run, rep int, dir string) Result {
+	result := Result{Run: run, Repetition: rep, Dir: dir}
+
+	if r.reset != nil {
+		if err := r.reset(ctx); err != nil {
+			result.Err = fmt.Errorf("reset failed: %w", err)
+			return result
+		}
+	}
+
+	params := make(map[string]string, len(r.params)+len(run.Params))
+	for k, v := range r.params {
+		params[k] = v
+	}
+	for k, v := range run.Params {
+		params[k] = v
+	}
+
+	scenario, err := simulation.LoadFromYaml(scenarioFile, simulation.WithParams(params))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if r.seed == nil && r.repetitions > 1 {
+		// the scenario seed, or a random one, is the base seed of all combinations
+		base := time.Now().UnixNano()
+		if scenario.Seed != nil {
+			base = *scenario.Seed
+		}
+		r.seed = &base
+		r.logger.Infof("using base seed %d", base)
+	}
+	if r.seed != nil {
+		seed := distribution.DeriveSeed(*r.seed, "repetition", rep)
+		scenario.Seed = &seed
+	}
+	result.Seed = scenario.EffectiveSeed()
+	if r.termination != nil {
+		scenario.Termination = scenario.Termination.Merge(r.termination)
+	}
+
+	if len(run.Weights) > 0 {
+		if err := r.manager.UpdatePluginWeights(ctx, run.Weights); err != nil {
+			result.Err = fmt.Errorf("failed to set plugin weights: %w", err)
+			return result
+		}
+	}
+
+	sim := r.newSimulation(scenario)
+	result.SimulationID = sim.GetID()
+	err = sim.Start(ctx)
+	result.EndReason = sim.EndReason()
+	if r.cleanup {
+		// the cleanup runs even if the simulation failed or the context is done
+		if err := r.deleteCreated(sim.GetID()); err != nil {
+			r.logger.Errorf("cleanup of simulation %s failed: %v", sim.GetID(), err)
+		}
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	stats := sim.GetStats()
+	if stats == nil {
+		result.Err = fmt.Errorf("simulation %s has no stats", sim.GetID())
+		return result
+	}
+	result.Summary = stats.Summarize()
+
+	if err := stats.ExportCSV(result.Dir); err != nil {
+		result.Err = err
+		return result
+	}
+	metadata := simulation.NewResultsMetadata(sim, scenario, scenarioFile)
+	metadata.Weights = run.Weights
+	if err := simulation.WriteResultsMetadata(result.Dir, metadata); err != nil {
+		result.Err = err
+	}
+	return result
+}
+
+// deleteCreated deletes the objects created by a simulation and waits until they are gone.
+func (r *Runner) deleteCreated(simulationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	objs, err := kube.ResetSelected(ctx, r.clientset, r.logger, kube.SimulationSelector(simulationID), false)
+	if err != nil {
+		return err
+	}
+	return kube.WaitDeleted(ctx, r.clientset, objs)
+}
+
+// WriteSummary writes one row per run with the axis values, the run summary and its error, if any.
+func WriteSummary(filename string, axes []Axis, results []Result) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := []string{"run"}
+	for _, axis := range axes {
+		header = append(header, axis.Column())
+	}
+	header = append(header, "repetition", "seed")
+	header = append(header, cache.SummaryHeader()...)
+	header = append(header, "end_reason", "simulation_id", "error")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		row := append([]string{result.Name()}, result.Values...)
+		row = append(row, strconv.Itoa(result.Repetition), strconv.FormatInt(result.Seed, 10))
+		row = append(row, result.Summary.Row()...)
+		errMsg := ""
+		if result.Err != nil {
+			errMsg = result.Err.Error()
+		}
+		row = append(row, string(result.EndReason), result.SimulationID, errMsg)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,244 @@
+package sweep
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/cache"
+	kube "github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	kubescheduler "k8s.io/kube-scheduler/config/v1"
+)
+
+var sweepScenarioYaml = `
+params:
+  replicas: 1
+metadata:
+  name: sweep
+events:
+  pods:
+    - name: web
+      replicas: ${replicas}
+      podSpec:
+        spec:
+          containers:
+            - name: main
+              image: nginx
+`
+
+type fakeManager struct {
+	weights []map[string]int32
+}
+
+func (m *fakeManager) GetPluginWeights(ctx context.Context) (map[string]int32, error) {
+	return nil, nil
+}
+
+func (m *fakeManager) UpdatePluginWeight(ctx context.Context, pluginName string, weight int32) error {
+	return m.UpdatePluginWeights(ctx, map[string]int32{pluginName: weight})
+}
+
+func (m *fakeManager) UpdatePluginWeights(ctx context.Context, weights map[string]int32) error {
+	m.weights = append(m.weights, weights)
+	return nil
+}
+
+func (m *fakeManager) UpdateProfilePluginWeights(ctx context.Context, profile string, weights map[string]int32) error {
+	return m.UpdatePluginWeights(ctx, weights)
+}
+
+func (m *fakeManager) GetConfiguration(ctx context.Context) (*kubescheduler.KubeSchedulerConfiguration, error) {
+	return nil, nil
+}
+
+func (m *fakeManager) UpdateConfiguration(ctx context.Context, config *kubescheduler.KubeSchedulerConfiguration) error {
+	return nil
+}
+
+func (m *fakeManager) ValidatePluginName(pluginName string) error {
+	return nil
+}
+
+type fakeSimulation struct {
+	id       string
+	replicas int
+	// clientset, if set, is where the simulation creates a pod labelled with its ID
+	clientset kubernetes.Interface
+}
+
+func (s *fakeSimulation) GetID() string { return s.id }
+func (s *fakeSimulation) Start(ctx context.Context) error {
+	if s.clientset == nil {
+		return nil
+	}
+	_, err := s.clientset.CoreV1().Pods("default").Create(ctx, simulationPod(s.id), metav1.CreateOptions{})
+	return err
+}
+func (s *fakeSimulation) Stop(ctx context.Context) error             { return nil }
+func (s *fakeSimulation) DeleteCreated(ctx context.Context) error    { return nil }
+func (s *fakeSimulation) RestoreScheduler(ctx context.Context) error { return nil }
+func (s *fakeSimulation) Namespace() string                          { return "default" }
+func (s *fakeSimulation) Pause() error                               { return nil }
+func (s *fakeSimulation) Resume() error                              { return nil }
+func (s *fakeSimulation) Paused() bool                               { return false }
+func (s *fakeSimulation) ReadStats(fn func(stats *cache.Stats))      { fn(s.GetStats()) }
+func (s *fakeSimulation) ReadProgress(fn func(p simulation.Progress)) {
+	fn(simulation.Progress{Stats: s.GetStats()})
+}
+func (s *fakeSimulation) EndReason() simulation.EndReason {
+	return simulation.EndAllEvicted
+}
+
+// simulationPod returns a pod labelled as created by a simulation.
+func simulationPod(simulationID string) *v1.Pod {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-" + simulationID, Namespace: "default"}}
+	kube.Ownership{SimulationID: simulationID}.Stamp(pod)
+	return pod
+}
+
+func (s *fakeSimulation) GetStats() *cache.Stats {
+	stats := cache.NewStats()
+	for i := 0; i < s.replicas; i++ {
+		stats.PendingDurations[cache.Key{Name: string(rune('a' + i))}] = time.Second
+	}
+	return stats
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	scenarioFile := filepath.Join(dir, "scenario.yaml")
+	require.NoError(t, os.WriteFile(scenarioFile, []byte(sweepScenarioYaml), 0o644))
+
+	manager := &fakeManager{}
+	resets := 0
+	runner := NewRunner(logger.Default(), nil, manager,
+		WithOutputDir(filepath.Join(dir, "out")),
+		WithReset(func(ctx context.Context) error {
+			resets++
+			return nil
+		}))
+	runner.newSimulation = func(scenario *simulation.Scenario) simulation.Simulation {
+		return &fakeSimulation{id: "sim-" + scenario.Metadata.Name, replicas: scenario.Events.Pods[0].Replicas}
+	}
+
+	axes := []Axis{
+		{Kind: AxisWeight, Name: "NodeResourcesFit", Values: []string{"1", "5"}},
+		{Kind: AxisParam, Name: "replicas", Values: []string{"2", "3", "many"}},
+	}
+	results, err := runner.Run(context.Background(), scenarioFile, axes)
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+
+	assert.Equal(t, 6, resets)
+	assert.Len(t, manager.weights, 4, "weights are not set when the scenario fails to load")
+	assert.Equal(t, 3, results[1].Summary.ScheduledPods)
+	assert.Error(t, results[2].Err)
+
+	assert.FileExists(t, filepath.Join(dir, "out", "run-001", simulation.ResultsMetadataFile))
+
+	f, err := os.Open(filepath.Join(dir, "out", SummaryFile))
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 7)
+	assert.Equal(t, []string{"run", "weight:NodeResourcesFit", "param:replicas", "repetition", "seed"}, rows[0][:5])
+	assert.Equal(t, []string{"run-002", "1", "3", "1"}, rows[2][:4])
+	assert.Equal(t, "3", rows[2][5])
+	assert.NotEmpty(t, rows[3][len(rows[3])-1])
+	assert.Equal(t, "all-evicted", rows[1][len(rows[1])-3])
+}
+
+func TestRunner_RunRepetitions(t *testing.T) {
+	dir := t.TempDir()
+	scenarioFile := filepath.Join(dir, "scenario.yaml")
+	require.NoError(t, os.WriteFile(scenarioFile, []byte(sweepScenarioYaml), 0o644))
+
+	run := func(out string) []Result {
+		runner := NewRunner(logger.Default(), nil, &fakeManager{},
+			WithOutputDir(filepath.Join(dir, out)), WithRepetitions(3), WithSeed(7))
+		runner.newSimulation = func(scenario *simulation.Scenario) simulation.Simulation {
+			return &fakeSimulation{id: "sim", replicas: 2}
+		}
+		results, err := runner.Run(context.Background(), scenarioFile, nil)
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		return results
+	}
+
+	results, again := run("first"), run("second")
+	seeds := make(map[int64]bool)
+	for i, r := range results {
+		assert.Equal(t, i+1, r.Repetition)
+		assert.Equal(t, again[i].Seed, r.Seed, "the base seed determines the repetition seeds")
+		seeds[r.Seed] = true
+	}
+	assert.Len(t, seeds, 3)
+
+	assert.FileExists(t, filepath.Join(dir, "first", "rep-002", simulation.ResultsMetadataFile))
+	f, err := os.Open(filepath.Join(dir, "first", AggregateFile))
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"run-001", "3"}, rows[1][:2])
+}
+
+func TestRunner_RunInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	scenarioFile := filepath.Join(dir, "scenario.yaml")
+	require.NoError(t, os.WriteFile(scenarioFile, []byte(sweepScenarioYaml), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := NewRunner(logger.Default(), nil, &fakeManager{}, WithOutputDir(filepath.Join(dir, "out")), WithRepetitions(3))
+	runner.newSimulation = func(scenario *simulation.Scenario) simulation.Simulation {
+		// the first repetition is interrupted
+		cancel()
+		return &fakeSimulation{id: "sim", replicas: 1}
+	}
+
+	results, err := runner.Run(ctx, scenarioFile, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, results, 1, "no repetition starts after the interrupt")
+	assert.FileExists(t, filepath.Join(dir, "out", SummaryFile), "the summary of the runs done is written")
+	assert.FileExists(t, filepath.Join(dir, "out", AggregateFile))
+}
+
+func TestRunner_Cleanup(t *testing.T) {
+	dir := t.TempDir()
+	scenarioFile := filepath.Join(dir, "scenario.yaml")
+	require.NoError(t, os.WriteFile(scenarioFile, []byte(sweepScenarioYaml), 0o644))
+
+	// a simulation of another user on the same cluster
+	clientset := fake.NewSimpleClientset(simulationPod("sim-other"))
+	runner := NewRunner(logger.Default(), clientset, &fakeManager{},
+		WithOutputDir(filepath.Join(dir, "out")), WithRepetitions(2), WithCleanup(true))
+	rep := 0
+	runner.newSimulation = func(scenario *simulation.Scenario) simulation.Simulation {
+		rep++
+		return &fakeSimulation{id: fmt.Sprintf("sim-%d", rep), replicas: 1, clientset: clientset}
+	}
+
+	results, err := runner.Run(context.Background(), scenarioFile, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	pods, err := clientset.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 1, "the pods of the runs are deleted")
+	assert.Equal(t, "pod-sim-other", pods.Items[0].Name, "the objects of other simulations survive")
+}
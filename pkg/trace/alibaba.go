@@ -0,0 +1,66 @@
+package trace
+
+import (
+	"fmt"
+	"time"
+)
+
+// alibabaMachineCores are the cores of a machine of the Alibaba cluster trace 2018.
+const alibabaMachineCores = 96
+
+// alibabaParser reads the batch_task table of the Alibaba cluster trace 2018: task name, instance
+// number, job name, task type, status, start time (seconds), end time (seconds), planned CPU
+// (100 is one core) and planned memory (normalized, 100 is a machine).
+//
+// The trace has no submission time, tasks arrive when they start. Each instance of a task is a replica.
+type alibabaParser struct {
+	list []Task
+}
+
+func (p *alibabaParser) parse(record []string) error {
+	name, err := field(record, 0, "task name")
+	if err != nil {
+		return err
+	}
+	instances, err := intField(record, 1, "instance number")
+	if err != nil {
+		return err
+	}
+	job, err := field(record, 2, "job name")
+	if err != nil {
+		return err
+	}
+	start, err := intField(record, 5, "start time")
+	if err != nil {
+		return err
+	}
+	end, err := intField(record, 6, "end time")
+	if err != nil {
+		return err
+	}
+	cpu, err := floatField(record, 7, "planned CPU")
+	if err != nil {
+		return err
+	}
+	memory, err := floatField(record, 8, "planned memory")
+	if err != nil {
+		return err
+	}
+
+	task := Task{
+		ID:       fmt.Sprintf("%s-%s", job, name),
+		Submit:   time.Duration(start) * time.Second,
+		CPU:      cpu / 100 / alibabaMachineCores,
+		Memory:   memory / 100,
+		Replicas: int(max(instances, 1)),
+	}
+	if end > start {
+		task.Duration = time.Duration(end-start) * time.Second
+	}
+	p.list = append(p.list, task)
+	return nil
+}
+
+func (p *alibabaParser) tasks() []Task {
+	return p.list
+}
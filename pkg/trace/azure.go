@@ -0,0 +1,78 @@
+package trace
+
+import (
+	"strings"
+	"time"
+)
+
+// azureParser reads the vmtable of the Azure public dataset: VM ID, subscription ID, deployment ID,
+// creation time (seconds), deletion time (seconds), max CPU, average CPU, p95 max CPU, category,
+// core count bucket and memory bucket (GB). Buckets such as ">24" count as their bound.
+//
+// Each VM is a task. The dataset has no machines, so requests are normalized to the largest VM.
+type azureParser struct {
+	list []Task
+}
+
+func (p *azureParser) parse(record []string) error {
+	id, err := field(record, 0, "VM ID")
+	if err != nil {
+		return err
+	}
+	created, err := intField(record, 3, "creation time")
+	if err != nil {
+		return err
+	}
+	deleted, err := intField(record, 4, "deletion time")
+	if err != nil {
+		return err
+	}
+	buckets := trimBuckets(record)
+	cores, err := floatField(buckets, 9, "core count")
+	if err != nil {
+		return err
+	}
+	memory, err := floatField(buckets, 10, "memory")
+	if err != nil {
+		return err
+	}
+
+	task := Task{
+		ID:       id,
+		Submit:   time.Duration(created) * time.Second,
+		CPU:      cores,
+		Memory:   memory,
+		Replicas: 1,
+	}
+	if deleted > created {
+		task.Duration = time.Duration(deleted-created) * time.Second
+	}
+	p.list = append(p.list, task)
+	return nil
+}
+
+// tasks normalizes the requests to the largest VM of the trace.
+func (p *azureParser) tasks() []Task {
+	var maxCPU, maxMemory float64
+	for _, t := range p.list {
+		maxCPU, maxMemory = max(maxCPU, t.CPU), max(maxMemory, t.Memory)
+	}
+	for i := range p.list {
+		if maxCPU > 0 {
+			p.list[i].CPU /= maxCPU
+		}
+		if maxMemory > 0 {
+			p.list[i].Memory /= maxMemory
+		}
+	}
+	return p.list
+}
+
+// trimBuckets returns a copy of the record without the ">" of open-ended buckets.
+func trimBuckets(record []string) []string {
+	trimmed := make([]string, len(record))
+	for i, f := range record {
+		trimmed[i] = strings.TrimPrefix(strings.TrimSpace(f), ">")
+	}
+	return trimmed
+}
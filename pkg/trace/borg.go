@@ -0,0 +1,121 @@
+package trace
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Event types of the Google cluster data task_events table.
+const (
+	borgSubmit   = 0
+	borgSchedule = 1
+	borgEvict    = 2
+	borgLost     = 6
+)
+
+// borgParser reads the task_events table of the Google cluster data 2011: timestamp (microseconds),
+// missing info, job ID, task index, machine ID, event type, user, scheduling class, priority,
+// CPU request, memory request, ... Requests are normalized to the largest machine of the trace.
+//
+// A task arrives at its first submission and runs from its first schedule to the next eviction,
+// failure, completion, kill or loss. Resubmissions are ignored, and tasks ended before being
+// scheduled are dropped, as they never ran. Events after the end of the trace have the timestamp MAXINT
+// and are ignored, so a task ending after the end of the trace does not end.
+type borgParser struct {
+	byKey map[string]*borgTask
+	order []*borgTask
+}
+
+type borgTask struct {
+	Task
+	scheduledAt time.Duration
+	scheduled   bool
+	done        bool
+	dropped     bool
+}
+
+func newBorgParser() *borgParser {
+	return &borgParser{byKey: make(map[string]*borgTask)}
+}
+
+func (p *borgParser) parse(record []string) error {
+	timestamp, err := intField(record, 0, "timestamp")
+	if err != nil {
+		return err
+	}
+	job, err := field(record, 2, "job ID")
+	if err != nil {
+		return err
+	}
+	index, err := field(record, 3, "task index")
+	if err != nil {
+		return err
+	}
+	eventType, err := intField(record, 5, "event type")
+	if err != nil {
+		return err
+	}
+	cpu, err := floatField(record, 9, "CPU request")
+	if err != nil {
+		return err
+	}
+	memory, err := floatField(record, 10, "memory request")
+	if err != nil {
+		return err
+	}
+	if timestamp == math.MaxInt64 {
+		return nil
+	}
+	if timestamp < 0 || timestamp > math.MaxInt64/int64(time.Microsecond) {
+		return fmt.Errorf("timestamp %d out of range", timestamp)
+	}
+	at := time.Duration(timestamp) * time.Microsecond
+
+	key := fmt.Sprintf("%s-%s", job, index)
+	task, ok := p.byKey[key]
+	if !ok {
+		if eventType >= borgEvict && eventType <= borgLost {
+			// ended before its submission is in the trace
+			return nil
+		}
+		task = &borgTask{Task: Task{ID: key, Submit: at, CPU: cpu, Memory: memory, Replicas: 1}}
+		p.byKey[key] = task
+		p.order = append(p.order, task)
+	}
+	if task.done {
+		return nil
+	}
+
+	switch {
+	case eventType == borgSubmit:
+	case eventType == borgSchedule:
+		if !task.scheduled {
+			task.scheduled = true
+			task.scheduledAt = at
+		}
+	case eventType >= borgEvict && eventType <= borgLost:
+		task.done = true
+		if task.scheduled {
+			task.Duration = at - task.scheduledAt
+		} else {
+			task.dropped = true
+		}
+	default:
+		// updates of a task not running yet change its requests
+		if !task.scheduled && (cpu > 0 || memory > 0) {
+			task.CPU, task.Memory = cpu, memory
+		}
+	}
+	return nil
+}
+
+func (p *borgParser) tasks() []Task {
+	tasks := make([]Task, 0, len(p.order))
+	for _, t := range p.order {
+		if !t.dropped {
+			tasks = append(tasks, t.Task)
+		}
+	}
+	return tasks
+}
@@ -0,0 +1,216 @@
+package trace
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/kubernetes"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TaskAnnotation is the pod annotation holding the ID of the trace task.
+const TaskAnnotation = "kube-event-generator/trace-task"
+
+// DefaultImage is the container image of the imported pods.
+const DefaultImage = "registry.k8s.io/pause:3.9"
+
+// ImportOpt configures how tasks are converted to a scenario.
+type ImportOpt func(*importOptions)
+
+type importOptions struct {
+	name        string
+	windowStart time.Duration
+	windowEnd   time.Duration
+	sample      float64
+	seed        int64
+	limit       int
+	nodes       int
+	nodeCPU     resource.Quantity
+	nodeMemory  resource.Quantity
+	image       string
+}
+
+// WithName sets the name of the scenario and the prefix of the pod names.
+func WithName(name string) ImportOpt {
+	return func(o *importOptions) {
+		o.name = name
+	}
+}
+
+// WithWindow keeps the tasks submitted in [start, end) of the trace time. A zero end keeps all the
+// tasks submitted after start.
+func WithWindow(start, end time.Duration) ImportOpt {
+	return func(o *importOptions) {
+		o.windowStart = start
+		o.windowEnd = end
+	}
+}
+
+// WithSample keeps each task with the given probability, drawn from the seed.
+func WithSample(fraction float64, seed int64) ImportOpt {
+	return func(o *importOptions) {
+		o.sample = fraction
+		o.seed = seed
+	}
+}
+
+// WithLimit keeps at most n tasks. Zero means no limit.
+func WithLimit(n int) ImportOpt {
+	return func(o *importOptions) {
+		o.limit = n
+	}
+}
+
+// WithCluster sets the target cluster: count nodes of the given size. The requests of the tasks,
+// fractions of a trace machine, are scaled to the node size.
+func WithCluster(count int, cpu, memory resource.Quantity) ImportOpt {
+	return func(o *importOptions) {
+		o.nodes = count
+		o.nodeCPU = cpu
+		o.nodeMemory = memory
+	}
+}
+
+// WithImage sets the container image of the pods.
+func WithImage(image string) ImportOpt {
+	return func(o *importOptions) {
+		o.image = image
+	}
+}
+
+// ToScenario converts trace tasks to a scenario. Submission times become arrival times, relative to
+// the first task kept, and durations become eviction times.
+func ToScenario(tasks []Task, opts ...ImportOpt) (*simulation.Scenario, error) {
+	o := &importOptions{
+		name:       "trace",
+		sample:     1,
+		nodes:      10,
+		nodeCPU:    resource.MustParse("4"),
+		nodeMemory: resource.MustParse("16Gi"),
+		image:      DefaultImage,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.sample <= 0 || o.sample > 1 {
+		return nil, fmt.Errorf("sample must be in (0, 1], got %g", o.sample)
+	}
+	if o.windowEnd > 0 && o.windowEnd <= o.windowStart {
+		return nil, fmt.Errorf("window end %s must be after its start %s", o.windowEnd, o.windowStart)
+	}
+	if o.nodeCPU.Sign() <= 0 || o.nodeMemory.Sign() <= 0 {
+		return nil, fmt.Errorf("node cpu and memory must be positive")
+	}
+
+	kept := o.selectTasks(tasks)
+
+	scenario := &simulation.Scenario{
+		Metadata: simulation.Metadata{
+			Name:        o.name,
+			Description: fmt.Sprintf("%d of %d trace tasks", len(kept), len(tasks)),
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	for i := 0; i < o.nodes; i++ {
+		scenario.Cluster.Nodes = append(scenario.Cluster.Nodes, o.node(i))
+	}
+
+	var origin time.Duration
+	if len(kept) > 0 {
+		origin = kept[0].Submit
+	}
+	for i, task := range kept {
+		name := fmt.Sprintf("%s-%05d", o.name, i)
+		event := simulation.PodEvent{
+			Name:        name,
+			ArrivalTime: simulation.EventDuration(task.Submit - origin),
+			EvictTime:   simulation.EventDuration(task.Duration),
+			PodSpec:     o.pod(name, task),
+			EventType:   simulation.PodEventTypeCreate,
+		}
+		if task.Replicas > 1 {
+			event.Replicas = task.Replicas
+		}
+		scenario.Events.Pods = append(scenario.Events.Pods, event)
+	}
+	return scenario, nil
+}
+
+// selectTasks applies the window, the sampling and the limit.
+func (o *importOptions) selectTasks(tasks []Task) []Task {
+	rng := rand.New(rand.NewSource(o.seed))
+	kept := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Submit < o.windowStart || (o.windowEnd > 0 && task.Submit >= o.windowEnd) {
+			continue
+		}
+		// draw for every task in the window, so the sample does not depend on the limit
+		if rng.Float64() >= o.sample {
+			continue
+		}
+		if o.limit > 0 && len(kept) == o.limit {
+			break
+		}
+		kept = append(kept, task)
+	}
+	return kept
+}
+
+// node returns the i-th node of the target cluster.
+func (o *importOptions) node(i int) *v1.Node {
+	resources := v1.ResourceList{
+		v1.ResourceCPU:    o.nodeCPU,
+		v1.ResourceMemory: o.nodeMemory,
+		v1.ResourcePods:   resource.MustParse("110"),
+	}
+	return &v1.Node{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+		ObjectMeta: metav1.ObjectMeta{Name: kubernetes.ObjectFactory.GenerateNodeName(o.name+"-node", i)},
+		Status: v1.NodeStatus{
+			Capacity:    resources,
+			Allocatable: resources.DeepCopy(),
+		},
+	}
+}
+
+// pod returns the pod spec of a task, its requests scaled to the node size.
+func (o *importOptions) pod(name string, task Task) *v1.Pod {
+	requests := v1.ResourceList{}
+	if task.CPU > 0 {
+		millis := int64(math.Ceil(task.CPU * float64(o.nodeCPU.MilliValue())))
+		requests[v1.ResourceCPU] = *resource.NewMilliQuantity(millis, resource.DecimalSI)
+	}
+	if task.Memory > 0 {
+		// rounded up to a mebibyte, to keep the quantities readable
+		mebibytes := int64(math.Ceil(task.Memory * float64(o.nodeMemory.Value()) / (1 << 20)))
+		requests[v1.ResourceMemory] = *resource.NewQuantity(mebibytes<<20, resource.BinarySI)
+	}
+
+	return &v1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{TaskAnnotation: task.ID},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:      "task",
+				Image:     o.image,
+				Resources: v1.ResourceRequirements{Requests: requests},
+			}},
+		},
+	}
+}
+
+// sortTasks sorts tasks by submission time, keeping the trace order of simultaneous tasks.
+func sortTasks(tasks []Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Submit < tasks[j].Submit
+	})
+}
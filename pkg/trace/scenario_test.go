@@ -0,0 +1,105 @@
+package trace
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func testTasks(n int) []Task {
+	tasks := make([]Task, 0, n)
+	for i := 0; i < n; i++ {
+		tasks = append(tasks, Task{
+			ID:       fmt.Sprintf("task-%d", i),
+			Submit:   time.Duration(i) * time.Minute,
+			Duration: 30 * time.Second,
+			CPU:      0.25,
+			Memory:   0.1,
+			Replicas: 1,
+		})
+	}
+	return tasks
+}
+
+func TestToScenario(t *testing.T) {
+	tasks := testTasks(3)
+	tasks[2].Replicas = 4
+	scenario, err := ToScenario(tasks,
+		WithName("borg"),
+		WithCluster(2, resource.MustParse("8"), resource.MustParse("10Gi")))
+	require.NoError(t, err)
+
+	assert.Equal(t, "borg", scenario.Metadata.Name)
+	require.Len(t, scenario.Cluster.Nodes, 2)
+	assert.Equal(t, "borg-node-1", scenario.Cluster.Nodes[1].Name)
+	assert.Equal(t, "8", scenario.Cluster.Nodes[0].Status.Allocatable.Cpu().String())
+
+	require.Len(t, scenario.Events.Pods, 3)
+	event := scenario.Events.Pods[1]
+	assert.Equal(t, "borg-00001", event.Name)
+	assert.Equal(t, simulation.EventDuration(time.Minute), event.ArrivalTime)
+	assert.Equal(t, simulation.EventDuration(30*time.Second), event.EvictTime)
+	assert.Equal(t, "task-1", event.PodSpec.Annotations[TaskAnnotation])
+	requests := event.PodSpec.Spec.Containers[0].Resources.Requests
+	assert.Equal(t, int64(2000), requests.Cpu().MilliValue())
+	assert.Equal(t, int64(1024<<20), requests.Memory().Value())
+	assert.Equal(t, 4, scenario.Events.Pods[2].Replicas)
+
+	// the imported scenario is valid and loads back
+	data, err := simulation.MarshalScenario(scenario)
+	require.NoError(t, err)
+	reloaded, err := simulation.Load(data)
+	require.NoError(t, err)
+	assert.Len(t, reloaded.Events.Pods, 3)
+	assert.Empty(t, reloaded.Events.Pods[0].PodSpec.Namespace, "the pods run in the namespace of the simulation")
+}
+
+func TestToScenario_Selection(t *testing.T) {
+	tasks := testTasks(100)
+
+	tests := []struct {
+		name  string
+		opts  []ImportOpt
+		count int
+	}{
+		{name: "all", count: 100},
+		{name: "window", opts: []ImportOpt{WithWindow(10*time.Minute, 20*time.Minute)}, count: 10},
+		{name: "open window", opts: []ImportOpt{WithWindow(90*time.Minute, 0)}, count: 10},
+		{name: "limit", opts: []ImportOpt{WithLimit(5)}, count: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario, err := ToScenario(tasks, tt.opts...)
+			require.NoError(t, err)
+			assert.Len(t, scenario.Events.Pods, tt.count)
+			// arrivals are relative to the first task kept
+			assert.Equal(t, simulation.EventDuration(0), scenario.Events.Pods[0].ArrivalTime)
+		})
+	}
+
+	sampled, err := ToScenario(tasks, WithSample(0.3, 42))
+	require.NoError(t, err)
+	assert.Greater(t, len(sampled.Events.Pods), 10)
+	assert.Less(t, len(sampled.Events.Pods), 50)
+	again, err := ToScenario(tasks, WithSample(0.3, 42))
+	require.NoError(t, err)
+	assert.Equal(t, annotations(sampled), annotations(again))
+
+	_, err = ToScenario(tasks, WithSample(0, 1))
+	assert.Error(t, err)
+	_, err = ToScenario(tasks, WithWindow(time.Hour, time.Minute))
+	assert.Error(t, err)
+}
+
+func annotations(s *simulation.Scenario) []string {
+	ids := make([]string, 0, len(s.Events.Pods))
+	for _, e := range s.Events.Pods {
+		ids = append(ids, e.PodSpec.Annotations[TaskAnnotation])
+	}
+	return ids
+}
@@ -0,0 +1,151 @@
+// Package trace converts public cluster traces into scenarios.
+package trace
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the format of a cluster trace.
+type Format string
+
+const (
+	// FormatGoogleBorg is the task_events table of the Google cluster data 2011.
+	FormatGoogleBorg Format = "google-borg"
+	// FormatAlibaba is the batch_task table of the Alibaba cluster trace 2018.
+	FormatAlibaba Format = "alibaba"
+	// FormatAzure is the vmtable of the Azure public dataset.
+	FormatAzure Format = "azure"
+)
+
+// Formats are the supported trace formats.
+var Formats = []Format{FormatGoogleBorg, FormatAlibaba, FormatAzure}
+
+// ParseFormat parses a trace format name.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown trace format %q, expected one of %s", name, strings.Join(formatNames(), ", "))
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+	return names
+}
+
+// Task is a unit of work of a trace, run by one pod per replica.
+type Task struct {
+	// ID identifies the task in the trace
+	ID string
+	// Submit is the submission time since the start of the trace
+	Submit time.Duration
+	// Duration is the running time of the task. Zero means it does not end within the trace.
+	Duration time.Duration
+	// CPU is the requested CPU as a fraction of a trace machine
+	CPU float64
+	// Memory is the requested memory as a fraction of a trace machine
+	Memory float64
+	// Replicas is the number of identical instances of the task
+	Replicas int
+}
+
+// Parse reads the tasks of a trace in the given format, sorted by submission time.
+func Parse(r io.Reader, format Format) ([]Task, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var p parser
+	switch format {
+	case FormatGoogleBorg:
+		p = newBorgParser()
+	case FormatAlibaba:
+		p = &alibabaParser{}
+	case FormatAzure:
+		p = &azureParser{}
+	default:
+		return nil, fmt.Errorf("unknown trace format %q", format)
+	}
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// traces are published without a header, skip it if one was added
+		if line == 1 && isHeader(record) {
+			continue
+		}
+		if err := p.parse(record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	tasks := p.tasks()
+	sortTasks(tasks)
+	return tasks, nil
+}
+
+// parser reads the records of a trace format.
+type parser interface {
+	parse(record []string) error
+	tasks() []Task
+}
+
+// isHeader reports whether the record looks like a header: none of its fields is a number.
+func isHeader(record []string) bool {
+	for _, field := range record {
+		if _, err := strconv.ParseFloat(field, 64); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// field returns the field at index i, or an error if the record is too short.
+func field(record []string, i int, name string) (string, error) {
+	if i >= len(record) {
+		return "", fmt.Errorf("missing %s, expected at least %d fields, got %d", name, i+1, len(record))
+	}
+	return strings.TrimSpace(record[i]), nil
+}
+
+// floatField parses the field at index i. An empty field is zero, as traces leave missing values empty.
+func floatField(record []string, i int, name string) (float64, error) {
+	value, err := field(record, i, name)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return f, nil
+}
+
+// intField parses the integer field at index i. An empty field is zero.
+func intField(record []string, i int, name string) (int64, error) {
+	value, err := field(record, i, name)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
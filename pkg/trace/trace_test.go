@@ -0,0 +1,104 @@
+package trace
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// borgTrace has a completed task, a task killed while pending, a task still running at the end of the
+// trace, killed after it, a resubmission of the completed task and a task submitted after the end of the trace.
+var borgTrace = `
+600000000,,1,0,,0,u,0,9,0.125,0.0625,0,0
+600000000,,1,1,,0,u,0,9,0.25,0.125,0,0
+601000000,,1,0,m1,1,u,0,9,0.125,0.0625,0,0
+605000000,,1,1,,5,u,0,9,0.25,0.125,0,0
+661000000,,1,0,m1,4,u,0,9,0.125,0.0625,0,0
+662000000,,1,0,,0,u,0,9,0.125,0.0625,0,0
+700000000,,2,0,,0,u,0,9,0.5,0.25,0,0
+702000000,,2,0,m2,1,u,0,9,0.5,0.25,0,0
+9223372036854775807,,2,0,m2,5,u,0,9,0.5,0.25,0,0
+9223372036854775807,,3,0,,0,u,0,9,0.5,0.25,0,0
+`
+
+var alibabaTrace = `task_name,instance_num,job_name,task_type,status,start_time,end_time,plan_cpu,plan_mem
+M1,10,j_1,1,Terminated,87000,87060,100,0.5
+R2_1,1,j_2,1,Running,86400,0,4800,50
+`
+
+var azureTrace = `
+vm-a,sub,dep,0,3600,90,10,80,Interactive,2,4
+vm-b,sub,dep,300,2592000,90,10,80,Delay-insensitive,>24,>64
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		trace  string
+		want   []Task
+	}{
+		{
+			name:   "google borg",
+			format: FormatGoogleBorg,
+			trace:  borgTrace,
+			want: []Task{
+				{ID: "1-0", Submit: 600 * time.Second, Duration: time.Minute, CPU: 0.125, Memory: 0.0625, Replicas: 1},
+				{ID: "2-0", Submit: 700 * time.Second, CPU: 0.5, Memory: 0.25, Replicas: 1},
+			},
+		},
+		{
+			name:   "alibaba",
+			format: FormatAlibaba,
+			trace:  alibabaTrace,
+			want: []Task{
+				{ID: "j_2-R2_1", Submit: 24 * time.Hour, CPU: 0.5, Memory: 0.5, Replicas: 1},
+				{ID: "j_1-M1", Submit: 87000 * time.Second, Duration: time.Minute, CPU: 1.0 / 96, Memory: 0.005, Replicas: 10},
+			},
+		},
+		{
+			name:   "azure",
+			format: FormatAzure,
+			trace:  azureTrace,
+			want: []Task{
+				{ID: "vm-a", Duration: time.Hour, CPU: 2.0 / 24, Memory: 4.0 / 64, Replicas: 1},
+				{ID: "vm-b", Submit: 5 * time.Minute, Duration: 2591700 * time.Second, CPU: 1, Memory: 1, Replicas: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := Parse(strings.NewReader(strings.TrimSpace(tt.trace)), tt.format)
+			require.NoError(t, err)
+			require.Len(t, tasks, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].ID, tasks[i].ID)
+				assert.Equal(t, tt.want[i].Submit, tasks[i].Submit)
+				assert.Equal(t, tt.want[i].Duration, tasks[i].Duration)
+				assert.InDelta(t, tt.want[i].CPU, tasks[i].CPU, 1e-9)
+				assert.InDelta(t, tt.want[i].Memory, tasks[i].Memory, 1e-9)
+				assert.Equal(t, tt.want[i].Replicas, tasks[i].Replicas)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse(strings.NewReader("600000000,,1,0,,0,u,0,9,0.125,0.0625\n600000000,,1,1,,x,u"), FormatGoogleBorg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `line 2: invalid event type "x"`)
+
+	_, err = Parse(strings.NewReader("9223372036854775806,,1,0,,0,u,0,9,0.125,0.0625"), FormatGoogleBorg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timestamp 9223372036854775806 out of range")
+
+	_, err = Parse(strings.NewReader("M1,1,j_1"), FormatAlibaba)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing start time")
+
+	_, err = ParseFormat("borg")
+	assert.Error(t, err)
+}
@@ -4,22 +4,38 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
 )
 
-var stopCh chan os.Signal
+// ForcedExitCode is the exit code of a process interrupted twice.
+const ForcedExitCode = 130
 
-// GetStopChan returns a channel that will receive os.Signal notifications.
-func GetStopChan() <-chan os.Signal {
-	if stopCh == nil {
-		stopCh = make(chan os.Signal, 1)
-		signal.Notify(stopCh, syscall.SIGINT, syscall.SIGTERM)
-	}
+// OnInterrupt calls fn on the first SIGINT or SIGTERM, and exits the process on the second one. fn must
+// not block: the cleanup it starts runs until the process is interrupted again. The returned function
+// stops handling the signals.
+func OnInterrupt(fn func()) (stop func()) {
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
 
-	return stopCh
-}
+	go func() {
+		select {
+		case <-ch:
+			fn()
+		case <-done:
+			return
+		}
+		select {
+		case <-ch:
+			logger.Default().Errorln("interrupted again, exiting without cleanup")
+			os.Exit(ForcedExitCode)
+		case <-done:
+		}
+	}()
 
-// WaitStopAndExecute waits for a stop signal and then executes the provided function.
-func WaitStopAndExecute(fn func()) {
-	<-GetStopChan()
-	fn()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
 }
@@ -0,0 +1,33 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/offline"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+)
+
+// NewOfflineCluster creates and starts a fake cluster with the nodes of the scenario, and a scheduler
+// profile per scheduler name of the scenario scoring nodes with the given strategy.
+func NewOfflineCluster(ctx context.Context, log *logger.Logger, scenario *simulation.Scenario, strategy string) (*offline.Cluster, error) {
+	s, err := offline.ParseStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+	if len(scenario.Cluster.Nodes) == 0 {
+		return nil, fmt.Errorf("scenario %s declares no cluster nodes, the offline cluster needs them", scenario.Metadata.Name)
+	}
+	if len(scenario.Events.Workloads) > 0 {
+		log.Warnf("the offline cluster has no controllers, workload events do not create pods")
+	}
+
+	cluster, err := offline.NewCluster(log, scenario.Cluster.Nodes, offline.WithStrategy(s), offline.WithProfiles(scenario.SchedulerNames()...))
+	if err != nil {
+		return nil, err
+	}
+	cluster.Start(ctx)
+	log.Infof("offline cluster started with %d node(s), %s scheduling", len(scenario.Cluster.Nodes), s)
+	return cluster, nil
+}
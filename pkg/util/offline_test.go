@@ -0,0 +1,60 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maczg/kube-event-generator/pkg/logger"
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewOfflineCluster(t *testing.T) {
+	scenario, err := simulation.Load([]byte(`
+metadata:
+  name: offline
+cluster:
+  nodes:
+    - metadata:
+        name: node-1
+      status:
+        allocatable: { cpu: "2", memory: 2Gi }
+events:
+  pods:
+    - name: batch
+      arrivalTime: 1s
+      podSpec:
+        metadata:
+          name: batch
+        spec:
+          schedulerName: batch-scheduler
+          containers:
+            - name: main
+              image: nginx
+`))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err = NewOfflineCluster(ctx, logger.Default(), scenario, "round-robin")
+	assert.Error(t, err)
+
+	cluster, err := NewOfflineCluster(ctx, logger.Default(), scenario, "most-allocated")
+	require.NoError(t, err)
+	nodes, err := cluster.Clientset().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, nodes.Items, 1)
+	config, err := cluster.Scheduler().GetConfiguration(ctx)
+	require.NoError(t, err)
+	var profiles []string
+	for _, p := range config.Profiles {
+		profiles = append(profiles, *p.SchedulerName)
+	}
+	assert.Contains(t, profiles, "batch-scheduler")
+
+	scenario.Cluster.Nodes = nil
+	_, err = NewOfflineCluster(ctx, logger.Default(), scenario, "most-allocated")
+	assert.ErrorContains(t, err, "declares no cluster nodes")
+}
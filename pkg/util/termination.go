@@ -0,0 +1,34 @@
+package util
+
+import (
+	"time"
+
+	"github.com/maczg/kube-event-generator/pkg/simulation"
+	"github.com/spf13/cobra"
+)
+
+// TerminationFlags are the end conditions set on the command line.
+type TerminationFlags struct {
+	duration        time.Duration
+	timeout         time.Duration
+	untilDispatched bool
+	pendingIdle     time.Duration
+}
+
+// Register adds the end condition flags to a command.
+func (f *TerminationFlags) Register(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&f.duration, "duration", 0, "End the simulation once its simulated time reaches this duration")
+	cmd.Flags().DurationVar(&f.timeout, "timeout", 0, "End the simulation once this wall clock time has elapsed since its start")
+	cmd.Flags().BoolVar(&f.untilDispatched, "until-dispatched", false, "End the simulation once all its events are dispatched")
+	cmd.Flags().DurationVar(&f.pendingIdle, "pending-idle", 0, "End the simulation once no pod has been pending for this duration")
+}
+
+// Termination returns the end conditions of the flags, overriding the ones of the scenario.
+func (f *TerminationFlags) Termination() *simulation.Termination {
+	return &simulation.Termination{
+		Duration:            simulation.EventDuration(f.duration),
+		Timeout:             simulation.EventDuration(f.timeout),
+		AllEventsDispatched: f.untilDispatched,
+		PendingIdle:         simulation.EventDuration(f.pendingIdle),
+	}
+}